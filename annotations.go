@@ -0,0 +1,51 @@
+package goose
+
+import (
+	"bytes"
+	"strings"
+)
+
+// annotationPrefixes are the prefixes of the annotations of SQL migrations.
+// "-- +goose " is always accepted; the prefixes of other tools can be added
+// so that their migrations are run without being rewritten.
+var annotationPrefixes = []string{sqlCmdPrefix}
+
+// SetAnnotationPrefixes accepts annotations starting with the given
+// prefixes in addition to "-- +goose ", e.g. "-- +migrate" for migrations
+// written for sql-migrate. Files created by goose keep using "-- +goose ".
+func SetAnnotationPrefixes(prefixes ...string) {
+	annotationPrefixes = []string{sqlCmdPrefix}
+	for _, p := range prefixes {
+		if p = strings.TrimSpace(p) + " "; p != sqlCmdPrefix {
+			annotationPrefixes = append(annotationPrefixes, p)
+		}
+	}
+
+	// Statements parsed with the previous prefixes are stale.
+	parsedCacheMu.Lock()
+	parsedCache = map[parsedKey]*parsedSQL{}
+	parsedCacheBytes = 0
+	parsedCacheMu.Unlock()
+}
+
+// annotationCommand returns the command of an annotation line, e.g. "Up"
+// for "-- +goose Up", and whether line is an annotation.
+func annotationCommand(line []byte) ([]byte, bool) {
+	for _, p := range annotationPrefixes {
+		if bytes.HasPrefix(line, []byte(p)) {
+			return bytes.TrimSpace(line[len(p):]), true
+		}
+	}
+	return nil, false
+}
+
+// hasAnnotation reports whether a migration has the annotation cmd on a line
+// of its own.
+func hasAnnotation(b []byte, cmd string) bool {
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		if c, ok := annotationCommand(bytes.TrimRight(line, " \t\r")); ok && string(c) == cmd {
+			return true
+		}
+	}
+	return false
+}
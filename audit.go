@@ -0,0 +1,105 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// AuditOptions configures the goose_audit table, recording every migration
+// applied or rolled back with the identity of the operator.
+type AuditOptions struct {
+	// Operator is recorded with each migration. It defaults to $GOOSE_OPERATOR,
+	// or the name of the OS user running goose.
+	Operator string
+	// Details additionally records the SHA-256 of the executed SQL and the
+	// number of affected rows.
+	Details bool
+}
+
+var audit *AuditOptions
+
+// auditHost is the client_host recorded with each migration.
+var auditHost, _ = os.Hostname()
+
+// createdTables records the goose_audit and run context tables created in
+// each database.
+var createdTables sync.Map
+
+// audit is the goose_audit table, in the schema of the version table.
+func (t gooseTables) audit() string {
+	return t.qualified("goose_audit")
+}
+
+// EnableAudit records every executed migration into the goose_audit table,
+// which is created if needed.
+func EnableAudit(opts AuditOptions) {
+	audit = auditOptions(opts)
+}
+
+// auditOptions returns opts with the default operator.
+func auditOptions(opts AuditOptions) *AuditOptions {
+	if opts.Operator == "" {
+		opts.Operator = os.Getenv("GOOSE_OPERATOR")
+	}
+	if opts.Operator == "" {
+		if u, err := user.Current(); err == nil {
+			opts.Operator = u.Username
+		}
+	}
+	return &opts
+}
+
+type operatorKey struct{}
+
+// withOperator returns a context recording operator in goose_audit, rather
+// than the operator of the audit options, e.g. the operator of a request to
+// the admin server.
+func withOperator(ctx context.Context, operator string) context.Context {
+	return context.WithValue(ctx, operatorKey{}, operator)
+}
+
+func operatorFrom(ctx context.Context) string {
+	operator, _ := ctx.Value(operatorKey{}).(string)
+	return operator
+}
+
+// writeAudit records an applied migration when auditing is enabled. As the
+// migration is committed already, it isn't canceled with ctx, and a failure
+// only logs a warning.
+func writeAudit(ctx context.Context, db *sql.DB, m AppliedMigration, checksum string) {
+	c := configFrom(ctx)
+	if c.audit == nil {
+		return
+	}
+	operator := c.audit.Operator
+	if o := operatorFrom(ctx); o != "" {
+		operator = o
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	table := c.tables.audit()
+	if !tableMarked(&createdTables, db, table) {
+		if _, err := db.ExecContext(ctx, c.dialect.createAuditTableSQL(c.tables)); err != nil {
+			c.log.Printf("WARNING: %s not audited: failed to create %s table: %v\n", m.File, table, err)
+			return
+		}
+		markTable(&createdTables, db, table)
+	}
+
+	var sum sql.NullString
+	var rows sql.NullInt64
+	if c.audit.Details {
+		sum = sql.NullString{String: checksum, Valid: checksum != ""}
+		rows = sql.NullInt64{Int64: m.RowsAffected, Valid: true}
+	}
+
+	_, err := db.ExecContext(ctx, c.dialect.insertAuditSQL(c.tables),
+		m.Version, m.File, m.Direction, operator, auditHost, sum, rows, int64(m.Duration/time.Millisecond))
+	if err != nil {
+		c.log.Printf("WARNING: %s not audited: %v\n", m.File, err)
+	}
+}
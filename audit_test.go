@@ -0,0 +1,86 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAuditFailure(t *testing.T) {
+	db := openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id int);\n")},
+	})
+	defer SetBaseFS(nil)
+	EnableAudit(AuditOptions{Operator: "jane"})
+	defer func() { audit = nil }()
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+
+	// A goose_audit table goose can't insert into.
+	if _, err := db.Exec("CREATE TABLE goose_audit (id integer)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The migration is committed, the run must not fail.
+	if err := RunWithContext(context.Background(), "up", db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := GetDBVersion(db); err != nil || v != 1 {
+		t.Errorf("got version %d, %v, want 1", v, err)
+	}
+	warned := false
+	for _, line := range logger.lines {
+		warned = warned || strings.HasPrefix(line, "WARNING: 00001_a.sql not audited")
+	}
+	if !warned {
+		t.Errorf("no audit warning logged: %q", logger.lines)
+	}
+}
+
+func TestAuditOperatorFromContext(t *testing.T) {
+	db := openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id int);\n")},
+	})
+	defer SetBaseFS(nil)
+	EnableAudit(AuditOptions{Operator: "jane"})
+	defer func() { audit = nil }()
+
+	if err := RunWithContext(withOperator(context.Background(), "alice"), "up", db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	var operator string
+	if err := db.QueryRow("SELECT operator FROM goose_audit").Scan(&operator); err != nil || operator != "alice" {
+		t.Errorf("got operator %q, %v, want alice", operator, err)
+	}
+	if audit.Operator != "jane" {
+		t.Errorf("audit options changed to operator %q", audit.Operator)
+	}
+}
+
+func TestAuditTablePerDatabase(t *testing.T) {
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id int);\n")},
+	})
+	defer SetBaseFS(nil)
+	EnableAudit(AuditOptions{Operator: "jane"})
+	defer func() { audit = nil }()
+
+	// The goose_audit table is created in every database migrated.
+	for _, db := range []*sql.DB{openDuckDB(t), openDuckDB(t)} {
+		if err := RunWithContext(context.Background(), "up", db, "migrations"); err != nil {
+			t.Fatal(err)
+		}
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM goose_audit").Scan(&n); err != nil || n != 1 {
+			t.Errorf("got %d audit rows, %v, want 1", n, err)
+		}
+	}
+}
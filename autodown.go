@@ -0,0 +1,121 @@
+package goose
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+var (
+	createTableRegexp  = regexp.MustCompile(`(?is)^\s*CREATE\s+TABLE\s+(IF\s+NOT\s+EXISTS\s+)?([\w."` + "`" + `]+)`)
+	indexDefRegexp     = regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(IF\s+NOT\s+EXISTS\s+)?([\w."` + "`" + `]+)\s+ON\s+([\w."` + "`" + `]+)`)
+	addColumnRegexp    = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+([\w."` + "`" + `]+)\s+ADD\s+(COLUMN\s+)?(IF\s+NOT\s+EXISTS\s+)?([\w"` + "`" + `]+)[^,]*;?\s*$`)
+	renameColumnRegexp = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+([\w."` + "`" + `]+)\s+RENAME\s+(COLUMN\s+)?([\w"` + "`" + `]+)\s+TO\s+([\w"` + "`" + `]+)\s*;?\s*$`)
+	renameTableRegexp  = regexp.MustCompile(`(?is)^\s*(?:ALTER\s+TABLE\s+([\w."` + "`" + `]+)\s+RENAME\s+TO|RENAME\s+TABLE\s+([\w."` + "`" + `]+)\s+TO)\s+([\w."` + "`" + `]+)\s*;?\s*$`)
+)
+
+// tableElementKeywords start the ALTER TABLE ADD clauses that don't add a
+// column.
+var tableElementKeywords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "FOREIGN": true, "UNIQUE": true,
+	"CHECK": true, "INDEX": true, "KEY": true, "FULLTEXT": true, "SPATIAL": true,
+}
+
+// sqlStatements parses the statements of one direction of a migration
+// like parseSQLStatements, generating the Down statements of migrations
+// whose Down section is marked "-- +goose AutoDown".
+func sqlStatements(c *config, r io.Reader, direction bool) (stmts []string, lines []int, tx bool, err error) {
+	if direction {
+		return parseSQLStatements(c, r, direction)
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if stmts, lines, tx, err = parseSQLStatements(c, bytes.NewReader(b), direction); err != nil {
+		return nil, nil, false, err
+	}
+	if !hasAnnotation(b, "AutoDown") {
+		return stmts, lines, tx, nil
+	}
+	for _, s := range stmts {
+		if strings.TrimSpace(stripComments(s)) != "" {
+			return nil, nil, false, fmt.Errorf("AutoDown: the Down section must not have statements")
+		}
+	}
+
+	up, upLines, _, err := parseSQLStatements(c, bytes.NewReader(b), true)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	stmts, lines = nil, nil
+	for i := len(up) - 1; i >= 0; i-- {
+		down, err := reverseStatement(c, up[i])
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("line %d: AutoDown: %v", upLines[i], err)
+		}
+		stmts = append(stmts, down)
+		lines = append(lines, upLines[i])
+	}
+	return stmts, lines, tx, nil
+}
+
+// reverseStatement returns the statement undoing query, for CREATE TABLE,
+// CREATE INDEX, ALTER TABLE ADD COLUMN and renames.
+func reverseStatement(c *config, query string) (string, error) {
+	if _, ok := parseLoad(query); ok {
+		return "", fmt.Errorf("can't reverse fixture loads, write the Down section")
+	}
+	q := stripComments(query)
+	_, mysql := c.dialect.(*MySQLDialect)
+	if _, ok := c.dialect.(*TiDBDialect); ok {
+		mysql = true
+	}
+
+	if m := createTableRegexp.FindStringSubmatch(q); m != nil {
+		if m[1] != "" {
+			return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", m[2]), nil
+		}
+		return fmt.Sprintf("DROP TABLE %s;\n", m[2]), nil
+	}
+	if m := indexDefRegexp.FindStringSubmatch(q); m != nil {
+		if mysql {
+			return fmt.Sprintf("DROP INDEX %s ON %s;\n", m[4], m[5]), nil
+		}
+		drop := "DROP INDEX "
+		if m[2] != "" {
+			drop += "CONCURRENTLY "
+		}
+		if m[3] != "" {
+			drop += "IF EXISTS "
+		}
+		return drop + m[4] + ";\n", nil
+	}
+	if m := renameTableRegexp.FindStringSubmatch(q); m != nil {
+		from := m[1] + m[2]
+		if mysql {
+			return fmt.Sprintf("RENAME TABLE %s TO %s;\n", m[3], from), nil
+		}
+		return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", m[3], from), nil
+	}
+	if m := renameColumnRegexp.FindStringSubmatch(q); m != nil {
+		return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;\n", m[1], m[4], m[3]), nil
+	}
+	if m := addColumnRegexp.FindStringSubmatch(q); m != nil && !tableElementKeywords[strings.ToUpper(m[4])] {
+		return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", m[1], m[4]), nil
+	}
+	return "", fmt.Errorf("can't reverse %q, write the Down section", strings.TrimSpace(firstLine(q)))
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package goose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoDown(t *testing.T) {
+	sql := `-- +goose Up
+CREATE TABLE users (id int PRIMARY KEY, login text);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS users_login ON users (login);
+ALTER TABLE users ADD COLUMN email text NOT NULL DEFAULT '';
+ALTER TABLE users RENAME COLUMN login TO username;
+ALTER TABLE accounts RENAME TO customers;
+
+-- +goose Down
+-- +goose AutoDown
+`
+	stmts, lines, _, err := sqlStatements(packageConfig(), strings.NewReader(sql), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ALTER TABLE customers RENAME TO accounts;\n",
+		"ALTER TABLE users RENAME COLUMN username TO login;\n",
+		"ALTER TABLE users DROP COLUMN email;\n",
+		"DROP INDEX CONCURRENTLY IF EXISTS users_login;\n",
+		"DROP TABLE users;\n",
+	}
+	if strings.Join(stmts, "") != strings.Join(want, "") {
+		t.Errorf("got %q, want %q", stmts, want)
+	}
+	if len(lines) != 5 || lines[0] != 6 || lines[4] != 2 {
+		t.Errorf("got lines %v", lines)
+	}
+
+	for _, sql := range []string{
+		"-- +goose Up\nUPDATE users SET active = true;\n-- +goose Down\n-- +goose AutoDown\n",
+		"-- +goose Up\nALTER TABLE users ADD CONSTRAINT users_login UNIQUE (login);\n-- +goose Down\n-- +goose AutoDown\n",
+		"-- +goose Up\nCREATE TABLE t (id int);\n-- +goose Down\n-- +goose AutoDown\nDROP TABLE t;\n",
+	} {
+		if _, _, _, err := sqlStatements(packageConfig(), strings.NewReader(sql), false); err == nil {
+			t.Errorf("%q: expected an error", sql)
+		}
+	}
+}
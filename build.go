@@ -0,0 +1,198 @@
+package goose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"text/template"
+)
+
+const goosePkg = "github.com/gojuno/goose"
+
+// Driver is the database/sql driver a migrator is built with.
+type Driver struct {
+	Name    string // name the driver registers with database/sql
+	Package string // import path of the package registering it
+	CGO     bool   // whether the package needs cgo
+}
+
+// buildGooseDir, when set, is a goose checkout migrators are built against
+// instead of a released version.
+var buildGooseDir string
+
+// Build generates a main package embedding the SQL migrations found in dir
+// and the given driver for the dialect, and compiles it into a single
+// binary at output, static unless the driver needs cgo.
+//
+// The resulting binary accepts the usual goose commands:
+//
+//	./migrator -dbstring="user=postgres dbname=app" up
+func Build(dir, dialect string, driver Driver, output string) error {
+	if _, err := lookupDialect(dialect); err != nil {
+		return err
+	}
+
+	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return fmt.Errorf("no migrations found in %s", dir)
+	}
+
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir("", "goose-build")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	embedDir := filepath.Join(tmp, "migrations")
+	if err := os.Mkdir(embedDir, 0755); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if !isSQLMigration(m.Source) {
+			return fmt.Errorf("%s: only SQL migrations can be embedded, Go migrations need a custom binary", filepath.Base(m.Source))
+		}
+		files := []string{m.Source}
+		if isPairedMigration(m.Source) {
+			files = append(files, pairedDownFile(m.Source))
+		}
+		for _, file := range files {
+			if err := copyFile(file, filepath.Join(embedDir, filepath.Base(file))); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(tmp, "go.mod"), []byte("module goose-migrator\n\ngo 1.17\n"), 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(tmp, "main.go"))
+	if err != nil {
+		return err
+	}
+	err = buildMainTemplate.Execute(f, map[string]string{
+		"Dialect":   dialect,
+		"Driver":    driver.Name,
+		"DriverPkg": driver.Package,
+	})
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	env := []string{"CGO_ENABLED=0"}
+	if driver.CGO {
+		env = []string{"CGO_ENABLED=1"}
+	}
+	gooseDep := []string{"get", goosePkg + "@" + gooseModuleVersion()}
+	if buildGooseDir != "" {
+		gooseDep = []string{"mod", "edit", "-require=" + goosePkg + "@v0.0.0", "-replace=" + goosePkg + "=" + buildGooseDir}
+	}
+	// Resolving the driver while building takes the version goose requires.
+	for _, args := range [][]string{gooseDep, {"build", "-mod=mod", "-o", output, "."}} {
+		if err := goCommand(tmp, env, args...); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Built migrator with %d migrations: %s\n", len(migrations), output)
+	return nil
+}
+
+// gooseModuleVersion returns the goose version the running binary was built
+// with, so that the generated migrator behaves the same way.
+func gooseModuleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "latest"
+	}
+	if info.Main.Path == goosePkg && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == goosePkg {
+			return dep.Version
+		}
+	}
+	return "latest"
+}
+
+func goCommand(dir string, env []string, args ...string) error {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go %s: %v", args[0], err)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	b, err := readFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, b, 0644)
+}
+
+var buildMainTemplate = template.Must(template.New("goose.build-main").Parse(`// Code generated by goose build. DO NOT EDIT.
+
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/gojuno/goose"
+
+	_ "{{.DriverPkg}}"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+var dbstring = flag.String("dbstring", os.Getenv("GOOSE_DBSTRING"), "db conn string")
+
+func main() {
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 1 {
+		log.Fatal("usage: migrator [-dbstring=DBSTRING] COMMAND")
+	}
+	if *dbstring == "" {
+		log.Fatal("-dbstring or GOOSE_DBSTRING must be set")
+	}
+
+	goose.SetBaseFS(migrations)
+	if err := goose.SetDialect("{{.Dialect}}"); err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := sql.Open("{{.Driver}}", *dbstring)
+	if err != nil {
+		log.Fatalf("-dbstring=%q: %v", *dbstring, err)
+	}
+	defer db.Close()
+
+	if err := goose.Run(args[0], db, "migrations", args[1:]...); err != nil {
+		log.Fatalf("goose run: %v", err)
+	}
+}
+`))
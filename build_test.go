@@ -0,0 +1,65 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a binary")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir, err := ioutil.TempDir("", "goose-build-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "00001_init.sql"), []byte(multitxt), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Build against this checkout with the modules already downloaded.
+	src, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buildGooseDir = src
+	defer func() { buildGooseDir = "" }()
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	output := filepath.Join(dir, "migrator")
+	if err := Build(dir, "postgres", Driver{Name: "postgres", Package: "github.com/lib/pq"}, output); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(output).CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "usage: migrator") {
+		t.Errorf("migrator without a command: got %v, %q; want its usage", err, out)
+	}
+
+	if err := Build(dir, "oracle", Driver{}, output); err == nil {
+		t.Error("expected building for an unknown dialect to fail")
+	}
+
+	// Embedded migrations are read through the base filesystem.
+	SetBaseFS(fstest.MapFS{"migrations/00001_init.sql": {Data: []byte(multitxt)}})
+	defer SetBaseFS(nil)
+	copied := filepath.Join(dir, "copied.sql")
+	if err := copyFile("migrations/00001_init.sql", copied); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(copied); string(b) != multitxt {
+		t.Errorf("unexpected copy of an embedded migration: %q", b)
+	}
+}
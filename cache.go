@@ -0,0 +1,68 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cacheDir holds content fetched from remote migration sources, keyed by
+// digest. Caching is disabled when empty.
+var cacheDir string
+
+// SetCacheDir sets the directory caching files fetched from remote
+// migration sources, so repeated runs don't download them again.
+// An empty dir disables the cache.
+func SetCacheDir(dir string) {
+	cacheDir = dir
+}
+
+func cachePath(digest string) string {
+	return filepath.Join(cacheDir, strings.Replace(digest, ":", string(filepath.Separator), 1))
+}
+
+// readCache returns the cached content for digest. Entries that don't match
+// their digest are ignored.
+func readCache(digest string) ([]byte, bool) {
+	if cacheDir == "" {
+		return nil, false
+	}
+	b, err := ioutil.ReadFile(cachePath(digest))
+	if err != nil || verifyDigest(b, digest) != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// writeCache stores verified content. Failures only disable caching for
+// this entry.
+func writeCache(digest string, b []byte) {
+	if cacheDir == "" {
+		return
+	}
+	path := cachePath(digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("goose: cache: %v\n", err)
+		return
+	}
+
+	// Write to a temporary file first so concurrent runs never read a
+	// partial entry.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		log.Printf("goose: cache: %v\n", err)
+		return
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("goose: cache: %v\n", err)
+	}
+}
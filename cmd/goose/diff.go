@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/gojuno/goose"
+)
+
+// runDiff implements the diff command:
+//
+//	diff [-name=NAME] [-shadow=DBSTRING] REFERENCE
+//
+// REFERENCE is the dbstring of a database with the desired schema, or a SQL
+// file loaded into the empty -shadow database.
+func runDiff(driver, dbstring string, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	name := fs.String("name", "schema_diff", "name of the generated migration")
+	shadow := fs.String("shadow", "", "dbstring of an empty database the desired schema file is loaded into")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("diff must be of form: goose [OPTIONS] diff [-name=NAME] [-shadow=DBSTRING] REFERENCE")
+	}
+	ref := fs.Arg(0)
+
+	db, err := sql.Open(driver, dbstring)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var desired *sql.DB
+	if strings.HasSuffix(ref, ".sql") {
+		if *shadow == "" {
+			return errors.New("diff: -shadow is required to load a schema file")
+		}
+		if desired, err = sql.Open(driver, *shadow); err != nil {
+			return err
+		}
+		defer desired.Close()
+		if err := goose.LoadSchemaFile(desired, ref); err != nil {
+			return err
+		}
+	} else {
+		if desired, err = sql.Open(driver, ref); err != nil {
+			return err
+		}
+		defer desired.Close()
+	}
+
+	_, err = goose.CreateDiffMigration(*dir, *name, db, desired)
+	return err
+}
@@ -0,0 +1,7 @@
+//go:build duckdb
+// +build duckdb
+
+package main
+
+// The DuckDB driver needs cgo, it is only built in with -tags duckdb.
+import _ "github.com/marcboeker/go-duckdb"
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gojuno/goose"
+)
+
+// githubAnnotation formats err as a GitHub Actions workflow command, so that
+// the failing migration is highlighted in pull requests. It returns "" for
+// errors that aren't about a migration file.
+func githubAnnotation(err error) string {
+	var file string
+	var line int
+
+	var validationErr *goose.ValidationError
+	var migrationErr *goose.MigrationError
+	var stmtErr *goose.StatementError
+	switch {
+	case errors.As(err, &validationErr):
+		file = validationErr.File
+	case errors.As(err, &migrationErr):
+		file = migrationErr.Source
+		if errors.As(err, &stmtErr) {
+			line = stmtErr.Line
+		}
+	default:
+		return ""
+	}
+
+	var props []string
+	if file != "" {
+		props = append(props, "file="+escapeProperty(file))
+	}
+	if line > 0 {
+		props = append(props, fmt.Sprintf("line=%d", line))
+	}
+	props = append(props, "title=goose")
+	return fmt.Sprintf("::error %s::%s", strings.Join(props, ","), escapeData(err.Error()))
+}
+
+// See https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeData(s string) string     { return dataEscaper.Replace(s) }
+func escapeProperty(s string) string { return propertyEscaper.Replace(s) }
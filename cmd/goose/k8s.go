@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gojuno/goose"
+)
+
+// jobResult is the JSON document -k8s-job prints on stdout.
+type jobResult struct {
+	Status     string                   `json:"status"` // applied, up_to_date or failed
+	Version    int64                    `json:"version"`
+	Migrations []goose.AppliedMigration `json:"migrations"`
+	DurationMs int64                    `json:"duration_ms"`
+	Error      string                   `json:"error,omitempty"`
+	ExitCode   int                      `json:"exit_code"`
+}
+
+// runK8sJob waits for the database, then applies the migrations under the
+// migration lock, waiting for other replicas running concurrently, and
+// prints the result as JSON, all within -k8s-timeout.
+func runK8sJob(db *sql.DB) error {
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), *k8sTimeout)
+	defer cancel()
+
+	result := jobResult{Migrations: []goose.AppliedMigration{}}
+	err := waitForDB(ctx, db)
+	if err == nil {
+		err = goose.MigrateOnStartup(ctx, db, *dir, *k8sTimeout-time.Since(started), goose.WithEventHandler(func(e goose.Event) {
+			if e, ok := e.(goose.MigrationApplied); ok {
+				result.Migrations = append(result.Migrations, e.AppliedMigration)
+			}
+		}))
+	}
+	if err == nil {
+		result.Version, err = goose.GetDBVersion(db)
+	}
+
+	result.DurationMs = int64(time.Since(started) / time.Millisecond)
+	switch {
+	case err != nil:
+		result.Status = "failed"
+		result.Error = err.Error()
+		result.ExitCode = exitCode(err)
+	case len(result.Migrations) > 0:
+		result.Status = "applied"
+	default:
+		result.Status = "up_to_date"
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+		log.Printf("goose: writing job result: %v", err)
+	}
+	return err
+}
+
+// waitForDB pings db until it answers, e.g. while its pod is starting.
+func waitForDB(ctx context.Context, db *sql.DB) error {
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		log.Printf("goose: waiting for database: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Second):
+		}
+	}
+}
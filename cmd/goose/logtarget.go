@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gojuno/goose"
+)
+
+// setLogTarget directs goose's output, and the command's own errors, to
+// stderr, syslog or the systemd journal.
+func setLogTarget(target string) error {
+	switch target {
+	case "", "stderr":
+		return nil
+	case "syslog":
+		logger, errWriter, err := newSyslogTarget()
+		if err != nil {
+			return err
+		}
+		goose.SetLogger(logger)
+		log.SetFlags(0)
+		log.SetOutput(errWriter)
+		return nil
+	case "journald":
+		goose.SetLogger(newJournalLogger(os.Stderr))
+		log.SetFlags(0)
+		log.SetOutput(journalWriter{w: os.Stderr, priority: journalErr})
+		return nil
+	}
+	return fmt.Errorf("-log=%q: must be stderr, syslog or journald", target)
+}
+
+// levelLogger is a goose.Logger sending each message to the function of its
+// priority. Warnings are recognized by their prefix.
+type levelLogger struct {
+	info, warning, crit func(msg string)
+}
+
+func (l levelLogger) Fatal(v ...interface{}) {
+	l.crit(strings.TrimSuffix(fmt.Sprint(v...), "\n"))
+	os.Exit(exitError)
+}
+
+func (l levelLogger) Fatalf(format string, v ...interface{}) {
+	l.crit(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+	os.Exit(exitError)
+}
+
+func (l levelLogger) Print(v ...interface{})                 { l.write(fmt.Sprint(v...)) }
+func (l levelLogger) Println(v ...interface{})               { l.write(fmt.Sprintln(v...)) }
+func (l levelLogger) Printf(format string, v ...interface{}) { l.write(fmt.Sprintf(format, v...)) }
+
+func (l levelLogger) write(msg string) {
+	msg = strings.TrimSuffix(msg, "\n")
+	if strings.HasPrefix(msg, "WARNING") {
+		l.warning(msg)
+		return
+	}
+	l.info(msg)
+}
+
+// Syslog priorities understood by journald in stderr line prefixes, see
+// sd-daemon(3).
+const (
+	journalCrit    = 2
+	journalErr     = 3
+	journalWarning = 4
+	journalInfo    = 6
+)
+
+func newJournalLogger(w io.Writer) levelLogger {
+	write := func(priority int) func(string) {
+		return func(msg string) {
+			journalWriter{w: w, priority: priority}.Write([]byte(msg))
+		}
+	}
+	return levelLogger{
+		info:    write(journalInfo),
+		warning: write(journalWarning),
+		crit:    write(journalCrit),
+	}
+}
+
+// journalWriter prefixes every line with its priority.
+type journalWriter struct {
+	w        io.Writer
+	priority int
+}
+
+func (j journalWriter) Write(p []byte) (int, error) {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		fmt.Fprintf(&b, "<%d>%s\n", j.priority, line)
+	}
+	if _, err := io.WriteString(j.w, b.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
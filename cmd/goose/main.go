@@ -2,10 +2,14 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/gojuno/goose"
 	"gopkg.in/yaml.v2"
@@ -13,30 +17,251 @@ import (
 	// Init DB drivers.
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "github.com/snowflakedb/gosnowflake"
 	_ "github.com/ziutek/mymysql/godrv"
 )
 
+// drivers maps -driver to the database/sql driver opened, and built into
+// migrators by build.
+var drivers = map[string]goose.Driver{
+	"postgres":  {Name: "postgres", Package: "github.com/lib/pq"},
+	"pgx":       {Name: "postgres", Package: "github.com/lib/pq"},
+	"redshift":  {Name: "postgres", Package: "github.com/lib/pq"},
+	"mysql":     {Name: "mysql", Package: "github.com/go-sql-driver/mysql"},
+	"tidb":      {Name: "mysql", Package: "github.com/go-sql-driver/mysql"},
+	"snowflake": {Name: "snowflake", Package: "github.com/snowflakedb/gosnowflake"},
+	"duckdb":    {Name: "duckdb", Package: "github.com/marcboeker/go-duckdb", CGO: true},
+}
+
 var (
-	flags        = flag.NewFlagSet("goose", flag.ExitOnError)
+	flags        = flag.NewFlagSet("goose", flag.ContinueOnError)
 	dir          = flags.String("dir", "db/migrations", "directory with migration files")
 	conf         = flags.String("conf", "etc/config.yaml", "configuration file")
 	driverFlag   = flags.String("driver", "", "db driver")
 	dbstringFlag = flags.String("dbstring", "", "db conn string")
+	pluginsFlag  = flags.String("plugins", "", "directory with Go migration plugins (*.so) to load")
+	gpgKeyring   = flags.String("verify-gpg-keyring", "", "require migrations.yaml to be signed by a key in this GPG keyring")
+	cosignKey    = flags.String("verify-sigstore-key", "", "require migrations.yaml to be signed with this cosign public key")
+	cosignID     = flags.String("verify-sigstore-identity", "", "require migrations.yaml to be signed by this sigstore identity")
+	cosignIssuer = flags.String("verify-sigstore-issuer", "", "OIDC issuer of -verify-sigstore-identity")
+	ghAnnotate   = flags.Bool("github-annotations", false, "print failures as GitHub Actions annotations")
+	noColor      = flags.Bool("no-color", false, "disable colors, also disabled by setting NO_COLOR or when not writing to a terminal")
+	logTarget    = flags.String("log", "stderr", "where to write the output: stderr, syslog or journald")
+	quiet        = flags.Bool("q", false, "suppress informational output, only print errors")
+	verbose      = flags.Bool("v", false, "log every SQL statement, with credentials redacted")
+	debugSQL     = flags.Bool("debug-sql", false, "log every query sent to the driver with its arguments, affected rows and latency")
+	explainRows  = flags.Int64("explain", 0, "EXPLAIN the UPDATE and DELETE statements of pending migrations first, warning about full scans of tables over this many rows")
+	heartbeat    = flags.Duration("heartbeat", 30*time.Second, "log statements still running at this interval, 0 to disable")
+	verboseLen   = flags.Int("v-max-len", 500, "truncate statements logged by -v to this length, 0 for no limit")
+	webhooks     = flags.String("webhook", "", "comma-separated URLs notified with a JSON payload when migrations run")
+	slackHooks   = flags.String("slack-webhook", "", "comma-separated Slack incoming webhook URLs notified when migrations run")
+	auditFlag    = flags.Bool("audit", false, "record every executed migration into the goose_audit table")
+	auditDetails = flags.Bool("audit-details", false, "also record the SQL checksum and affected rows in goose_audit")
+	auditOp      = flags.String("audit-operator", "", "operator recorded in goose_audit (default $GOOSE_OPERATOR or the OS user)")
+	grafanaURL   = flags.String("grafana-url", "", "Grafana URL annotated when migrations are applied")
+	grafanaToken = flags.String("grafana-token", os.Getenv("GRAFANA_TOKEN"), "Grafana service account token")
+	grafanaTags  = flags.String("grafana-tags", "", "comma-separated tags added to the Grafana annotation")
+	statsdAddr   = flags.String("statsd", "", "host:port of a StatsD or DogStatsD agent receiving run metrics")
+	statsdTags   = flags.String("statsd-tags", "", "comma-separated key:value tags added to the metrics, e.g. service:app,env:production")
+	smtpAddr     = flags.String("smtp", "", "host:port of the SMTP server emailing failed runs")
+	smtpUser     = flags.String("smtp-user", "", "SMTP username, the password is read from $GOOSE_SMTP_PASSWORD")
+	emailFrom    = flags.String("email-from", "", "sender of failure emails")
+	emailTo      = flags.String("email-to", "", "comma-separated recipients of failure emails")
+	emailTmpl    = flags.String("email-template", "", "text/template file rendering failure emails")
+	sentryDSN    = flags.String("sentry-dsn", os.Getenv("SENTRY_DSN"), "report failed migrations to this Sentry DSN")
+	commitSHA    = flags.String("commit-sha", os.Getenv("GOOSE_COMMIT_SHA"), "record this commit SHA with every applied migration")
+	contextFlag  = flags.String("context", "", "comma-separated key=value pairs recorded with every applied migration")
+	snapshotFlag = flags.String("snapshot", "", "write the schema snapshot to this file after applying migrations")
+	docFlag      = flags.String("doc", "", "write the markdown schema documentation to this file after applying migrations")
+	strictFlag   = flags.Bool("strict", false, "exit with code 2 when there are no migrations to apply")
+	strictDDL    = flags.Bool("strict-ddl", false, "refuse migrations with statements known to lock or rewrite large tables")
+	sequential   = flags.Bool("s", false, "number new migrations sequentially instead of with a timestamp")
+	dryRunFlag   = flags.Bool("dry-run", false, "print the statements up, down and redo would execute instead of running them")
+	noLock       = flags.Bool("no-lock", false, "don't take the migration lock serializing concurrent runs")
+	lockTimeout  = flags.Duration("lock-timeout", 5*time.Minute, "how long to wait for the migration lock held by another run")
+	allowMiss    = flags.Bool("allow-missing", false, "apply migrations older than the current version that were never applied, e.g. merged from another branch")
+	ghostArgs    = flags.String("gh-ost-args", "", "space-separated arguments passed to gh-ost by online migrations, e.g. --host=db --user=goose")
+	ptOSCArgs    = flags.String("pt-osc-args", "", "space-separated arguments passed to pt-online-schema-change by online migrations")
+	tenants      = flags.String("tenants", "", "run the command in every schema matching this LIKE pattern, e.g. tenant_%")
+	shardsFile   = flags.String("shards", "", "YAML file listing the name and dbstring of shards to run the command against")
+	parallelism  = flags.Int("parallelism", 1, "schemas or shards migrated at once with -tenants or -shards")
+	keepGoing    = flags.Bool("continue-on-error", false, "keep migrating the other shards after one failed")
+	tableFlag    = flags.String("table", goose.TableName(), "name of the version table, e.g. for services sharing a database")
+	schemaFlag   = flags.String("schema", "", "schema of the version table, instead of the current schema")
+	storeDown    = flags.Bool("store-down", false, "store the Down SQL of applied migrations in goose_db_version, to roll back without the files")
+	envFlag      = flags.String("env", os.Getenv("GOOSE_ENV"), "environment, running the migrations annotated with -- +goose Env for it")
+	seedsDir     = flags.String("seeds", "db/seeds", "directory with seed scripts")
+	k8sJob       = flags.Bool("k8s-job", false, "wait for the database, apply the migrations under a lock and print a JSON result, for init containers and Jobs")
+	k8sTimeout   = flags.Duration("k8s-timeout", 10*time.Minute, "how long -k8s-job waits for the database and for other replicas migrating")
+	versionRule  = flags.String("version-policy", "", "version format validate and up enforce: timestamp, sequential or hybrid")
+	autoNoTx     = flags.Bool("auto-no-transaction", true, "run migrations with statements that can't run in a transaction outside of one, instead of refusing them")
+	annPrefixes  = flags.String("annotation-prefix", "", "comma-separated annotation prefixes accepted along with -- +goose, e.g. \"-- +migrate\"")
+	timeZone     = flags.String("timezone", "UTC", "time zone of the displayed timestamps, e.g. Europe/Paris or Local")
+	cacheDir     = flags.String("cache-dir", os.Getenv("GOOSE_CACHE_DIR"), "directory caching migrations fetched from remote sources")
+)
+
+// Exit codes, documented in the README.
+const (
+	exitError      = 1 // usage, configuration and connection errors
+	exitNoChange   = 2 // nothing to apply, with -strict
+	exitValidation = 3 // invalid migration files
+	exitLocked     = 4 // another goose run holds the migration lock
+	exitExecution  = 5 // a migration failed to apply
+	exitPending    = 6 // migrations are pending, with status -check
 )
 
+// cleanups run before exiting, including on failure.
+var cleanups []func()
+
+// fail logs err and exits with the code matching its kind. Without
+// -strict, finding no migration to apply or roll back isn't a failure.
+func fail(err error) {
+	if exitCode(err) == 0 {
+		return
+	}
+	log.Print(goose.ColorError(fmt.Sprintf("goose run: %v", err)))
+	if *ghAnnotate {
+		if a := githubAnnotation(err); a != "" {
+			fmt.Println(a)
+		}
+	}
+	for _, f := range cleanups {
+		f()
+	}
+	os.Exit(exitCode(err))
+}
+
+func exitCode(err error) int {
+	var validationErr *goose.ValidationError
+	var migrationErr *goose.MigrationError
+	switch {
+	case err == goose.ErrNoChange:
+		return exitNoChange
+	case err == goose.ErrNoNextVersion:
+		if *strictFlag {
+			return exitNoChange
+		}
+		return 0
+	case err == goose.ErrStartupTimeout, err == goose.ErrLocked:
+		return exitLocked
+	case errors.As(err, &validationErr):
+		return exitValidation
+	case errors.As(err, &migrationErr):
+		return exitExecution
+	case errors.Is(err, goose.ErrPending):
+		return exitPending
+	}
+	return exitError
+}
+
+// noDBCommands only work on the migrations folder.
+var noDBCommands = map[string]bool{
+	"validate":    true,
+	"manifest":    true,
+	"lock":        true,
+	"verify-lock": true,
+	"convert":     true,
+}
+
 func main() {
 	flags.Usage = usage
-	flags.Parse(os.Args[1:])
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		os.Exit(exitError)
+	}
 
 	args := flags.Args()
 
-	if len(args) > 1 && args[0] == "create" {
-		if err := goose.Run("create", nil, *dir, args[1:]...); err != nil {
-			log.Fatalf("goose run: %v", err)
+	defer func() {
+		for _, f := range cleanups {
+			f()
+		}
+	}()
+
+	goose.SetColor(!*noColor && *logTarget == "stderr" && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr))
+	if err := setLogTarget(*logTarget); err != nil {
+		log.Fatal(err)
+	}
+	if *quiet {
+		goose.SetLogger(quietLogger{})
+	}
+	goose.SetStrict(*strictFlag)
+	goose.SetStrictDDL(*strictDDL)
+	goose.SetAllowMissing(*allowMiss)
+	goose.SetMigrationLock(!*noLock)
+	goose.SetDryRun(*dryRunFlag)
+	goose.SetLockTimeout(*lockTimeout)
+	goose.SetSequential(*sequential)
+	goose.SetVerbose(*verbose)
+	goose.SetVerboseMaxLen(*verboseLen)
+	goose.SetHeartbeat(*heartbeat)
+	goose.SetExplain(*explainRows)
+	loc, err := time.LoadLocation(*timeZone)
+	if err != nil {
+		log.Fatalf("-timezone=%s: %v", *timeZone, err)
+	}
+	goose.SetTimeZone(loc)
+	if err := goose.SetVersionPolicy(goose.VersionPolicy(*versionRule)); err != nil {
+		log.Fatalf("-version-policy: %v", err)
+	}
+	goose.SetAnnotationPrefixes(splitList(*annPrefixes)...)
+	goose.SetAutoNoTransaction(*autoNoTx)
+	goose.SetEnvironment(*envFlag)
+	goose.SetStoreDownSQL(*storeDown)
+	goose.SetTableName(*tableFlag)
+	goose.SetTableSchema(*schemaFlag)
+	goose.SetSeedDir(*seedsDir)
+
+	if *dir == goose.StreamDir {
+		if err := goose.ReadStream(os.Stdin); err != nil {
+			fail(&goose.ValidationError{Err: err})
+		}
+	}
+
+	if goose.IsOCISource(*dir) {
+		goose.SetCacheDir(*cacheDir)
+		ociDir, err := goose.FetchOCI(*dir)
+		if err != nil {
+			log.Fatalf("goose: %v", err)
+		}
+		cleanups = append(cleanups, func() { os.RemoveAll(ociDir) })
+		*dir = ociDir
+	}
+
+	switch {
+	case *gpgKeyring != "":
+		goose.SetSignatureVerifier(goose.GPGVerifier{Keyring: *gpgKeyring})
+	case *cosignKey != "" || *cosignID != "":
+		goose.SetSignatureVerifier(goose.SigstoreVerifier{Key: *cosignKey, Identity: *cosignID, Issuer: *cosignIssuer})
+	}
+
+	if *pluginsFlag != "" {
+		if err := loadPlugins(*pluginsFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// conflicts and fix only check the database when one is given.
+	if len(args) > 0 && (noDBCommands[args[0]] || ((args[0] == "conflicts" || args[0] == "fix") && *driverFlag == "" && *dbstringFlag == "")) {
+		if err := goose.Run(args[0], nil, *dir, args[1:]...); err != nil {
+			fail(err)
 		}
 		return
 	}
 
+	if len(args) > 1 && (args[0] == "create" || args[0] == "script") {
+		if err := goose.Run(args[0], nil, *dir, args[1:]...); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if *k8sJob && len(args) == 0 {
+		args = []string{"up"}
+	}
+
 	if len(args) < 1 {
 		flags.Usage()
 		return
@@ -48,10 +273,14 @@ func main() {
 	}
 
 	command, args := args[0], args[1:]
+	if *k8sJob && command != "up" {
+		log.Fatalf("-k8s-job only applies migrations, got %q", command)
+	}
 
 	driver, dbstring := *driverFlag, *dbstringFlag
 	switch {
 	case driver != "" && dbstring != "":
+	case driver != "" && *shardsFile != "":
 	case driver == "" && dbstring == "":
 		var err error
 		driver, dbstring, err = readConfig(*conf)
@@ -68,36 +297,202 @@ func main() {
 
 	goose.GetDialect()
 
-	switch driver {
-	case "redshift", "pgx":
-		driver = "postgres"
-	case "tidb":
-		driver = "mysql"
+	if command == "build" {
+		output := "goose-migrator"
+		if len(args) > 0 {
+			output = args[0]
+		}
+		drv, ok := drivers[driver]
+		if !ok {
+			log.Fatalf("%q: no driver to build with\n", driver)
+		}
+		if err := goose.Build(*dir, driver, drv, output); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if drv, ok := drivers[driver]; ok {
+		driver = drv.Name
 	}
 
-	if dbstring == "" {
+	if dbstring == "" && *shardsFile == "" {
 		log.Fatalf("-dbstring=%q not supported\n", dbstring)
 	}
 
+	if name, err := goose.DBName(dbstring); err == nil {
+		goose.SetDatabaseName(name)
+	}
+	if *auditFlag {
+		goose.EnableAudit(goose.AuditOptions{Operator: *auditOp, Details: *auditDetails})
+	}
+	if *commitSHA != "" || *contextFlag != "" {
+		values, err := parseContext(*contextFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		goose.SetRunContext(goose.RunContext{CommitSHA: *commitSHA, Values: values})
+	}
+	if *statsdAddr != "" {
+		if err := goose.EnableStatsd(goose.StatsdOptions{Addr: *statsdAddr, Tags: splitList(*statsdTags)}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *grafanaURL != "" {
+		goose.EnableGrafanaAnnotations(goose.GrafanaOptions{URL: *grafanaURL, Token: *grafanaToken, Tags: splitList(*grafanaTags)})
+	}
+	if *smtpAddr != "" {
+		opts := goose.EmailOptions{
+			Addr:     *smtpAddr,
+			Username: *smtpUser,
+			Password: os.Getenv("GOOSE_SMTP_PASSWORD"),
+			From:     *emailFrom,
+			To:       splitList(*emailTo),
+		}
+		if *emailTmpl != "" {
+			b, err := ioutil.ReadFile(*emailTmpl)
+			if err != nil {
+				log.Fatal(err)
+			}
+			opts.Template = string(b)
+		}
+		if err := goose.EnableEmail(opts); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := goose.SetSentryDSN(*sentryDSN); err != nil {
+		log.Fatal(err)
+	}
+	if *ghostArgs != "" || *ptOSCArgs != "" {
+		goose.SetOnlineOptions(goose.OnlineOptions{GhOstArgs: strings.Fields(*ghostArgs), PtOSCArgs: strings.Fields(*ptOSCArgs)})
+	}
+	for _, url := range splitList(*webhooks) {
+		goose.AddWebhook(goose.Webhook{URL: url})
+	}
+	for _, url := range splitList(*slackHooks) {
+		goose.AddWebhook(goose.Webhook{URL: url, Slack: true})
+	}
+
+	if *shardsFile != "" {
+		if err := runShards(driver, command, args); err != nil {
+			fail(err)
+		}
+		return
+	}
+
 	switch command {
 	case "create_db":
 		if err := goose.CreateDB(dbstring); err != nil {
-			log.Fatalf("goose run: %v", err)
+			fail(err)
 		}
 	case "drop_db":
 		if err := goose.DropDB(dbstring); err != nil {
-			log.Fatalf("goose run: %v", err)
+			fail(err)
+		}
+	case "diff":
+		if err := runDiff(driver, dbstring, args); err != nil {
+			fail(err)
+		}
+	case "verify-queries":
+		db, err := sql.Open(driver, dbstring)
+		if err != nil {
+			log.Fatalf("-dbstring=%q: %v\n", dbstring, err)
+		}
+		if err := runVerifyQueries(db, dbstring, args); err != nil {
+			fail(err)
+		}
+	case "compare":
+		if len(args) != 1 {
+			log.Fatal("compare must be of form: goose [OPTIONS] compare OTHER_DBSTRING")
+		}
+		db, err := sql.Open(driver, dbstring)
+		if err != nil {
+			log.Fatalf("-dbstring=%q: %v\n", dbstring, err)
+		}
+		other, err := sql.Open(driver, args[0])
+		if err != nil {
+			log.Fatalf("%q: %v\n", args[0], err)
+		}
+		if err := goose.Compare(db, other); err != nil {
+			fail(err)
 		}
 	default:
+		if *debugSQL {
+			var err error
+			if driver, err = goose.DebugDriver(driver); err != nil {
+				log.Fatal(err)
+			}
+		}
 		db, err := sql.Open(driver, dbstring)
 		if err != nil {
 			log.Fatalf("-dbstring=%q: %v\n", dbstring, err)
 		}
 
-		if err := goose.Run(command, db, *dir, args...); err != nil {
-			log.Fatalf("goose run: %v", err)
+		switch {
+		case *tenants != "":
+			err = runTenants(db, driver, dbstring, command, args)
+		case *k8sJob:
+			err = runK8sJob(db)
+		case command == "serve":
+			err = runServe(db, args)
+		default:
+			err = goose.Run(command, db, *dir, args...)
+		}
+		if err != nil {
+			fail(err)
+		}
+		// Commands changing the schema update the -snapshot and -doc.
+		changed := goose.IsMigratingCommand(command) && !*dryRunFlag
+		if *snapshotFlag != "" && changed {
+			if err := goose.WriteSnapshot(db, *snapshotFlag); err != nil {
+				fail(err)
+			}
+		}
+		if *docFlag != "" && changed {
+			if err := goose.WriteSchemaDoc(db, *docFlag); err != nil {
+				fail(err)
+			}
+		}
+	}
+}
+
+// isTerminal reports whether f is a character device, such as a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// quietLogger discards everything but fatal errors.
+type quietLogger struct{}
+
+func (quietLogger) Fatal(v ...interface{})                 { log.Fatal(v...) }
+func (quietLogger) Fatalf(format string, v ...interface{}) { log.Fatalf(format, v...) }
+func (quietLogger) Print(v ...interface{})                 {}
+func (quietLogger) Println(v ...interface{})               {}
+func (quietLogger) Printf(format string, v ...interface{}) {}
+
+// splitList splits a comma-separated flag value.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseContext parses the key=value pairs of -context.
+func parseContext(s string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, item := range splitList(s) {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("-context: %q must be of form key=value", item)
 		}
+		values[kv[0]] = kv[1]
 	}
+	return values, nil
 }
 
 // extract configuration details from the given file
@@ -140,6 +535,8 @@ Supported drivers:
     pgx
     mysql
     redshift
+    snowflake
+    duckdb (built with -tags duckdb)
 
 Examples:
     goose status
@@ -149,16 +546,53 @@ Options:
 
 	usageCommands = `
 Commands:
-    up                   Migrate the DB to the most recent version available
+    up [-phase=PHASE]    Migrate the DB to the most recent version available, or through the expand or contract phase
     up-to VERSION        Migrate the DB to a specific VERSION
     down                 Roll back the version by 1
-    down-to VERSION      Roll back to a specific VERSION
+    down-to VERSION|TIME Roll back to a specific VERSION, or the migrations created after an RFC 3339 TIME
     redo                 Re-run the latest migration
     reset                Roll back all migrations
-    status               Dump the migration status for the current DB
+    status [--format=F|--json] [-check [-checksums]]
+                         Dump the migration status for the current DB (table, yaml, csv or json), failing if migrations are pending with -check
+    pending [--format=F|--json] [-check [-checksums]]
+                         Dump the migrations not applied yet
     version              Print the current version of the database
-    create NAME [sql|go] Creates new migration file with next version
+    snapshot [-check] [FILE]
+                         Write the schema to FILE (default DIR/schema.snapshot), or check it didn't change
+    doc [-format=F] [FILE]
+                         Document the schema in markdown or as a mermaid ER diagram
+    exec FILE            Run an SQL script like a migration, without recording a version
+    seed [-env=ENV] [-rerun] [NAME...]
+                         Run the seed scripts not run yet, or changed since, or the given ones
+    plan [-format=F] [COMMAND [VERSION]]
+                         Print the migrations up, up-to, down or down-to would run, as text or json
+    apply PLAN           Run the migrations of a JSON plan, if the database and files didn't change
+    import [-rename] TOOL
+                         Mark the migrations applied by flyway, liquibase or golang-migrate as applied
+    export golang-migrate
+                         Record the current version in golang-migrate's schema_migrations
+    convert [-to=golang-migrate|goose] OUTDIR
+                         Convert the migrations to the golang-migrate layout, or back
+    diff [-name=NAME] [-shadow=DBSTRING] REFERENCE
+                         Create a migration to the schema of REFERENCE, a database or a SQL file
+    verify-queries [-sqlc] [FILE...]
+                         Migrate the (shadow) DB, then check the queries of the files still prepare
+    compare DBSTRING     Diff the version history with another database
+    create [-force] [-paired] NAME [sql|go|index]
+                         Creates new migration file with next version, index creating a Postgres index concurrently
+    create-from-table TABLE...
+                         Creates a migration creating the existing TABLEs, to bring them under goose management
     create_db            Creates database
     drop_db              Drops database
+    fix                  Renumbers timestamp migrations sequentially, refusing applied ones
+    script up-to VERSION [FROM]
+                         Print the SQL migrating the DB from FROM (default 0) to VERSION
+    validate             Checks the migration files without connecting to the database
+    lock                 Writes goose.lock recording the version and checksum of every migration
+    verify-lock          Checks the migrations match goose.lock
+    conflicts            Checks for duplicate versions, renamed files and migrations older than the applied ones
+    manifest             Writes migrations.yaml listing the migrations with their checksums
+    serve [-addr=ADDR]   Serves authenticated HTTP endpoints for status, pending, up, up-to, down and down-to
+    build [OUTPUT]       Builds a self-contained migrator binary embedding the migrations
 `
 )
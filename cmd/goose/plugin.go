@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+)
+
+// loadPlugins opens every Go plugin (*.so) found in dir. Plugins register
+// their Go migrations with goose.AddMigration from init functions, exactly like
+// migrations compiled into a custom binary:
+//
+//	go build -buildmode=plugin -o plugins/migrations.so ./migrations
+//
+// Plugins must be built with the same Go toolchain and goose version as the
+// binary loading them.
+func loadPlugins(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if _, err := plugin.Open(file); err != nil {
+			return fmt.Errorf("failed to load plugin %s: %v", file, err)
+		}
+	}
+	return nil
+}
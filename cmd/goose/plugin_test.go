@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPlugins(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Only *.so files are plugins.
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadPlugins(dir); err != nil {
+		t.Fatalf("loadPlugins: %v", err)
+	}
+
+	bogus := filepath.Join(dir, "migrations.so")
+	if err := ioutil.WriteFile(bogus, []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadPlugins(dir); err == nil || !strings.Contains(err.Error(), bogus) {
+		t.Errorf("loadPlugins: got %v, want an error naming %s", err, bogus)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gojuno/goose"
+)
+
+// runServe serves the HTTP admin endpoints of goose.NewServer, accepting the
+// tokens of $GOOSE_SERVE_TOKENS, comma-separated operator=token pairs.
+func runServe(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	tokens := map[string]string{}
+	for _, item := range splitList(os.Getenv("GOOSE_SERVE_TOKENS")) {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return fmt.Errorf("GOOSE_SERVE_TOKENS: %q must be of form operator=token", item)
+		}
+		tokens[kv[1]] = kv[0]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("serve needs the operator=token pairs of GOOSE_SERVE_TOKENS")
+	}
+
+	log.Printf("goose: serving %s on %s\n", *dir, *addr)
+	return http.ListenAndServe(*addr, goose.NewServer(db, *dir, goose.ServerOptions{Tokens: tokens}))
+}
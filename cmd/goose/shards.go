@@ -0,0 +1,33 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/gojuno/goose"
+	"gopkg.in/yaml.v2"
+)
+
+// runShards implements -shards, running command against every shard listed
+// in the file, a YAML list of name and dbstring pairs.
+func runShards(driver, command string, args []string) error {
+	b, err := ioutil.ReadFile(*shardsFile)
+	if err != nil {
+		return err
+	}
+	var shards []goose.Shard
+	if err := yaml.Unmarshal(b, &shards); err != nil {
+		return err
+	}
+	for i := range shards {
+		shards[i].DBString = os.ExpandEnv(shards[i].DBString)
+	}
+
+	_, err = goose.RunShards(*dir, command, args, goose.ShardOptions{
+		Driver:          driver,
+		Shards:          shards,
+		Parallelism:     *parallelism,
+		ContinueOnError: *keepGoing,
+	})
+	return err
+}
@@ -0,0 +1,35 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// newSyslogTarget connects to the local syslog daemon, returning the logger
+// for goose's output and the writer for errors.
+func newSyslogTarget() (levelLogger, io.Writer, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "goose")
+	if err != nil {
+		return levelLogger{}, nil, err
+	}
+	logger := levelLogger{
+		info:    func(msg string) { w.Info(msg) },
+		warning: func(msg string) { w.Warning(msg) },
+		crit:    func(msg string) { w.Crit(msg) },
+	}
+	return logger, syslogErrWriter{w}, nil
+}
+
+type syslogErrWriter struct {
+	w *syslog.Writer
+}
+
+func (s syslogErrWriter) Write(p []byte) (int, error) {
+	if err := s.w.Err(string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
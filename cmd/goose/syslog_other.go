@@ -0,0 +1,13 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+func newSyslogTarget() (levelLogger, io.Writer, error) {
+	return levelLogger{}, nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gojuno/goose"
+)
+
+// runTenants implements -tenants, running command in every matching schema.
+func runTenants(db *sql.DB, driver, dbstring, command string, args []string) error {
+	_, err := goose.RunTenants(db, *dir, command, args, goose.TenantOptions{
+		Pattern:     *tenants,
+		Parallelism: *parallelism,
+		Open: func(schema string) (*sql.DB, error) {
+			dsn, err := withSchema(driver, dbstring, schema)
+			if err != nil {
+				return nil, err
+			}
+			return sql.Open(driver, dsn)
+		},
+	})
+	return err
+}
+
+// withSchema returns dbstring connecting to schema: the search path on
+// PostgreSQL, the database on MySQL, where schemas are databases.
+func withSchema(driver, dbstring, schema string) (string, error) {
+	switch driver {
+	case "postgres":
+		if strings.HasPrefix(dbstring, "postgres://") || strings.HasPrefix(dbstring, "postgresql://") {
+			u, err := url.Parse(dbstring)
+			if err != nil {
+				return "", err
+			}
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String(), nil
+		}
+		return fmt.Sprintf("%s search_path=%s", dbstring, schema), nil
+	case "mysql":
+		// user:password@tcp(host:port)/dbname?params
+		slash := strings.LastIndex(dbstring, "/")
+		if slash < 0 {
+			return "", fmt.Errorf("-dbstring=%q: missing database name", dbstring)
+		}
+		params := ""
+		if i := strings.Index(dbstring[slash:], "?"); i >= 0 {
+			params = dbstring[slash+i:]
+		}
+		return dbstring[:slash+1] + schema + params, nil
+	}
+	return "", fmt.Errorf("-tenants is not supported by %s", driver)
+}
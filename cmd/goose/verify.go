@@ -0,0 +1,48 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/gojuno/goose"
+)
+
+// runVerifyQueries implements the verify-queries command:
+//
+//	verify-queries [-sqlc] [FILE...]
+//
+// It migrates the database, meant to be a shadow database, to the latest
+// version, then prepares the queries of the files and optionally runs
+// sqlc vet against it.
+func runVerifyQueries(db *sql.DB, dbstring string, args []string) error {
+	fs := flag.NewFlagSet("verify-queries", flag.ContinueOnError)
+	sqlc := fs.Bool("sqlc", false, "run sqlc vet, with the dbstring in $GOOSE_DBSTRING")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 && !*sqlc {
+		return fmt.Errorf("verify-queries must be of form: goose [OPTIONS] verify-queries [-sqlc] [FILE...]")
+	}
+
+	if err := goose.Up(db, *dir); err != nil && err != goose.ErrNoChange {
+		return err
+	}
+	if fs.NArg() > 0 {
+		if err := goose.VerifyQueries(db, fs.Args()); err != nil {
+			return err
+		}
+	}
+	if *sqlc {
+		cmd := exec.Command("sqlc", "vet")
+		cmd.Env = append(os.Environ(), "GOOSE_DBSTRING="+dbstring)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("sqlc vet: %v", err)
+		}
+	}
+	return nil
+}
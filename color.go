@@ -0,0 +1,29 @@
+package goose
+
+// ANSI color codes used for terminal output.
+const (
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+)
+
+var useColor = false
+
+// SetColor enables or disables ANSI colors in goose's output. It's disabled
+// by default; the goose command enables it when writing to a terminal.
+func SetColor(c bool) {
+	useColor = c
+}
+
+// colorize wraps s in the given ANSI color code when colors are enabled.
+func colorize(color, s string) string {
+	if !useColor {
+		return s
+	}
+	return "\x1b[" + color + "m" + s + "\x1b[0m"
+}
+
+// ColorError formats an error message in red when colors are enabled.
+func ColorError(s string) string {
+	return colorize(colorRed, s)
+}
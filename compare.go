@@ -0,0 +1,111 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// VersionRecord is the latest state of a version in goose_db_version.
+type VersionRecord struct {
+	Version   int64
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// VersionDiff is a version whose state differs between two databases. A or B
+// is nil when the version was never recorded in that database.
+type VersionDiff struct {
+	Version int64
+	A, B    *VersionRecord
+}
+
+// CompareVersions diffs the goose_db_version contents of two databases. A
+// version is reported when it's applied in only one of them, or applied at
+// different times.
+func CompareVersions(a, b *sql.DB) ([]VersionDiff, error) {
+	recordsA, err := versionRecords(a)
+	if err != nil {
+		return nil, err
+	}
+	recordsB, err := versionRecords(b)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := map[int64]bool{}
+	for v := range recordsA {
+		versions[v] = true
+	}
+	for v := range recordsB {
+		versions[v] = true
+	}
+
+	var diffs []VersionDiff
+	for v := range versions {
+		ra, rb := recordsA[v], recordsB[v]
+		appliedA := ra != nil && ra.Applied
+		appliedB := rb != nil && rb.Applied
+		if appliedA != appliedB || (appliedA && !ra.AppliedAt.Equal(rb.AppliedAt)) {
+			diffs = append(diffs, VersionDiff{Version: v, A: ra, B: rb})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Version < diffs[j].Version })
+	return diffs, nil
+}
+
+// Compare prints the versions whose state differs between two databases,
+// and returns an error if there are any.
+func Compare(a, b *sql.DB) error {
+	diffs, err := CompareVersions(a, b)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		log.Println("goose: version history is identical")
+		return nil
+	}
+
+	log.Println("    Version         A                          B")
+	log.Println("    ==========================================================")
+	for _, d := range diffs {
+		log.Printf("    %-15d %-26s %s\n", d.Version, d.A.state(), d.B.state())
+	}
+	return fmt.Errorf("version history diverges on %d versions", len(diffs))
+}
+
+func (r *VersionRecord) state() string {
+	switch {
+	case r == nil:
+		return "-"
+	case !r.Applied:
+		return "rolled back"
+	}
+	return localTime(r.AppliedAt).Format(time.ANSIC)
+}
+
+// versionRecords returns the latest record of every version, excluding the
+// initial version 0.
+func versionRecords(db *sql.DB) (map[int64]*VersionRecord, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version_id, is_applied, tstamp FROM %s ORDER BY id DESC", TableName()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := map[int64]*VersionRecord{}
+	for rows.Next() {
+		var r VersionRecord
+		var tstamp sql.NullTime
+		if err := rows.Scan(&r.Version, &r.Applied, &tstamp); err != nil {
+			return nil, err
+		}
+		if _, ok := records[r.Version]; ok || r.Version == 0 {
+			continue
+		}
+		r.AppliedAt = tstamp.Time
+		records[r.Version] = &r
+	}
+	return records, rows.Err()
+}
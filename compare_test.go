@@ -0,0 +1,66 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	a, b := openDuckDB(t), openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n-- +goose Down\nDROP TABLE users;\n")},
+		"migrations/00002_email.sql": {Data: []byte("-- +goose Up\nALTER TABLE users ADD email text;\n-- +goose Down\nALTER TABLE users DROP email;\n")},
+	})
+	defer SetBaseFS(nil)
+
+	if err := Up(a, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpTo(b, "migrations", 1); err != nil {
+		t.Fatal(err)
+	}
+	// Version 1 is the same in both databases once applied at the same time.
+	var tstamp time.Time
+	if err := a.QueryRow("SELECT tstamp FROM goose_db_version WHERE version_id = 1").Scan(&tstamp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Exec("UPDATE goose_db_version SET tstamp = ? WHERE version_id = 1", tstamp); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+
+	if err := Compare(a, a); err != nil || len(logger.lines) != 1 || logger.lines[0] != "goose: version history is identical" {
+		t.Errorf("same database: got %v, %q", err, logger.lines)
+	}
+
+	logger.lines = nil
+	err := Compare(a, b)
+	if err == nil || err.Error() != "version history diverges on 1 versions" {
+		t.Errorf("got error %v, want a divergence on version 2", err)
+	}
+	if len(logger.lines) != 3 || !strings.HasPrefix(logger.lines[2], "    2 ") || !strings.HasSuffix(logger.lines[2], " -") {
+		t.Errorf("got %q, want version 2 applied in A only", logger.lines)
+	}
+
+	// A rolled back version differs from an applied one.
+	SetLogger(&stdLogger{})
+	if err := Up(b, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Down(b, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	logger.lines = nil
+	SetLogger(logger)
+	if err := Compare(a, b); err == nil || len(logger.lines) != 3 || !strings.HasSuffix(logger.lines[2], " rolled back") {
+		t.Errorf("got %v, %q; want version 2 rolled back in B", err, logger.lines)
+	}
+}
@@ -0,0 +1,85 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"text/template"
+)
+
+var concurrentIndexRetries = 1
+
+// SetConcurrentIndexRetries sets how many times a PostgreSQL CREATE INDEX
+// CONCURRENTLY leaving an INVALID index is retried, after dropping the
+// invalid index, 1 by default.
+func SetConcurrentIndexRetries(n int) {
+	concurrentIndexRetries = n
+}
+
+var (
+	// concurrentIndexRegexp matches the statements PostgreSQL refuses to run
+	// in a transaction block.
+	concurrentIndexRegexp = regexp.MustCompile(`(?is)^\s*(CREATE\s+(UNIQUE\s+)?|DROP\s+)INDEX\s+CONCURRENTLY\b`)
+	createIndexRegexp     = regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s+CONCURRENTLY\s+(IF\s+NOT\s+EXISTS\s+)?("?[\w.]+"?)\s+ON\b`)
+)
+
+// concurrentIndexName returns the index a CREATE INDEX CONCURRENTLY
+// statement builds, or "" for other statements and unnamed indexes.
+func concurrentIndexName(c *config, query string) string {
+	if _, ok := c.dialect.(*PostgresDialect); !ok {
+		return ""
+	}
+	m := createIndexRegexp.FindStringSubmatch(stripComments(query))
+	if m == nil {
+		return ""
+	}
+	return m[3]
+}
+
+// execConcurrentIndex runs a CREATE INDEX CONCURRENTLY statement, then
+// checks the index is valid. A failed concurrent build leaves an INVALID
+// index behind, which is dropped before retrying the statement.
+func execConcurrentIndex(ctx context.Context, db *sql.DB, scriptFile string, v int64, i, line int, query, index string, result *execResult) error {
+	for attempt := 0; ; attempt++ {
+		err := execStatement(ctx, db, scriptFile, v, i, line, query, result)
+
+		var valid sql.NullBool
+		verr := db.QueryRowContext(ctx, "SELECT indisvalid FROM pg_index WHERE indexrelid = to_regclass($1)", index).Scan(&valid)
+		switch {
+		case verr == sql.ErrNoRows:
+			// A statement failing before the build, e.g. on a syntax error.
+			return err
+		case verr != nil:
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%s: checking index %s: %v", filepath.Base(scriptFile), index, verr)
+		case valid.Bool:
+			return err
+		}
+
+		configFrom(ctx).log.Printf("goose: %s: index %s is INVALID, dropping it\n", filepath.Base(scriptFile), index)
+		if _, derr := db.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", index)); derr != nil {
+			return fmt.Errorf("%s: dropping invalid index %s: %v", filepath.Base(scriptFile), index, derr)
+		}
+		if err == nil {
+			err = &StatementError{File: filepath.Base(scriptFile), Line: line, Query: query, Err: fmt.Errorf("index %s is INVALID", index)}
+		}
+		if attempt >= concurrentIndexRetries {
+			return err
+		}
+		configFrom(ctx).log.Printf("goose: %s: retrying index %s (%v)\n", filepath.Base(scriptFile), index, err)
+	}
+}
+
+var concurrentIndexTemplate = template.Must(template.New("goose.index-migration").Parse(`-- +goose NO TRANSACTION
+-- +goose Up
+-- Concurrent builds don't lock writes. A failed build leaving an INVALID
+-- index is dropped and retried by goose.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS table_column_idx ON table (column);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY IF EXISTS table_column_idx;
+`))
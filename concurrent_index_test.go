@@ -0,0 +1,25 @@
+package goose
+
+import "testing"
+
+func TestConcurrentIndex(t *testing.T) {
+	tests := []struct {
+		query      string
+		concurrent bool
+		index      string
+	}{
+		{"CREATE INDEX CONCURRENTLY users_email_idx ON users (email);\n", true, "users_email_idx"},
+		{"-- Speeds up logins.\ncreate unique index concurrently if not exists app.users_login ON users (login);\n", true, "app.users_login"},
+		{"CREATE INDEX CONCURRENTLY ON users (email);\n", true, ""},
+		{"DROP INDEX CONCURRENTLY IF EXISTS users_email_idx;\n", true, ""},
+		{"CREATE INDEX users_email_idx ON users (email);\n", false, ""},
+	}
+	for _, test := range tests {
+		if i, _ := noTransactionStatement(packageConfig(), []string{test.query}); (i == 0) != test.concurrent {
+			t.Errorf("%q: got concurrent %v", test.query, i == 0)
+		}
+		if index := concurrentIndexName(packageConfig(), test.query); index != test.index {
+			t.Errorf("%q: got index %q, want %q", test.query, index, test.index)
+		}
+	}
+}
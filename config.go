@@ -0,0 +1,55 @@
+package goose
+
+import (
+	"context"
+	"io/fs"
+)
+
+// config is the settings goose runs with: the package settings, set with
+// SetDialect, SetTableName, SetLogger and the like, or the ones of a
+// Provider. A run carries its config in its context, so that Providers
+// migrate concurrently without sharing state.
+type config struct {
+	provider     *Provider // nil for the package settings
+	dialect      SQLDialect
+	tables       gooseTables
+	log          Logger
+	fsys         fs.FS // filesystem migrations are read from, the local one when nil
+	allowMissing bool
+	strict       bool
+	dryRun       bool
+	verbose      bool
+	audit        *AuditOptions
+	runContext   *RunContext
+}
+
+// packageConfig returns the current package settings.
+func packageConfig() *config {
+	return &config{
+		dialect:      dialect,
+		tables:       packageTables(),
+		log:          log,
+		fsys:         baseFS,
+		allowMissing: allowMissing,
+		strict:       strict,
+		dryRun:       dryRun,
+		verbose:      verbose,
+		audit:        audit,
+		runContext:   runContext,
+	}
+}
+
+type configKey struct{}
+
+func withConfig(ctx context.Context, c *config) context.Context {
+	return context.WithValue(ctx, configKey{}, c)
+}
+
+// configFrom returns the config of ctx, the package settings if it has
+// none.
+func configFrom(ctx context.Context) *config {
+	if c, ok := ctx.Value(configKey{}).(*config); ok {
+		return c
+	}
+	return packageConfig()
+}
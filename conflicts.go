@@ -0,0 +1,107 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Conflicts checks the migrations folder for the usual conflicts between
+// branches merged concurrently: versions used by several files, migrations
+// renamed since goose.lock was written, and new migrations older than the
+// latest locked one, which databases already past it would never apply.
+// With a database, pending migrations older than its version are reported
+// too. It is meant to run as a pre-commit hook or CI check.
+func Conflicts(db *sql.DB, dir string) error {
+	files, err := migrationFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	versions := make([]int64, 0, len(files))
+	for v, names := range files {
+		versions = append(versions, v)
+		if len(names) > 1 {
+			problems = append(problems, fmt.Sprintf("version %d is used by %s", v, strings.Join(names, ", ")))
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	locked, err := readLock(packageConfig(), filepath.Join(dir, LockFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var latest int64
+	for v := range locked {
+		if v > latest {
+			latest = v
+		}
+	}
+	for _, v := range versions {
+		name := files[v][0]
+		l, ok := locked[v]
+		switch {
+		case ok && l.file != name:
+			problems = append(problems, fmt.Sprintf("%s was renamed from %s", name, l.file))
+		case !ok && v < latest:
+			problems = append(problems, fmt.Sprintf("%s is older than %s, the latest migration in %s: renumber it", name, locked[latest].file, LockFile))
+		}
+	}
+
+	// Statuses can only be collected without duplicate versions.
+	if db != nil && len(problems) == 0 {
+		current, err := GetDBVersion(db)
+		if err != nil {
+			return err
+		}
+		statuses, err := GetStatus(db, dir)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if !s.Applied && s.Version < current {
+				problems = append(problems, fmt.Sprintf("%s is pending but older than the database version %d: renumber it", filepath.Base(s.Source), current))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d conflicts found:\n\t%s", len(problems), strings.Join(problems, "\n\t"))
+	}
+	log.Printf("goose: no conflicts in %d migrations\n", len(versions))
+	return nil
+}
+
+// migrationFiles returns the names of the migration files of dir by
+// version, without failing on duplicate versions like CollectMigrations.
+func migrationFiles(dir string) (map[int64][]string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	files := map[int64][]string{}
+	for _, pattern := range []string{"*.sql", "*.sql.gz", "*.go"} {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			v, err := NumericComponent(path)
+			if err != nil {
+				if strings.HasSuffix(path, ".go") {
+					continue // Go files without version prefix aren't migrations.
+				}
+				return nil, &ValidationError{File: path, Err: fmt.Errorf("%s: %v", filepath.Base(path), err)}
+			}
+			files[v] = append(files[v], filepath.Base(path))
+		}
+	}
+	for _, names := range files {
+		sort.Strings(names)
+	}
+	return files, nil
+}
@@ -0,0 +1,50 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-conflicts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"00001_users.sql", "00003_orders.sql"} {
+		write(name, "-- +goose Up\nSELECT 1;\n")
+	}
+	if err := WriteLock(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := Conflicts(nil, dir); err != nil {
+		t.Fatalf("no conflicts expected, got %v", err)
+	}
+
+	write("00002_payments.sql", "-- +goose Up\nSELECT 1;\n")
+	write("00003_invoices.sql", "-- +goose Up\nSELECT 1;\n")
+	os.Rename(filepath.Join(dir, "00001_users.sql"), filepath.Join(dir, "00001_accounts.sql"))
+
+	err = Conflicts(nil, dir)
+	if err == nil {
+		t.Fatal("conflicts expected")
+	}
+	for _, want := range []string{
+		"version 3 is used by 00003_invoices.sql, 00003_orders.sql",
+		"00001_accounts.sql was renamed from 00001_users.sql",
+		"00002_payments.sql is older than 00003_orders.sql",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q not reported in %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package goose
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Suffixes of golang-migrate migration files.
+const (
+	migrateUpExt   = ".up.sql"
+	migrateDownExt = ".down.sql"
+)
+
+// ConvertToMigrate writes the SQL migrations of dir to out in the
+// golang-migrate layout, as VERSION_NAME.up.sql and VERSION_NAME.down.sql
+// pairs.
+func ConvertToMigrate(dir, out string) error {
+	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if !isSQLMigration(m.Source) {
+			return fmt.Errorf("%s: Go migrations can't be converted", filepath.Base(m.Source))
+		}
+		base := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(filepath.Base(m.Source), gzipSQLExt), migrateUpExt), ".sql")
+		for _, direction := range []bool{true, false} {
+			b, err := migrationSQL(m.Source, direction)
+			if err != nil {
+				return err
+			}
+			ext := migrateUpExt
+			if !direction {
+				ext = migrateDownExt
+			}
+			if err := ioutil.WriteFile(filepath.Join(out, base+ext), b, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("goose: converted %d migrations to %s\n", len(migrations), out)
+	return nil
+}
+
+// migrationSQL returns the statements of one direction of a migration.
+func migrationSQL(path string, direction bool) ([]byte, error) {
+	statements, _, useTx, err := readSQLStatements(packageConfig(), path, direction)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+	}
+
+	var b bytes.Buffer
+	if !useTx {
+		// golang-migrate has no equivalent, the statements of a file are
+		// sent at once.
+		fmt.Fprintf(&b, "-- converted from a goose NO TRANSACTION migration\n")
+	}
+	for _, s := range statements {
+		for _, line := range strings.SplitAfter(s, "\n") {
+			// Statements keep the annotations preceding them.
+			if !strings.HasPrefix(line, sqlCmdPrefix) && (b.Len() > 0 || strings.TrimSpace(line) != "") {
+				b.WriteString(line)
+			}
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// ConvertFromMigrate writes the golang-migrate migrations of dir to out as
+// goose SQL migrations. golang-migrate executes each file at once, so each
+// direction becomes a single StatementBegin/StatementEnd block.
+func ConvertFromMigrate(dir, out string) error {
+	ups, err := filepath.Glob(filepath.Join(dir, "*"+migrateUpExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(ups)
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+
+	for _, up := range ups {
+		base := strings.TrimSuffix(filepath.Base(up), migrateUpExt)
+		if _, err := NumericComponent(base + ".sql"); err != nil {
+			return fmt.Errorf("%s: %v", filepath.Base(up), err)
+		}
+
+		upSQL, err := ioutil.ReadFile(up)
+		if err != nil {
+			return err
+		}
+		downSQL, err := ioutil.ReadFile(filepath.Join(dir, base+migrateDownExt))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		var b bytes.Buffer
+		writeMigrateSection(&b, "Up", upSQL)
+		b.WriteString("\n")
+		writeMigrateSection(&b, "Down", downSQL)
+		if err := ioutil.WriteFile(filepath.Join(out, base+".sql"), b.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("goose: converted %d migrations to %s\n", len(ups), out)
+	return nil
+}
+
+func writeMigrateSection(b *bytes.Buffer, direction string, body []byte) {
+	b.WriteString(sqlCmdPrefix + direction + "\n")
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return
+	}
+	b.WriteString(sqlCmdPrefix + "StatementBegin\n")
+	b.Write(body)
+	b.WriteString("\n" + sqlCmdPrefix + "StatementEnd\n")
+}
+
+// migrateMigrations returns the migrations of dir, in goose or golang-migrate
+// layout, up to the version recorded in golang-migrate's schema_migrations.
+func migrateMigrations(db *sql.DB, dir string) ([]importedMigration, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRow("SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema_migrations: %v", err)
+	}
+	if dirty {
+		return nil, fmt.Errorf("schema_migrations: version %d is dirty, fix it with golang-migrate first", version)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	versions := map[int64]bool{}
+	var migrations []importedMigration
+	for _, file := range files {
+		v, err := NumericComponent(file)
+		if err != nil || v > version || versions[v] {
+			continue
+		}
+		versions[v] = true
+		migrations = append(migrations, importedMigration{version: v})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// ExportToMigrate records the current goose version in golang-migrate's
+// schema_migrations table, creating it if needed.
+func ExportToMigrate(db *sql.DB) error {
+	version, err := GetDBVersion(db)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, q := range []string{
+		"CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)",
+		"DELETE FROM schema_migrations",
+	} {
+		if _, err := tx.Exec(q); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	// Literals avoid dialect-specific placeholders.
+	if _, err := tx.Exec(fmt.Sprintf("INSERT INTO schema_migrations (version, dirty) VALUES (%d, false)", version)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("goose: recorded version %d in schema_migrations\n", version)
+	return nil
+}
+
+// convertCommand implements the convert command: convert -to=LAYOUT OUTDIR.
+func convertCommand(dir string, args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	to := fs.String("to", "golang-migrate", "layout to convert to, golang-migrate or goose")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("convert must be of form: goose [OPTIONS] convert [-to=golang-migrate|goose] OUTDIR")
+	}
+
+	switch *to {
+	case "golang-migrate":
+		return ConvertToMigrate(dir, fs.Arg(0))
+	case "goose":
+		return ConvertFromMigrate(dir, fs.Arg(0))
+	}
+	return fmt.Errorf("-to=%q: must be golang-migrate or goose", *to)
+}
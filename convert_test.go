@@ -0,0 +1,56 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "goose")
+	os.Mkdir(src, 0755)
+	migration := `-- +goose Up
+CREATE TABLE post (id int);
+CREATE INDEX post_id ON post (id);
+
+-- +goose Down
+DROP TABLE post;
+`
+	if err := ioutil.WriteFile(filepath.Join(src, "00001_create_post.sql"), []byte(migration), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	migrate := filepath.Join(dir, "migrate")
+	if err := ConvertToMigrate(src, migrate); err != nil {
+		t.Fatal(err)
+	}
+	up, _ := ioutil.ReadFile(filepath.Join(migrate, "00001_create_post.up.sql"))
+	if want := "CREATE TABLE post (id int);\nCREATE INDEX post_id ON post (id);\n"; string(up) != want {
+		t.Errorf("incorrect up migration. got %q, want %q", up, want)
+	}
+	down, _ := ioutil.ReadFile(filepath.Join(migrate, "00001_create_post.down.sql"))
+	if want := "DROP TABLE post;\n"; string(down) != want {
+		t.Errorf("incorrect down migration. got %q, want %q", down, want)
+	}
+
+	back := filepath.Join(dir, "back")
+	if err := ConvertFromMigrate(migrate, back); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(back, "00001_create_post.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stmts, _, _ := getSQLStatements(f, true)
+	if len(stmts) != 1 {
+		t.Errorf("expected the up migration as a single statement, got %q", stmts)
+	}
+}
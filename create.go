@@ -2,36 +2,70 @@ package goose
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
+	"unicode"
 )
 
 // Create writes a new blank migration file.
 func CreateWithTemplate(db *sql.DB, dir string, migrationTemplate *template.Template, name, migrationType string) error {
-	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	return createMigration(dir, migrationTemplate, name, migrationType, createOptions{})
+}
+
+// createOptions are the flags of the create command.
+type createOptions struct {
+	// force numbers the name of the new migration when a migration with the
+	// same name already exists, instead of failing.
+	force bool
+	// paired writes an SQL migration as NNN_name.up.sql and
+	// NNN_name.down.sql files.
+	paired bool
+}
+
+// createMigration writes a new migration file named after the slug of name.
+func createMigration(dir string, migrationTemplate *template.Template, name, migrationType string, opts createOptions) error {
+	slug := slugify(name)
+	if slug == "" {
+		return fmt.Errorf("%q: migration names need letters or digits", name)
+	}
+
+	version, err := nextVersion(dir)
 	if err != nil {
 		return err
 	}
+	if slug, err = uniqueName(dir, slug, opts.force); err != nil {
+		return err
+	}
 
-	// Initial version.
-	version := "00001"
+	tmpl := sqlMigrationTemplate
+	switch migrationType {
+	case "sql":
+	case "go":
+		tmpl = goSQLMigrationTemplate
+	case "index":
+		tmpl = concurrentIndexTemplate
+		migrationType = "sql"
+	default:
+		return fmt.Errorf("%q: unknown migration type, must be go, sql or index", migrationType)
+	}
 
-	if last, err := migrations.Last(); err == nil {
-		version = fmt.Sprintf("%05v", last.Version+1)
+	if opts.paired {
+		if migrationType != "sql" {
+			return fmt.Errorf("-paired only applies to sql migrations")
+		}
+		return createPaired(dir, fmt.Sprintf("%v_%v", version, slug), version)
 	}
 
-	filename := fmt.Sprintf("%v_%v.%v", version, name, migrationType)
+	filename := fmt.Sprintf("%v_%v.%v", version, slug, migrationType)
 
 	fpath := filepath.Join(dir, filename)
 
-	tmpl := sqlMigrationTemplate
-	if migrationType == "go" {
-		tmpl = goSQLMigrationTemplate
-	}
-
 	if migrationTemplate != nil {
 		tmpl = migrationTemplate
 	}
@@ -45,6 +79,159 @@ func CreateWithTemplate(db *sql.DB, dir string, migrationTemplate *template.Temp
 	return nil
 }
 
+// createPaired writes the up and down files of a paired migration.
+func createPaired(dir, base, version string) error {
+	for _, f := range []struct {
+		ext  string
+		tmpl *template.Template
+	}{{migrateUpExt, pairedUpTemplate}, {migrateDownExt, pairedDownTemplate}} {
+		path, err := writeTemplateToFile(filepath.Join(dir, base+f.ext), f.tmpl, version)
+		if err != nil {
+			return err
+		}
+		log.Printf("Created new file: %s\n", path)
+	}
+	return nil
+}
+
+// slugify turns a migration name into lower-case words separated by
+// underscores, e.g. "Add users table!" or "AddUsersTable" into
+// add_users_table. Characters other than ASCII letters and digits separate
+// words.
+func slugify(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	sep := false
+	for i, r := range runes {
+		isUpper := r >= 'A' && r <= 'Z'
+		isLower := r >= 'a' && r <= 'z'
+		isDigit := r >= '0' && r <= '9'
+		if !isUpper && !isLower && !isDigit {
+			sep = b.Len() > 0
+			continue
+		}
+
+		// CamelCase boundaries: aB, 1B, and the B of ABc.
+		if isUpper && i > 0 {
+			prev := runes[i-1]
+			if (prev >= 'a' && prev <= 'z') || (prev >= '0' && prev <= '9') ||
+				(prev >= 'A' && prev <= 'Z' && i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z') {
+				sep = b.Len() > 0
+			}
+		}
+		if sep {
+			b.WriteByte('_')
+			sep = false
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// uniqueName checks that no migration of dir is already named slug. With
+// force, the first free slug_N is returned instead of an error.
+func uniqueName(dir, slug string, force bool) (string, error) {
+	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		return "", err
+	}
+	existing := map[string]string{}
+	for _, m := range migrations {
+		existing[migrationName(m.Source)] = filepath.Base(m.Source)
+	}
+
+	file, ok := existing[slug]
+	if !ok {
+		return slug, nil
+	}
+	if !force {
+		return "", fmt.Errorf("%s already has this name, pick another one or use -force", file)
+	}
+	for n := 2; ; n++ {
+		if name := fmt.Sprintf("%s_%d", slug, n); existing[name] == "" {
+			return name, nil
+		}
+	}
+}
+
+// migrationName returns the name of a migration file, without its version
+// and extension.
+func migrationName(path string) string {
+	name := filepath.Base(path)
+	if i := strings.Index(name, "_"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, ext := range []string{gzipSQLExt, migrateUpExt, ".sql", ".go"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// parseCreateArgs parses "[-force] [-paired] NAME [TYPE]".
+func parseCreateArgs(args []string) (name, migrationType string, opts createOptions, err error) {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	fs.BoolVar(&opts.force, "force", false, "create the migration even if one has the same name, numbering the new name")
+	fs.BoolVar(&opts.paired, "paired", false, "write an sql migration as .up.sql and .down.sql files")
+	if err := fs.Parse(args); err != nil {
+		return "", "", opts, err
+	}
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		return "", "", opts, fmt.Errorf("create must be of form: goose [OPTIONS] DRIVER DBSTRING create [-force] [-paired] NAME [go|sql|index]")
+	}
+	migrationType = "go"
+	if opts.paired {
+		migrationType = "sql"
+	}
+	if fs.NArg() == 2 {
+		migrationType = fs.Arg(1)
+	}
+	return fs.Arg(0), migrationType, opts, nil
+}
+
+var sequential = false
+
+// SetSequential sets whether new migrations are numbered sequentially,
+// 00001, 00002..., instead of with a YYYYMMDDHHMMSS timestamp, the default,
+// which doesn't collide across branches. The sequential version policy
+// implies it.
+func SetSequential(s bool) {
+	sequential = s
+}
+
+// nextVersion returns the version of a new migration in dir: the current
+// time in the configured time zone, or the sequential version following the
+// last sequential one, timestamp versions of a folder mixing both formats
+// being left out.
+func nextVersion(dir string) (string, error) {
+	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		return "", err
+	}
+
+	if sequential || versionPolicy == VersionSequential {
+		last := int64(0)
+		for _, m := range migrations {
+			if !isTimestampVersion(m.Version) && m.Version > last {
+				last = m.Version
+			}
+		}
+		return fmt.Sprintf("%05v", last+1), nil
+	}
+
+	// Migrations created within the same second, or with a clock behind the
+	// last migration's, follow it.
+	now := localTime(time.Now()).Truncate(time.Second)
+	if last, err := migrations.Last(); err == nil && isTimestampVersion(last.Version) {
+		t, _ := time.ParseInLocation(timestampVersionFormat, strconv.FormatInt(last.Version, 10), timeZone)
+		if !now.After(t) {
+			now = t.Add(time.Second)
+		}
+	}
+	return now.Format(timestampVersionFormat), nil
+}
+
 // Create writes a new blank migration file.
 func Create(db *sql.DB, dir, name, migrationType string) error {
 	return CreateWithTemplate(db, dir, nil, name, migrationType)
@@ -1,6 +1,10 @@
 package goose
 
-import "fmt"
+import (
+	"database/sql"
+	"fmt"
+	"os"
+)
 
 // CreateDB creates database
 func CreateDB(dbstring string) error {
@@ -11,6 +15,10 @@ func CreateDB(dbstring string) error {
 		return fmt.Errorf("failed to get db name: %v", err)
 	}
 
+	if _, ok := d.(*DuckDBDialect); ok {
+		return createDuckDB(dbName)
+	}
+
 	db, err := d.connectToServer(dbstring)
 	if err != nil {
 		return fmt.Errorf("failed to connect to the server: %v", err)
@@ -19,3 +27,17 @@ func CreateDB(dbstring string) error {
 	_, err = db.Exec(fmt.Sprintf("CREATE DATABASE %s", dbName))
 	return err
 }
+
+// createDuckDB creates the DuckDB database file path, which the duckdb
+// driver initializes when opening it.
+func createDuckDB(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("database %s already exists", path)
+	}
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Ping()
+}
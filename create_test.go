@@ -0,0 +1,83 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Add users table!":   "add_users_table",
+		"AddSomeColumns":     "add_some_columns",
+		"add_users_table":    "add_users_table",
+		"HTTPServer logs":    "http_server_logs",
+		"v2 -- drop legacy ": "v2_drop_legacy",
+		"!!!":                "",
+	}
+	for name, want := range tests {
+		if got := slugify(name); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCreateCollision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-create")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetSequential(true)
+	defer SetSequential(false)
+
+	if err := createMigration(dir, nil, "Add users table!", "sql", createOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := createMigration(dir, nil, "AddUsersTable", "sql", createOptions{}); err == nil {
+		t.Fatal("expected a name collision")
+	}
+	if err := createMigration(dir, nil, "AddUsersTable", "sql", createOptions{force: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "00001_add_users_table.sql" || filepath.Base(files[1]) != "00002_add_users_table_2.sql" {
+		t.Errorf("got %v", files)
+	}
+}
+
+func TestCreateTimestampVersion(t *testing.T) {
+	dir := t.TempDir()
+	// A folder mixing both formats, with a migration from the future.
+	for _, name := range []string{"00001_users.sql", "00002_accounts.sql", "29990101000000_orders.sql"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("-- +goose Up\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	version, err := nextVersion(dir)
+	if err != nil || version != "29990101000001" {
+		t.Errorf("got version %s, error %v, want the second after the last migration", version, err)
+	}
+
+	SetSequential(true)
+	defer SetSequential(false)
+	if version, err = nextVersion(dir); err != nil || version != "00003" {
+		t.Errorf("got sequential version %s, error %v, want 00003", version, err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "29990101000000_orders.sql")); err != nil {
+		t.Fatal(err)
+	}
+	SetSequential(false)
+	before := time.Now().UTC().Format(timestampVersionFormat)
+	if version, err = nextVersion(dir); err != nil || len(version) != len(before) || version < before {
+		t.Errorf("got version %s, error %v, want the current time", version, err)
+	}
+}
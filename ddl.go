@@ -0,0 +1,131 @@
+package goose
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+var strictDDL = false
+
+// SetStrictDDL sets whether migrations with statements known to lock or
+// rewrite large tables are refused, instead of only logging a warning. A
+// migration accepts a rule with "-- +goose Allow RULE".
+func SetStrictDDL(v bool) {
+	strictDDL = v
+}
+
+// ddlRule flags statements matching re, unless they match except.
+type ddlRule struct {
+	name    string
+	re      *regexp.Regexp
+	except  *regexp.Regexp
+	message string
+}
+
+var postgresDDLRules = []ddlRule{
+	{
+		name:    "alter-column-type",
+		re:      regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\b.*\bALTER\s+(COLUMN\s+)?\S+\s+(SET\s+DATA\s+)?TYPE\b`),
+		message: "changing the type of a column rewrites the table under an ACCESS EXCLUSIVE lock",
+	},
+	{
+		name:    "set-not-null",
+		re:      regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\b.*\bALTER\s+(COLUMN\s+)?\S+\s+SET\s+NOT\s+NULL\b`),
+		message: "SET NOT NULL scans the table under an ACCESS EXCLUSIVE lock, validate a CHECK (col IS NOT NULL) NOT VALID constraint first",
+	},
+	{
+		name:    "blocking-index",
+		re:      regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\b`),
+		except:  regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s+CONCURRENTLY\b`),
+		message: "CREATE INDEX blocks writes to the table, use CREATE INDEX CONCURRENTLY",
+	},
+	{
+		name:    "validated-constraint",
+		re:      regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\b.*\bADD\s+(CONSTRAINT\s+\S+\s+)?(FOREIGN\s+KEY|CHECK)\b`),
+		except:  regexp.MustCompile(`(?is)\bNOT\s+VALID\b`),
+		message: "adding a constraint scans the table under lock, add it NOT VALID then VALIDATE CONSTRAINT",
+	},
+}
+
+var mysqlDDLRules = []ddlRule{
+	{
+		name:    "add-not-null-column",
+		re:      regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\b.*\bADD\s+(COLUMN\s+)?.*\bNOT\s+NULL\b.*\bDEFAULT\b`),
+		message: "adding a NOT NULL column with a default copies the table before MySQL 8.0",
+	},
+	{
+		name:    "modify-column",
+		re:      regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\b.*\b(MODIFY|CHANGE)\s+(COLUMN\s+)?`),
+		message: "changing a column definition copies the table, consider an online migration",
+	},
+}
+
+// ddlRules returns the rules of the current dialect.
+func ddlRules(c *config) []ddlRule {
+	switch c.dialect.(type) {
+	case *PostgresDialect:
+		return postgresDDLRules
+	case *MySQLDialect, *TiDBDialect:
+		return mysqlDDLRules
+	}
+	return nil
+}
+
+// ddlFinding is a statement flagged by a rule.
+type ddlFinding struct {
+	line int
+	rule ddlRule
+}
+
+// checkStatements returns the statements flagged by the rules, skipping the
+// allowed ones.
+func checkStatements(c *config, statements []string, lines []int, allowed map[string]bool) []ddlFinding {
+	var findings []ddlFinding
+	for i, query := range statements {
+		query = stripComments(query)
+		for _, rule := range ddlRules(c) {
+			if allowed[rule.name] || !rule.re.MatchString(query) || (rule.except != nil && rule.except.MatchString(query)) {
+				continue
+			}
+			findings = append(findings, ddlFinding{line: lines[i], rule: rule})
+		}
+	}
+	return findings
+}
+
+// checkDDL logs a warning for every dangerous statement of the up migrations
+// and, in strict mode, refuses the first migration with one.
+func checkDDL(c *config, migrations Migrations) error {
+	if len(ddlRules(c)) == 0 {
+		return nil
+	}
+	for _, m := range migrations {
+		if !isSQLMigration(m.Source) {
+			continue
+		}
+
+		allowed := map[string]bool{}
+		values, err := readAnnotations(c, m.Source, "Allow")
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			allowed[v] = true
+		}
+
+		statements, lines, _, err := readSQLStatements(c, m.Source, true)
+		if err != nil {
+			return err
+		}
+
+		findings := checkStatements(c, statements, lines, allowed)
+		for _, finding := range findings {
+			c.log.Printf("WARNING: %s:%d: %s (%s)\n", filepath.Base(m.Source), finding.line, finding.rule.message, finding.rule.name)
+		}
+		if strictDDL && len(findings) > 0 {
+			return &ValidationError{File: filepath.Base(m.Source), Err: fmt.Errorf("%s: dangerous statements refused in strict mode, add \"-- +goose Allow %s\" to accept them", filepath.Base(m.Source), findings[0].rule.name)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package goose
+
+import "testing"
+
+func TestCheckStatements(t *testing.T) {
+	defer SetDialect("postgres")
+
+	tests := []struct {
+		dialect string
+		query   string
+		rule    string
+	}{
+		{"postgres", "ALTER TABLE users ALTER COLUMN id TYPE bigint;", "alter-column-type"},
+		{"postgres", "ALTER TABLE users ALTER email SET DATA TYPE citext;", "alter-column-type"},
+		{"postgres", "ALTER TABLE users ALTER COLUMN email SET NOT NULL;", "set-not-null"},
+		{"postgres", "CREATE UNIQUE INDEX users_email ON users (email);", "blocking-index"},
+		{"postgres", "CREATE INDEX CONCURRENTLY users_email ON users (email);", ""},
+		{"postgres", "ALTER TABLE orders ADD CONSTRAINT orders_user FOREIGN KEY (user_id) REFERENCES users (id);", "validated-constraint"},
+		{"postgres", "ALTER TABLE orders ADD CONSTRAINT orders_user FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;", ""},
+		{"postgres", "ALTER TABLE users ADD COLUMN nickname text;", ""},
+		{"mysql", "ALTER TABLE users ADD COLUMN active TINYINT(1) NOT NULL DEFAULT 1;", "add-not-null-column"},
+		{"mysql", "ALTER TABLE users MODIFY COLUMN email VARCHAR(320);", "modify-column"},
+		{"mysql", "ALTER TABLE users ADD COLUMN nickname VARCHAR(64) NULL;", ""},
+	}
+	for _, test := range tests {
+		if err := SetDialect(test.dialect); err != nil {
+			t.Fatal(err)
+		}
+		findings := checkStatements(packageConfig(), []string{test.query}, []int{1}, nil)
+		rule := ""
+		if len(findings) > 0 {
+			rule = findings[0].rule.name
+		}
+		if rule != test.rule {
+			t.Errorf("%s: %q: got rule %q, want %q", test.dialect, test.query, rule, test.rule)
+		}
+		if findings := checkStatements(packageConfig(), []string{test.query}, []int{1}, map[string]bool{test.rule: true}); len(findings) > 0 {
+			t.Errorf("%s: %q: allowed rule still reported", test.dialect, test.query)
+		}
+	}
+}
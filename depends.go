@@ -0,0 +1,107 @@
+package goose
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// migrationDependencies returns the versions a migration declares it depends
+// on with "-- +goose DependsOn VERSION...", versions being separated by
+// spaces or commas.
+func migrationDependencies(c *config, m *Migration) ([]int64, error) {
+	if !isSQLMigration(m.Source) {
+		return nil, nil
+	}
+	values, err := readAnnotations(c, m.Source, "DependsOn")
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []int64
+	for _, value := range values {
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			v, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil, &ValidationError{File: filepath.Base(m.Source), Err: fmt.Errorf("DependsOn: %q is not a version", field)}
+			}
+			deps = append(deps, v)
+		}
+	}
+	return deps, nil
+}
+
+// validateDependencies checks the dependency graph of the pending
+// migrations: every dependency must be one of migrations, without cycles,
+// and have a lower version unless it is already applied, as migrations are
+// applied in version order. The files of the applied migrations aren't read.
+func validateDependencies(c *config, migrations, pending Migrations) error {
+	byVersion := map[int64]*Migration{}
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	deps := map[int64][]int64{}
+	for _, m := range pending {
+		d, err := migrationDependencies(c, m)
+		if err != nil {
+			return err
+		}
+		for _, v := range d {
+			if _, ok := byVersion[v]; !ok {
+				return &ValidationError{File: filepath.Base(m.Source), Err: fmt.Errorf("depends on version %d, which doesn't exist", v)}
+			}
+		}
+		deps[m.Version] = d
+	}
+
+	// Depth-first search, reporting the first cycle found.
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[int64]int{}
+	var path []int64
+	var visit func(v int64) error
+	visit = func(v int64) error {
+		switch state[v] {
+		case visiting:
+			cycle := []string{}
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append([]string{strconv.FormatInt(path[i], 10)}, cycle...)
+				if path[i] == v {
+					break
+				}
+			}
+			cycle = append(cycle, strconv.FormatInt(v, 10))
+			return &ValidationError{File: filepath.Base(byVersion[v].Source), Err: fmt.Errorf("dependency cycle %s", strings.Join(cycle, " -> "))}
+		case visited:
+			return nil
+		}
+		state[v] = visiting
+		path = append(path, v)
+		for _, d := range deps[v] {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[v] = visited
+		return nil
+	}
+	for _, m := range migrations {
+		if err := visit(m.Version); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range pending {
+		for _, v := range deps[m.Version] {
+			if _, ok := deps[v]; ok && v > m.Version {
+				return &ValidationError{File: filepath.Base(m.Source), Err: fmt.Errorf("depends on version %d, which is applied after it: give it a version above %d", v, v)}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package goose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		deps map[int64]string
+		err  string
+	}{
+		{map[int64]string{1: "", 2: "1", 3: "1, 2"}, ""},
+		{map[int64]string{1: "", 2: "4"}, "version 4, which doesn't exist"},
+		{map[int64]string{1: "3", 2: "1", 3: "2"}, "dependency cycle 1 -> 3 -> 2 -> 1"},
+		{map[int64]string{1: "2", 2: ""}, "give it a version above 2"},
+		{map[int64]string{1: "", 2: "first"}, `"first" is not a version`},
+	}
+	for i, test := range tests {
+		dir, err := ioutil.TempDir("", "goose-depends")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		var migrations Migrations
+		for v := int64(1); v <= int64(len(test.deps)); v++ {
+			sql := "-- +goose Up\nSELECT 1;\n"
+			if test.deps[v] != "" {
+				sql = "-- +goose DependsOn " + test.deps[v] + "\n" + sql
+			}
+			path := filepath.Join(dir, fmt.Sprintf("%05d_m.sql", v))
+			if err := ioutil.WriteFile(path, []byte(sql), 0644); err != nil {
+				t.Fatal(err)
+			}
+			migrations = append(migrations, &Migration{Version: v, Source: path})
+		}
+
+		err = validateDependencies(packageConfig(), migrations, migrations)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%d: unexpected error %v", i, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%d: got %v, want %q", i, err, test.err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"net/url"
 	"regexp"
 	"strings"
@@ -12,36 +13,195 @@ import (
 // SQLDialect abstracts the details of specific SQL dialects
 // for goose's few SQL specific statements
 type SQLDialect interface {
-	createVersionTableSQL() string // sql string to create the goose_db_version table
-	insertVersionSQL() string      // sql string to insert the initial version table row
-	dbVersionQuery(db *sql.DB) (*sql.Rows, error)
+	createVersionTableSQL(t gooseTables) string // sql string to create the version table
+	insertVersionSQL(t gooseTables) string      // sql string to insert the initial version table row
 	getDBName(dbstring string) (string, error)
-	connectToServer(dbstring string) (*sql.DB, error) //ignores dbname when connecting to the server
+	connectToServer(dbstring string) (*sql.DB, error)     //ignores dbname when connecting to the server
+	createAuditTableSQL(t gooseTables) string             // sql string to create the goose_audit table if needed
+	insertAuditSQL(t gooseTables) string                  // sql string to insert a goose_audit row
+	createContextTableSQL(t gooseTables) string           // sql string to create the run context table if needed
+	insertContextSQL(t gooseTables) string                // sql string to insert a run context row
+	createSeedTableSQL(t gooseTables) string              // sql string to create the goose_seeds table if needed
+	insertSeedSQL(t gooseTables) string                   // sql string to insert a goose_seeds row
+	currentSchemaSQL() string                             // sql expression of the current schema, for information_schema queries
+	utcNowSQL() string                                    // sql expression of the current time in UTC, stored in the tstamp columns
+	indexesQuery() string                                 // sql query listing table, index name and definition of the current schema
+	columnsQuery() string                                 // sql query listing table, column, type, is_nullable and default of the current schema
+	foreignKeysQuery() string                             // sql query listing table, column, referenced table and column of the current schema
+	alterColumnSQL(table string, c schemaColumn) []string // sql statements changing a column to the given definition
+	dropIndexSQL(table, index string) string              // sql string to drop an index
+	tryLockSQL(t gooseTables) string                      // sql query taking the session-level migration lock without waiting, returning a boolean; empty if unsupported
+	unlockSQL(t gooseTables) string                       // sql string releasing the migration lock
+	versionIndexQuery(t gooseTables) string               // sql query counting the version table indexes on version_id; empty if unsupported
+	createVersionIndexSQL(t gooseTables) string           // sql string to index the version table on version_id
+	addDownSQLColumnSQL(t gooseTables) string             // sql string adding the down_sql column to the version table
+}
+
+// placeholder returns the n-th (1-based) bind parameter of the current
+// dialect.
+func placeholder(n int) string {
+	return packageConfig().placeholder(n)
+}
+
+// placeholder returns the n-th (1-based) bind parameter of the dialect of c,
+// following its insertVersionSQL so that registered dialects embedding a
+// built-in one get the same style.
+func (c *config) placeholder(n int) string {
+	if strings.Contains(c.insertVersionSQL(), "?") {
+		return "?"
+	}
+	return fmt.Sprintf("$%d", n)
+}
+
+// insertVersionSQL is the sql string recording a version in the version
+// table of c.
+func (c *config) insertVersionSQL() string {
+	return c.dialect.insertVersionSQL(c.tables)
+}
+
+// defaultTableName is the version table unless set with SetTableName.
+const defaultTableName = "goose_db_version"
+
+// tableName is the version table, see SetTableName.
+var tableName = defaultTableName
+
+// tableSchema is the schema of the version table, see SetTableSchema.
+var tableSchema string
+
+// gooseTables names the version table and the tables goose keeps next to
+// it: the package tables set with SetTableName and SetTableSchema, or the
+// ones of a Provider.
+type gooseTables struct {
+	name   string // version table, unqualified
+	schema string // schema of the tables, the current schema if empty
+}
+
+// packageTables returns the tables set with SetTableName and
+// SetTableSchema.
+func packageTables() gooseTables {
+	return gooseTables{name: tableName, schema: tableSchema}
+}
+
+// TableName returns the name of the version table, qualified with its
+// schema if one was set with SetTableSchema.
+func TableName() string {
+	return packageTables().version()
+}
+
+// version returns the version table, qualified with its schema.
+func (t gooseTables) version() string {
+	return t.qualified(t.name)
+}
+
+// qualified qualifies the name of one of goose's tables with the schema of
+// t, if any.
+func (t gooseTables) qualified(name string) string {
+	if t.schema != "" {
+		return t.schema + "." + name
+	}
+	return name
+}
+
+// SetTableName sets the name of the version table, goose_db_version by
+// default, e.g. so that services sharing a database keep separate version
+// histories. The tables goose keeps next to it, the migration lock and the
+// version index are named after it.
+func SetTableName(name string) {
+	tableName = name
+}
+
+// SetTableSchema sets the schema of the version table and the other goose
+// tables, such as goose_audit and goose_seeds, the current schema
+// (search_path) by default. The schema must exist.
+func SetTableSchema(schema string) {
+	tableSchema = schema
+}
+
+// schemaSQL returns the sql expression of the schema of the version table,
+// for information_schema queries.
+func (t gooseTables) schemaSQL(d SQLDialect) string {
+	if t.schema != "" {
+		return "'" + t.schema + "'"
+	}
+	return d.currentSchemaSQL()
+}
+
+// versionIndex names the index of the version table on version_id. Indexes
+// live in the schema of their table, the name isn't qualified.
+func (t gooseTables) versionIndex() string {
+	return t.name + "_version_id"
+}
+
+// namedLockSQL is the sql expression of the name of the MySQL and TiDB
+// migration lock. Named locks are server-wide, unlike advisory locks: the
+// name includes the database of the version table, hashed to fit the 64
+// characters MySQL allows.
+func (t gooseTables) namedLockSQL(d SQLDialect) string {
+	return fmt.Sprintf("CONCAT('goose_', SHA1(CONCAT(IFNULL(%s, ''), '.', '%s')))", t.schemaSQL(d), t.name)
+}
+
+// advisoryLockKey derives the PostgreSQL advisory lock key from the version
+// table.
+func (t gooseTables) advisoryLockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(t.version()))
+	return int64(h.Sum64())
 }
 
 var dialect SQLDialect = &PostgresDialect{}
 
+// registeredDialects are the dialects added with RegisterDialect, by name.
+var registeredDialects = map[string]SQLDialect{}
+
+// RegisterDialect makes d available to SetDialect under name, e.g. for a
+// driver of a database compatible with a built-in dialect. It replaces the
+// built-in dialect of the same name, if any. As SQLDialect has unexported
+// methods, custom dialects embed the built-in dialect they derive from.
+func RegisterDialect(name string, d SQLDialect) {
+	if d == nil {
+		panic(fmt.Sprintf("failed to register dialect %q: dialect is nil", name))
+	}
+	registeredDialects[name] = d
+}
+
 // GetDialect gets the SQLDialect
 func GetDialect() SQLDialect {
 	return dialect
 }
 
-// SetDialect sets the SQLDialect
+// SetDialect sets the SQLDialect by name, the dialects added with
+// RegisterDialect first.
 func SetDialect(d string) error {
+	found, err := lookupDialect(d)
+	if err != nil {
+		return err
+	}
+	dialect = found
+	return nil
+}
+
+// lookupDialect returns the dialect named d, the dialects added with
+// RegisterDialect first.
+func lookupDialect(d string) (SQLDialect, error) {
+	if registered, ok := registeredDialects[d]; ok {
+		return registered, nil
+	}
+
 	switch d {
 	case "postgres", "pgx":
-		dialect = &PostgresDialect{}
+		return &PostgresDialect{}, nil
 	case "mysql":
-		dialect = &MySQLDialect{}
+		return &MySQLDialect{}, nil
 	case "redshift":
-		dialect = &RedshiftDialect{}
+		return &RedshiftDialect{}, nil
 	case "tidb":
-		dialect = &TiDBDialect{}
-	default:
-		return fmt.Errorf("%q: unknown dialect", d)
+		return &TiDBDialect{}, nil
+	case "snowflake":
+		return &SnowflakeDialect{}, nil
+	case "duckdb":
+		return &DuckDBDialect{}, nil
 	}
-
-	return nil
+	return nil, fmt.Errorf("%q: unknown dialect", d)
 }
 
 ////////////////////////////
@@ -51,27 +211,19 @@ func SetDialect(d string) error {
 // PostgresDialect struct.
 type PostgresDialect struct{}
 
-func (pg PostgresDialect) createVersionTableSQL() string {
-	return `CREATE TABLE goose_db_version (
+func (pg PostgresDialect) createVersionTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE %s (
             	id serial NOT NULL,
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default now(),
+                down_sql bytea NULL,
                 PRIMARY KEY(id)
-            );`
-}
-
-func (pg PostgresDialect) insertVersionSQL() string {
-	return "INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, $2);"
+            );`, t.version())
 }
 
-func (pg PostgresDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query("SELECT version_id, is_applied from goose_db_version ORDER BY id DESC")
-	if err != nil {
-		return nil, err
-	}
-
-	return rows, err
+func (pg PostgresDialect) insertVersionSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tstamp) VALUES ($1, $2, %s);", t.version(), pg.utcNowSQL())
 }
 
 func (pg PostgresDialect) connectToServer(dbstring string) (*sql.DB, error) {
@@ -105,6 +257,130 @@ func (pg PostgresDialect) getDBName(dbstring string) (string, error) {
 	return strings.Replace(dbURL.Path, "/", "", -1), nil
 }
 
+func (pg PostgresDialect) createAuditTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id serial NOT NULL,
+                version_id bigint NOT NULL,
+                file text NOT NULL,
+                direction varchar(4) NOT NULL,
+                operator text NOT NULL,
+                client_host text NOT NULL,
+                sql_sha256 char(64) NULL,
+                rows_affected bigint NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.audit())
+}
+
+func (pg PostgresDialect) insertAuditSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, file, direction, operator, client_host, sql_sha256, rows_affected, duration_ms, tstamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, %s);", t.audit(), pg.utcNowSQL())
+}
+
+func (pg PostgresDialect) createContextTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_context (
+                id serial NOT NULL,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                commit_sha text NULL,
+                context text NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (pg PostgresDialect) insertContextSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s_context (version_id, is_applied, commit_sha, context, tstamp) VALUES ($1, $2, $3, $4, %s);", t.version(), pg.utcNowSQL())
+}
+
+func (pg PostgresDialect) createSeedTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id serial NOT NULL,
+                name varchar(255) NOT NULL,
+                env varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.seeds())
+}
+
+func (pg PostgresDialect) insertSeedSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (name, env, checksum, tstamp) VALUES ($1, $2, $3, %s);", t.seeds(), pg.utcNowSQL())
+}
+
+func (pg PostgresDialect) currentSchemaSQL() string {
+	return "current_schema()"
+}
+
+func (pg PostgresDialect) utcNowSQL() string {
+	return "now() at time zone 'utc'"
+}
+
+func (pg PostgresDialect) indexesQuery() string {
+	return "SELECT tablename, indexname, indexdef FROM pg_indexes WHERE schemaname = current_schema()"
+}
+
+func (pg PostgresDialect) columnsQuery() string {
+	return `SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod),
+		CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END, pg_get_expr(d.adbin, d.adrelid)
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = current_schema() AND c.relkind = 'r' AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`
+}
+
+func (pg PostgresDialect) foreignKeysQuery() string {
+	return `SELECT kcu.table_name, kcu.column_name, ccu.table_name, ccu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+		JOIN information_schema.constraint_column_usage ccu
+			ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()
+		ORDER BY kcu.table_name, kcu.column_name`
+}
+
+func (pg PostgresDialect) alterColumnSQL(table string, c schemaColumn) []string {
+	stmts := []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, c.name, c.dataType)}
+	if c.notNull {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, c.name))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, c.name))
+	}
+	if c.def != "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, c.name, c.def))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, c.name))
+	}
+	return stmts
+}
+
+func (pg PostgresDialect) dropIndexSQL(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s;", index)
+}
+
+func (pg PostgresDialect) tryLockSQL(t gooseTables) string {
+	return fmt.Sprintf("SELECT pg_try_advisory_lock(%d)", t.advisoryLockKey())
+}
+
+func (pg PostgresDialect) unlockSQL(t gooseTables) string {
+	return fmt.Sprintf("SELECT pg_advisory_unlock(%d)", t.advisoryLockKey())
+}
+
+func (pg PostgresDialect) versionIndexQuery(t gooseTables) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM pg_indexes WHERE schemaname = %s AND tablename = '%s' AND indexname = '%s'", t.schemaSQL(pg), t.name, t.versionIndex())
+}
+
+func (pg PostgresDialect) createVersionIndexSQL(t gooseTables) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (version_id);", t.versionIndex(), t.version())
+}
+
+func (pg PostgresDialect) addDownSQLColumnSQL(t gooseTables) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN down_sql bytea NULL;", t.version())
+}
+
 ////////////////////////////
 // MySQL
 ////////////////////////////
@@ -112,27 +388,19 @@ func (pg PostgresDialect) getDBName(dbstring string) (string, error) {
 // MySQLDialect struct.
 type MySQLDialect struct{}
 
-func (m MySQLDialect) createVersionTableSQL() string {
-	return `CREATE TABLE goose_db_version (
+func (m MySQLDialect) createVersionTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE %s (
                 id serial NOT NULL,
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default now(),
+                down_sql longblob NULL,
                 PRIMARY KEY(id)
-            );`
+            );`, t.version())
 }
 
-func (m MySQLDialect) insertVersionSQL() string {
-	return "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, ?);"
-}
-
-func (m MySQLDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query("SELECT version_id, is_applied from goose_db_version ORDER BY id DESC")
-	if err != nil {
-		return nil, err
-	}
-
-	return rows, err
+func (m MySQLDialect) insertVersionSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tstamp) VALUES (?, ?, %s);", t.version(), m.utcNowSQL())
 }
 
 func (m MySQLDialect) connectToServer(dbstring string) (*sql.DB, error) {
@@ -147,6 +415,112 @@ func (m MySQLDialect) getDBName(dbstring string) (string, error) {
 	return strings.Replace(dbURL.Path, "/", "", -1), nil
 }
 
+func (m MySQLDialect) createAuditTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id serial NOT NULL,
+                version_id bigint NOT NULL,
+                file varchar(255) NOT NULL,
+                direction varchar(4) NOT NULL,
+                operator varchar(255) NOT NULL,
+                client_host varchar(255) NOT NULL,
+                sql_sha256 char(64) NULL,
+                rows_affected bigint NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.audit())
+}
+
+func (m MySQLDialect) insertAuditSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, file, direction, operator, client_host, sql_sha256, rows_affected, duration_ms, tstamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?, %s);", t.audit(), m.utcNowSQL())
+}
+
+func (m MySQLDialect) createContextTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_context (
+                id serial NOT NULL,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                commit_sha varchar(64) NULL,
+                context text NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (m MySQLDialect) insertContextSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s_context (version_id, is_applied, commit_sha, context, tstamp) VALUES (?, ?, ?, ?, %s);", t.version(), m.utcNowSQL())
+}
+
+func (m MySQLDialect) createSeedTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id serial NOT NULL,
+                name varchar(255) NOT NULL,
+                env varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.seeds())
+}
+
+func (m MySQLDialect) insertSeedSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (name, env, checksum, tstamp) VALUES (?, ?, ?, %s);", t.seeds(), m.utcNowSQL())
+}
+
+func (m MySQLDialect) currentSchemaSQL() string {
+	return "DATABASE()"
+}
+
+func (m MySQLDialect) utcNowSQL() string {
+	return "utc_timestamp()"
+}
+
+func (m MySQLDialect) indexesQuery() string {
+	return `SELECT table_name, index_name,
+		CONCAT('CREATE ', IF(non_unique = 0, 'UNIQUE ', ''), 'INDEX ', index_name, ' ON ', table_name, ' (', GROUP_CONCAT(column_name ORDER BY seq_in_index), ')')
+		FROM information_schema.statistics WHERE table_schema = DATABASE()
+		GROUP BY table_name, index_name, non_unique`
+}
+
+func (m MySQLDialect) columnsQuery() string {
+	return `SELECT table_name, column_name, column_type, is_nullable, column_default
+		FROM information_schema.columns WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position`
+}
+
+func (m MySQLDialect) foreignKeysQuery() string {
+	return `SELECT table_name, column_name, referenced_table_name, referenced_column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL
+		ORDER BY table_name, column_name`
+}
+
+func (m MySQLDialect) alterColumnSQL(table string, c schemaColumn) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, c.definition())}
+}
+
+func (m MySQLDialect) dropIndexSQL(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s;", index, table)
+}
+
+func (m MySQLDialect) tryLockSQL(t gooseTables) string {
+	return fmt.Sprintf("SELECT GET_LOCK(%s, 0)", t.namedLockSQL(m))
+}
+
+func (m MySQLDialect) unlockSQL(t gooseTables) string {
+	return fmt.Sprintf("SELECT RELEASE_LOCK(%s)", t.namedLockSQL(m))
+}
+
+func (m MySQLDialect) versionIndexQuery(t gooseTables) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = %s AND table_name = '%s' AND index_name = '%s'", t.schemaSQL(m), t.name, t.versionIndex())
+}
+
+func (m MySQLDialect) createVersionIndexSQL(t gooseTables) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (version_id);", t.versionIndex(), t.version())
+}
+
+func (m MySQLDialect) addDownSQLColumnSQL(t gooseTables) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN down_sql longblob NULL;", t.version())
+}
+
 ////////////////////////////
 // Redshift
 ////////////////////////////
@@ -154,27 +528,19 @@ func (m MySQLDialect) getDBName(dbstring string) (string, error) {
 // RedshiftDialect struct.
 type RedshiftDialect struct{}
 
-func (rs RedshiftDialect) createVersionTableSQL() string {
-	return `CREATE TABLE goose_db_version (
+func (rs RedshiftDialect) createVersionTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE %s (
             	id integer NOT NULL identity(1, 1),
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default sysdate,
+                down_sql varbyte(1024000) NULL,
                 PRIMARY KEY(id)
-            );`
+            );`, t.version())
 }
 
-func (rs RedshiftDialect) insertVersionSQL() string {
-	return "INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, $2);"
-}
-
-func (rs RedshiftDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query("SELECT version_id, is_applied from goose_db_version ORDER BY id DESC")
-	if err != nil {
-		return nil, err
-	}
-
-	return rows, err
+func (rs RedshiftDialect) insertVersionSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tstamp) VALUES ($1, $2, %s);", t.version(), rs.utcNowSQL())
 }
 
 func (rs RedshiftDialect) connectToServer(dbstring string) (*sql.DB, error) {
@@ -209,6 +575,126 @@ func (rs RedshiftDialect) getDBName(dbstring string) (string, error) {
 	return strings.Replace(dbURL.Path, "/", "", -1), nil
 }
 
+func (rs RedshiftDialect) createAuditTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id integer NOT NULL identity(1, 1),
+                version_id bigint NOT NULL,
+                file varchar(255) NOT NULL,
+                direction varchar(4) NOT NULL,
+                operator varchar(255) NOT NULL,
+                client_host varchar(255) NOT NULL,
+                sql_sha256 char(64) NULL,
+                rows_affected bigint NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default sysdate,
+                PRIMARY KEY(id)
+            );`, t.audit())
+}
+
+func (rs RedshiftDialect) insertAuditSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, file, direction, operator, client_host, sql_sha256, rows_affected, duration_ms, tstamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, %s);", t.audit(), rs.utcNowSQL())
+}
+
+func (rs RedshiftDialect) createContextTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_context (
+                id integer NOT NULL identity(1, 1),
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                commit_sha varchar(64) NULL,
+                context varchar(65535) NULL,
+                tstamp timestamp NULL default sysdate,
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (rs RedshiftDialect) insertContextSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s_context (version_id, is_applied, commit_sha, context, tstamp) VALUES ($1, $2, $3, $4, %s);", t.version(), rs.utcNowSQL())
+}
+
+func (rs RedshiftDialect) createSeedTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id integer NOT NULL identity(1, 1),
+                name varchar(255) NOT NULL,
+                env varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default sysdate,
+                PRIMARY KEY(id)
+            );`, t.seeds())
+}
+
+func (rs RedshiftDialect) insertSeedSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (name, env, checksum, tstamp) VALUES ($1, $2, $3, %s);", t.seeds(), rs.utcNowSQL())
+}
+
+func (rs RedshiftDialect) currentSchemaSQL() string {
+	return "current_schema()"
+}
+
+func (rs RedshiftDialect) utcNowSQL() string {
+	return "sysdate"
+}
+
+func (rs RedshiftDialect) indexesQuery() string {
+	return "SELECT tablename, indexname, indexdef FROM pg_indexes WHERE schemaname = current_schema()"
+}
+
+func (rs RedshiftDialect) columnsQuery() string {
+	return `SELECT table_name, column_name, data_type, is_nullable, column_default
+		FROM information_schema.columns WHERE table_schema = current_schema() ORDER BY table_name, ordinal_position`
+}
+
+func (rs RedshiftDialect) foreignKeysQuery() string {
+	return `SELECT kcu.table_name, kcu.column_name, ccu.table_name, ccu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+		JOIN information_schema.constraint_column_usage ccu
+			ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()
+		ORDER BY kcu.table_name, kcu.column_name`
+}
+
+func (rs RedshiftDialect) alterColumnSQL(table string, c schemaColumn) []string {
+	stmts := []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, c.name, c.dataType)}
+	if c.notNull {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, c.name))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, c.name))
+	}
+	if c.def != "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, c.name, c.def))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, c.name))
+	}
+	return stmts
+}
+
+func (rs RedshiftDialect) dropIndexSQL(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s;", index)
+}
+
+// Redshift has no advisory locks.
+func (rs RedshiftDialect) tryLockSQL(t gooseTables) string {
+	return ""
+}
+
+func (rs RedshiftDialect) unlockSQL(t gooseTables) string {
+	return ""
+}
+
+// Redshift has no indexes.
+func (rs RedshiftDialect) versionIndexQuery(t gooseTables) string {
+	return ""
+}
+
+func (rs RedshiftDialect) createVersionIndexSQL(t gooseTables) string {
+	return ""
+}
+
+func (rs RedshiftDialect) addDownSQLColumnSQL(t gooseTables) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN down_sql varbyte(1024000) NULL;", t.version())
+}
+
 ////////////////////////////
 // TiDB
 ////////////////////////////
@@ -216,27 +702,19 @@ func (rs RedshiftDialect) getDBName(dbstring string) (string, error) {
 // TiDBDialect struct.
 type TiDBDialect struct{}
 
-func (m TiDBDialect) createVersionTableSQL() string {
-	return `CREATE TABLE goose_db_version (
+func (m TiDBDialect) createVersionTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE %s (
                 id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE,
                 version_id bigint NOT NULL,
                 is_applied boolean NOT NULL,
                 tstamp timestamp NULL default now(),
+                down_sql longblob NULL,
                 PRIMARY KEY(id)
-            );`
+            );`, t.version())
 }
 
-func (m TiDBDialect) insertVersionSQL() string {
-	return "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, ?);"
-}
-
-func (m TiDBDialect) dbVersionQuery(db *sql.DB) (*sql.Rows, error) {
-	rows, err := db.Query("SELECT version_id, is_applied from goose_db_version ORDER BY id DESC")
-	if err != nil {
-		return nil, err
-	}
-
-	return rows, err
+func (m TiDBDialect) insertVersionSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tstamp) VALUES (?, ?, %s);", t.version(), m.utcNowSQL())
 }
 
 func (m TiDBDialect) connectToServer(dbstring string) (*sql.DB, error) {
@@ -250,3 +728,451 @@ func (m TiDBDialect) getDBName(dbstring string) (string, error) {
 	}
 	return strings.Replace(dbURL.Path, "/", "", -1), nil
 }
+
+func (m TiDBDialect) createAuditTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE,
+                version_id bigint NOT NULL,
+                file varchar(255) NOT NULL,
+                direction varchar(4) NOT NULL,
+                operator varchar(255) NOT NULL,
+                client_host varchar(255) NOT NULL,
+                sql_sha256 char(64) NULL,
+                rows_affected bigint NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.audit())
+}
+
+func (m TiDBDialect) insertAuditSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, file, direction, operator, client_host, sql_sha256, rows_affected, duration_ms, tstamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?, %s);", t.audit(), m.utcNowSQL())
+}
+
+func (m TiDBDialect) createContextTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_context (
+                id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                commit_sha varchar(64) NULL,
+                context text NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (m TiDBDialect) insertContextSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s_context (version_id, is_applied, commit_sha, context, tstamp) VALUES (?, ?, ?, ?, %s);", t.version(), m.utcNowSQL())
+}
+
+func (m TiDBDialect) createSeedTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT UNIQUE,
+                name varchar(255) NOT NULL,
+                env varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default now(),
+                PRIMARY KEY(id)
+            );`, t.seeds())
+}
+
+func (m TiDBDialect) insertSeedSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (name, env, checksum, tstamp) VALUES (?, ?, ?, %s);", t.seeds(), m.utcNowSQL())
+}
+
+func (m TiDBDialect) currentSchemaSQL() string {
+	return "DATABASE()"
+}
+
+func (m TiDBDialect) utcNowSQL() string {
+	return "utc_timestamp()"
+}
+
+func (m TiDBDialect) indexesQuery() string {
+	return `SELECT table_name, index_name,
+		CONCAT('CREATE ', IF(non_unique = 0, 'UNIQUE ', ''), 'INDEX ', index_name, ' ON ', table_name, ' (', GROUP_CONCAT(column_name ORDER BY seq_in_index), ')')
+		FROM information_schema.statistics WHERE table_schema = DATABASE()
+		GROUP BY table_name, index_name, non_unique`
+}
+
+func (m TiDBDialect) columnsQuery() string {
+	return `SELECT table_name, column_name, column_type, is_nullable, column_default
+		FROM information_schema.columns WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position`
+}
+
+func (m TiDBDialect) foreignKeysQuery() string {
+	return `SELECT table_name, column_name, referenced_table_name, referenced_column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL
+		ORDER BY table_name, column_name`
+}
+
+func (m TiDBDialect) alterColumnSQL(table string, c schemaColumn) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, c.definition())}
+}
+
+func (m TiDBDialect) dropIndexSQL(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s;", index, table)
+}
+
+func (m TiDBDialect) tryLockSQL(t gooseTables) string {
+	return fmt.Sprintf("SELECT GET_LOCK(%s, 0)", t.namedLockSQL(m))
+}
+
+func (m TiDBDialect) unlockSQL(t gooseTables) string {
+	return fmt.Sprintf("SELECT RELEASE_LOCK(%s)", t.namedLockSQL(m))
+}
+
+func (m TiDBDialect) versionIndexQuery(t gooseTables) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = %s AND table_name = '%s' AND index_name = '%s'", t.schemaSQL(m), t.name, t.versionIndex())
+}
+
+func (m TiDBDialect) createVersionIndexSQL(t gooseTables) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (version_id);", t.versionIndex(), t.version())
+}
+
+func (m TiDBDialect) addDownSQLColumnSQL(t gooseTables) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN down_sql longblob NULL;", t.version())
+}
+
+////////////////////////////
+// Snowflake
+////////////////////////////
+
+// SnowflakeDialect struct.
+type SnowflakeDialect struct{}
+
+// snowflakeIdentifier matches the identifiers Snowflake accepts unquoted,
+// which it resolves in uppercase.
+var snowflakeIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// quoteSnowflakeIdentifier quotes name unless it is a valid unquoted
+// identifier, keeping the case of quoted names such as "my-db".
+func quoteSnowflakeIdentifier(name string) string {
+	if snowflakeIdentifier.MatchString(name) {
+		return name
+	}
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// splitSnowflakeDSN splits a gosnowflake dbstring,
+// user[:password]@account[/database[/schema]][?params], into the account
+// part, the database and schema path and the parameters.
+func splitSnowflakeDSN(dbstring string) (account, path, params string, err error) {
+	if i := strings.Index(dbstring, "?"); i >= 0 {
+		dbstring, params = dbstring[:i], dbstring[i:]
+	}
+	at := strings.LastIndex(dbstring, "@")
+	if at < 0 {
+		return "", "", "", fmt.Errorf("unsupported dbstring: %q", dbstring)
+	}
+	account = dbstring
+	if i := strings.Index(dbstring[at:], "/"); i >= 0 {
+		account, path = dbstring[:at+i], dbstring[at+i+1:]
+	}
+	return account, path, params, nil
+}
+
+func (s SnowflakeDialect) createVersionTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE %s (
+                id integer NOT NULL AUTOINCREMENT,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                tstamp timestamp_ntz NULL default sysdate(),
+                down_sql binary NULL,
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (s SnowflakeDialect) insertVersionSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tstamp) VALUES (?, ?, %s);", t.version(), s.utcNowSQL())
+}
+
+func (s SnowflakeDialect) connectToServer(dbstring string) (*sql.DB, error) {
+	account, _, params, err := splitSnowflakeDSN(dbstring)
+	if err != nil {
+		return nil, err
+	}
+	return sql.Open("snowflake", account+params)
+}
+
+func (s SnowflakeDialect) getDBName(dbstring string) (string, error) {
+	_, path, _, err := splitSnowflakeDSN(dbstring)
+	if err != nil {
+		return "", err
+	}
+	dbName := strings.SplitN(path, "/", 2)[0]
+	if dbName == "" {
+		return "", fmt.Errorf("no database in dbstring: %q", dbstring)
+	}
+	return quoteSnowflakeIdentifier(dbName), nil
+}
+
+func (s SnowflakeDialect) createAuditTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id integer NOT NULL AUTOINCREMENT,
+                version_id bigint NOT NULL,
+                file varchar(255) NOT NULL,
+                direction varchar(4) NOT NULL,
+                operator varchar(255) NOT NULL,
+                client_host varchar(255) NOT NULL,
+                sql_sha256 char(64) NULL,
+                rows_affected bigint NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp_ntz NULL default sysdate(),
+                PRIMARY KEY(id)
+            );`, t.audit())
+}
+
+func (s SnowflakeDialect) insertAuditSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, file, direction, operator, client_host, sql_sha256, rows_affected, duration_ms, tstamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?, %s);", t.audit(), s.utcNowSQL())
+}
+
+func (s SnowflakeDialect) createContextTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_context (
+                id integer NOT NULL AUTOINCREMENT,
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                commit_sha varchar(64) NULL,
+                context varchar NULL,
+                tstamp timestamp_ntz NULL default sysdate(),
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (s SnowflakeDialect) insertContextSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s_context (version_id, is_applied, commit_sha, context, tstamp) VALUES (?, ?, ?, ?, %s);", t.version(), s.utcNowSQL())
+}
+
+func (s SnowflakeDialect) createSeedTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+                id integer NOT NULL AUTOINCREMENT,
+                name varchar(255) NOT NULL,
+                env varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp_ntz NULL default sysdate(),
+                PRIMARY KEY(id)
+            );`, t.seeds())
+}
+
+func (s SnowflakeDialect) insertSeedSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (name, env, checksum, tstamp) VALUES (?, ?, ?, %s);", t.seeds(), s.utcNowSQL())
+}
+
+func (s SnowflakeDialect) currentSchemaSQL() string {
+	return "CURRENT_SCHEMA()"
+}
+
+func (s SnowflakeDialect) utcNowSQL() string {
+	return "sysdate()"
+}
+
+// Snowflake has no indexes, and doesn't list the columns of foreign keys in
+// information_schema.
+func (s SnowflakeDialect) indexesQuery() string {
+	return "SELECT NULL, NULL, NULL LIMIT 0"
+}
+
+// Unquoted identifiers are stored in uppercase, they're listed in lowercase
+// to match the goose tables and migrations.
+func (s SnowflakeDialect) columnsQuery() string {
+	return `SELECT LOWER(table_name), LOWER(column_name), data_type, is_nullable, column_default
+		FROM information_schema.columns WHERE table_schema = CURRENT_SCHEMA() ORDER BY table_name, ordinal_position`
+}
+
+func (s SnowflakeDialect) foreignKeysQuery() string {
+	return "SELECT NULL, NULL, NULL, NULL LIMIT 0"
+}
+
+func (s SnowflakeDialect) alterColumnSQL(table string, c schemaColumn) []string {
+	stmts := []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DATA TYPE %s;", table, c.name, c.dataType)}
+	if c.notNull {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, c.name))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, c.name))
+	}
+	if c.def == "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, c.name))
+	}
+	return stmts
+}
+
+func (s SnowflakeDialect) dropIndexSQL(table, index string) string {
+	return ""
+}
+
+// Snowflake has no advisory locks.
+func (s SnowflakeDialect) tryLockSQL(t gooseTables) string {
+	return ""
+}
+
+func (s SnowflakeDialect) unlockSQL(t gooseTables) string {
+	return ""
+}
+
+func (s SnowflakeDialect) versionIndexQuery(t gooseTables) string {
+	return ""
+}
+
+func (s SnowflakeDialect) createVersionIndexSQL(t gooseTables) string {
+	return ""
+}
+
+func (s SnowflakeDialect) addDownSQLColumnSQL(t gooseTables) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN down_sql binary NULL;", t.version())
+}
+
+////////////////////////////
+// DuckDB
+////////////////////////////
+
+// DuckDBDialect struct. DuckDB databases are files, the dbstring being the
+// path of the file, optionally followed by ?options.
+type DuckDBDialect struct{}
+
+func (dd DuckDBDialect) createVersionTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE SEQUENCE IF NOT EXISTS %[1]s_id;
+            CREATE TABLE %[1]s (
+                id integer NOT NULL default nextval('%[1]s_id'),
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                tstamp timestamp NULL default current_timestamp,
+                down_sql blob NULL,
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (dd DuckDBDialect) insertVersionSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tstamp) VALUES ($1, $2, %s);", t.version(), dd.utcNowSQL())
+}
+
+// DuckDB has no server, CreateDB and DropDB create and remove the file.
+func (dd DuckDBDialect) connectToServer(dbstring string) (*sql.DB, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (dd DuckDBDialect) getDBName(dbstring string) (string, error) {
+	path := strings.SplitN(dbstring, "?", 2)[0]
+	if path == "" || path == ":memory:" {
+		return "", fmt.Errorf("unsupported dbstring: %q", dbstring)
+	}
+	return path, nil
+}
+
+func (dd DuckDBDialect) createAuditTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE SEQUENCE IF NOT EXISTS %[1]s_id;
+            CREATE TABLE IF NOT EXISTS %[1]s (
+                id integer NOT NULL default nextval('%[1]s_id'),
+                version_id bigint NOT NULL,
+                file varchar(255) NOT NULL,
+                direction varchar(4) NOT NULL,
+                operator varchar(255) NOT NULL,
+                client_host varchar(255) NOT NULL,
+                sql_sha256 char(64) NULL,
+                rows_affected bigint NULL,
+                duration_ms bigint NOT NULL,
+                tstamp timestamp NULL default current_timestamp,
+                PRIMARY KEY(id)
+            );`, t.audit())
+}
+
+func (dd DuckDBDialect) insertAuditSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, file, direction, operator, client_host, sql_sha256, rows_affected, duration_ms, tstamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, %s);", t.audit(), dd.utcNowSQL())
+}
+
+func (dd DuckDBDialect) createContextTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE SEQUENCE IF NOT EXISTS %[1]s_context_id;
+            CREATE TABLE IF NOT EXISTS %[1]s_context (
+                id integer NOT NULL default nextval('%[1]s_context_id'),
+                version_id bigint NOT NULL,
+                is_applied boolean NOT NULL,
+                commit_sha varchar(64) NULL,
+                context text NULL,
+                tstamp timestamp NULL default current_timestamp,
+                PRIMARY KEY(id)
+            );`, t.version())
+}
+
+func (dd DuckDBDialect) insertContextSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s_context (version_id, is_applied, commit_sha, context, tstamp) VALUES ($1, $2, $3, $4, %s);", t.version(), dd.utcNowSQL())
+}
+
+func (dd DuckDBDialect) createSeedTableSQL(t gooseTables) string {
+	return fmt.Sprintf(`CREATE SEQUENCE IF NOT EXISTS %[1]s_id;
+            CREATE TABLE IF NOT EXISTS %[1]s (
+                id integer NOT NULL default nextval('%[1]s_id'),
+                name varchar(255) NOT NULL,
+                env varchar(255) NOT NULL,
+                checksum varchar(64) NOT NULL,
+                tstamp timestamp NULL default current_timestamp,
+                PRIMARY KEY(id)
+            );`, t.seeds())
+}
+
+func (dd DuckDBDialect) insertSeedSQL(t gooseTables) string {
+	return fmt.Sprintf("INSERT INTO %s (name, env, checksum, tstamp) VALUES ($1, $2, $3, %s);", t.seeds(), dd.utcNowSQL())
+}
+
+func (dd DuckDBDialect) currentSchemaSQL() string {
+	return "current_schema()"
+}
+
+func (dd DuckDBDialect) utcNowSQL() string {
+	return "make_timestamp(epoch_us(current_timestamp))"
+}
+
+func (dd DuckDBDialect) indexesQuery() string {
+	return "SELECT table_name, index_name, sql FROM duckdb_indexes() WHERE schema_name = current_schema()"
+}
+
+func (dd DuckDBDialect) columnsQuery() string {
+	return `SELECT table_name, column_name, data_type, is_nullable, column_default
+		FROM information_schema.columns WHERE table_schema = current_schema() ORDER BY table_name, ordinal_position`
+}
+
+func (dd DuckDBDialect) foreignKeysQuery() string {
+	return `SELECT table_name, constraint_column_names[1], referenced_table, referenced_column_names[1]
+		FROM duckdb_constraints()
+		WHERE constraint_type = 'FOREIGN KEY' AND schema_name = current_schema()
+		ORDER BY table_name, constraint_column_names[1]`
+}
+
+func (dd DuckDBDialect) alterColumnSQL(table string, c schemaColumn) []string {
+	stmts := []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, c.name, c.dataType)}
+	if c.notNull {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, c.name))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, c.name))
+	}
+	if c.def != "" {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, c.name, c.def))
+	} else {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, c.name))
+	}
+	return stmts
+}
+
+func (dd DuckDBDialect) dropIndexSQL(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s;", index)
+}
+
+// A DuckDB file is locked by the process that opened it for writing.
+func (dd DuckDBDialect) tryLockSQL(t gooseTables) string {
+	return ""
+}
+
+func (dd DuckDBDialect) unlockSQL(t gooseTables) string {
+	return ""
+}
+
+func (dd DuckDBDialect) versionIndexQuery(t gooseTables) string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM duckdb_indexes() WHERE schema_name = %s AND table_name = '%s' AND index_name = '%s'", t.schemaSQL(dd), t.name, t.versionIndex())
+}
+
+func (dd DuckDBDialect) createVersionIndexSQL(t gooseTables) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (version_id);", t.versionIndex(), t.version())
+}
+
+func (dd DuckDBDialect) addDownSQLColumnSQL(t gooseTables) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN down_sql blob;", t.version())
+}
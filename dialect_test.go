@@ -0,0 +1,163 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSnowflakeDBName(t *testing.T) {
+	tests := []struct {
+		dbstring string
+		dbName   string
+		account  string
+	}{
+		{"goose:secret@myorg-account/analytics/public?warehouse=wh", "analytics", "goose:secret@myorg-account?warehouse=wh"},
+		{"goose:p@ss/word@myorg-account/analytics", "analytics", "goose:p@ss/word@myorg-account"},
+		{"goose@myorg-account/my-db", `"my-db"`, "goose@myorg-account"},
+	}
+	d := &SnowflakeDialect{}
+	for _, test := range tests {
+		dbName, err := d.getDBName(test.dbstring)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dbName != test.dbName {
+			t.Errorf("%s: got database %s, want %s", test.dbstring, dbName, test.dbName)
+		}
+		account, _, params, err := splitSnowflakeDSN(test.dbstring)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account+params != test.account {
+			t.Errorf("%s: got server dbstring %s, want %s", test.dbstring, account+params, test.account)
+		}
+	}
+
+	if _, err := d.getDBName("goose@myorg-account?warehouse=wh"); err == nil {
+		t.Error("expected an error for a dbstring without database")
+	}
+}
+
+func TestDuckDBFiles(t *testing.T) {
+	d := &DuckDBDialect{}
+	path, err := d.getDBName("analytics.duckdb?access_mode=read_write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "analytics.duckdb" {
+		t.Errorf("got database %s, want analytics.duckdb", path)
+	}
+	if _, err := d.getDBName(":memory:"); err == nil {
+		t.Error("expected an error for an in-memory database")
+	}
+
+	path = t.TempDir() + "/analytics.duckdb"
+	for _, name := range []string{path, path + ".wal"} {
+		if err := ioutil.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := createDuckDB(path); err == nil {
+		t.Error("expected an error creating an existing database")
+	}
+	if err := dropDuckDB(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".wal"); !os.IsNotExist(err) {
+		t.Errorf("%s.wal wasn't removed", path)
+	}
+	if err := dropDuckDB(path); err != nil {
+		t.Errorf("dropping a missing database: %v", err)
+	}
+}
+
+func TestRegisterDialect(t *testing.T) {
+	type mariaDBDialect struct{ MySQLDialect }
+	RegisterDialect("mariadb", &mariaDBDialect{})
+	defer delete(registeredDialects, "mariadb")
+	defer SetDialect("postgres")
+
+	if err := SetDialect("mariadb"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GetDialect().(*mariaDBDialect); !ok {
+		t.Fatalf("got dialect %T, want the registered one", GetDialect())
+	}
+	if p := placeholder(1); p != "?" {
+		t.Errorf("got placeholder %s, want ?", p)
+	}
+
+	RegisterDialect("postgres", &RedshiftDialect{})
+	defer delete(registeredDialects, "postgres")
+	if err := SetDialect("postgres"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GetDialect().(*RedshiftDialect); !ok {
+		t.Errorf("got dialect %T, registered dialects should take precedence", GetDialect())
+	}
+}
+
+func TestSetTableName(t *testing.T) {
+	SetTableName("billing_db_version")
+	defer SetTableName("goose_db_version")
+
+	d := &PostgresDialect{}
+	for _, q := range []string{d.createVersionTableSQL(packageTables()), d.insertVersionSQL(packageTables()), d.createContextTableSQL(packageTables()), d.createVersionIndexSQL(packageTables()), insertVersionLiteral(packageConfig(), 1, true)} {
+		if !strings.Contains(q, "billing_db_version") || strings.Contains(q, "goose_db_version") {
+			t.Errorf("query doesn't use the table name: %s", q)
+		}
+	}
+	for _, table := range []string{"billing_db_version", "billing_db_version_meta", "billing_db_version_context"} {
+		if !isGooseTable(table) {
+			t.Errorf("%s should be a goose table", table)
+		}
+	}
+	if isGooseTable("goose_db_version") {
+		t.Error("goose_db_version is a table of another service")
+	}
+}
+
+func TestSetTableSchema(t *testing.T) {
+	SetTableSchema("ops")
+	defer SetTableSchema("")
+
+	d := &PostgresDialect{}
+	tests := []struct {
+		query, want string
+	}{
+		{d.createVersionTableSQL(packageTables()), "CREATE TABLE ops.goose_db_version ("},
+		{d.createContextTableSQL(packageTables()), "CREATE TABLE IF NOT EXISTS ops.goose_db_version_context ("},
+		{d.createVersionIndexSQL(packageTables()), "CREATE INDEX goose_db_version_version_id ON ops.goose_db_version (version_id);"},
+		{d.versionIndexQuery(packageTables()), "WHERE schemaname = 'ops' AND tablename = 'goose_db_version'"},
+		{(&RedshiftDialect{}).insertVersionSQL(packageTables()), "INSERT INTO ops.goose_db_version "},
+		{packageTables().meta(), "ops.goose_db_version_meta"},
+		{d.createAuditTableSQL(packageTables()), "CREATE TABLE IF NOT EXISTS ops.goose_audit ("},
+		{(&MySQLDialect{}).insertAuditSQL(packageTables()), "INSERT INTO ops.goose_audit "},
+		{d.insertSeedSQL(packageTables()), "INSERT INTO ops.goose_seeds "},
+		{(&DuckDBDialect{}).createSeedTableSQL(packageTables()), "nextval('ops.goose_seeds_id')"},
+	}
+	for _, test := range tests {
+		if !strings.Contains(test.query, test.want) {
+			t.Errorf("%s: doesn't contain %s", test.query, test.want)
+		}
+	}
+	if !isGooseTable("goose_db_version") {
+		t.Error("goose_db_version should be a goose table")
+	}
+}
+
+func TestNamedLock(t *testing.T) {
+	m := &MySQLDialect{}
+	want := "SELECT GET_LOCK(CONCAT('goose_', SHA1(CONCAT(IFNULL(DATABASE(), ''), '.', 'goose_db_version'))), 0)"
+	if got := m.tryLockSQL(packageTables()); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	SetTableSchema("ops")
+	defer SetTableSchema("")
+	if got := (&TiDBDialect{}).unlockSQL(packageTables()); !strings.Contains(got, "RELEASE_LOCK(CONCAT('goose_', SHA1(CONCAT(IFNULL('ops', ''), ") {
+		t.Errorf("lock name of %s doesn't use the schema of the version table", got)
+	}
+}
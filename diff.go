@@ -0,0 +1,305 @@
+package goose
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// reviewPrefix marks statements left commented out in generated migrations,
+// as they would lose data or can't be derived from the introspected schema.
+const reviewPrefix = "-- REVIEW: "
+
+// DiffSchema compares the schema of current with desired, e.g. a reference
+// database, returning the statements migrating current to desired and back.
+// The result is a candidate: destructive changes and constraints are left as
+// REVIEW comments.
+func DiffSchema(current, desired *sql.DB) (up, down []string, err error) {
+	from, err := readSchema(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := readSchema(desired)
+	if err != nil {
+		return nil, nil, err
+	}
+	up, down = schemaDiff(from, to)
+	return up, down, nil
+}
+
+// schemaDiff returns the statements migrating from to to, and back. Down
+// statements are in execution order.
+func schemaDiff(from, to dbSchema) (up, down []string) {
+	d := GetDialect()
+	var reverse []string
+
+	for _, name := range to.names() {
+		t := to[name]
+		current, ok := from[name]
+		if !ok {
+			up = append(up, createTableSQL(t)...)
+			reverse = append(reverse, fmt.Sprintf("DROP TABLE %s;", name))
+			continue
+		}
+
+		for _, c := range t.columns {
+			old, ok := current.column(c.name)
+			switch {
+			case !ok:
+				up = append(up, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", name, c.definition()))
+				reverse = append(reverse, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", name, c.name))
+			case old != c:
+				up = append(up, d.alterColumnSQL(name, c)...)
+				reverse = append(reverse, d.alterColumnSQL(name, old)...)
+			}
+		}
+		for _, c := range current.columns {
+			if _, ok := t.column(c.name); !ok {
+				up = append(up, reviewPrefix+fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", name, c.name))
+			}
+		}
+
+		for _, index := range sortedKeys(t.indexes) {
+			def := t.indexes[index]
+			if _, ok := t.constraints[index]; ok {
+				continue
+			}
+			switch old, ok := current.indexes[index]; {
+			case !ok:
+				up = append(up, def+";")
+				reverse = append(reverse, d.dropIndexSQL(name, index))
+			case old != def:
+				up = append(up, d.dropIndexSQL(name, index), def+";")
+				reverse = append(reverse, d.dropIndexSQL(name, index), old+";")
+			}
+		}
+		for _, index := range sortedKeys(current.indexes) {
+			if _, ok := current.constraints[index]; ok {
+				continue
+			}
+			if _, ok := t.indexes[index]; !ok {
+				up = append(up, d.dropIndexSQL(name, index))
+				reverse = append(reverse, current.indexes[index]+";")
+			}
+		}
+
+		for _, constraint := range sortedKeys(t.constraints) {
+			if current.constraints[constraint] != t.constraints[constraint] {
+				up = append(up, reviewPrefix+fmt.Sprintf("add %s constraint %s to %s", t.constraints[constraint], constraint, name))
+			}
+		}
+		for _, constraint := range sortedKeys(current.constraints) {
+			if _, ok := t.constraints[constraint]; !ok {
+				up = append(up, reviewPrefix+fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", name, constraint))
+			}
+		}
+	}
+
+	for _, name := range from.names() {
+		if _, ok := to[name]; !ok {
+			up = append(up, reviewPrefix+fmt.Sprintf("DROP TABLE %s;", name))
+		}
+	}
+
+	for i := len(reverse) - 1; i >= 0; i-- {
+		down = append(down, reverse[i])
+	}
+	return up, down
+}
+
+// createTableSQL returns the statements creating a table with its indexes.
+func createTableSQL(t *schemaTable) []string {
+	defs := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		defs[i] = "    " + c.definition()
+	}
+	stmts := []string{fmt.Sprintf("CREATE TABLE %s (\n%s\n);", t.name, strings.Join(defs, ",\n"))}
+
+	for _, constraint := range sortedKeys(t.constraints) {
+		stmts = append(stmts, reviewPrefix+fmt.Sprintf("add %s constraint %s to %s", t.constraints[constraint], constraint, t.name))
+	}
+	for _, index := range sortedKeys(t.indexes) {
+		if _, ok := t.constraints[index]; !ok {
+			stmts = append(stmts, t.indexes[index]+";")
+		}
+	}
+	return stmts
+}
+
+// CreateDiffMigration writes a new migration in dir migrating current to
+// the schema of desired. It returns the path of the migration, or "" when
+// the schemas match.
+func CreateDiffMigration(dir, name string, current, desired *sql.DB) (string, error) {
+	up, down, err := DiffSchema(current, desired)
+	if err != nil {
+		return "", err
+	}
+	if len(up) == 0 {
+		log.Println("goose: schemas match, no migration created")
+		return "", nil
+	}
+
+	return writeGeneratedMigration(dir, name, "goose diff", up, down)
+}
+
+// writeGeneratedMigration writes the up and down statements generated by
+// command as a new migration in dir, returning its path.
+func writeGeneratedMigration(dir, name, command string, up, down []string) (string, error) {
+	version, err := nextVersion(dir)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.sql", version, name))
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("failed to create file: %v already exists", path)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(sqlCmdPrefix + "Up\n")
+	b.WriteString("-- Generated by " + command + ": review before applying, in particular the REVIEW comments.\n")
+	for _, stmt := range up {
+		b.WriteString(stmt + "\n")
+	}
+	b.WriteString("\n" + sqlCmdPrefix + "Down\n")
+	for _, stmt := range down {
+		b.WriteString(stmt + "\n")
+	}
+	if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return "", err
+	}
+
+	log.Printf("Created new file: %s\n", path)
+	return path, nil
+}
+
+// CreateTableMigration writes a new migration in dir creating the given
+// existing tables of db, with their indexes and constraints, and dropping
+// them in its down section. It brings tables built by hand under goose
+// management; the database it was generated from already has them, so its
+// version needs to be recorded there rather than run.
+func CreateTableMigration(dir string, db *sql.DB, tables ...string) (string, error) {
+	if len(tables) == 0 {
+		return "", errors.New("create-from-table must be of form: goose [OPTIONS] DRIVER DBSTRING create-from-table TABLE...")
+	}
+	schema, err := readSchema(db)
+	if err != nil {
+		return "", err
+	}
+	up, down, err := tableMigration(schema, tables)
+	if err != nil {
+		return "", err
+	}
+	return writeGeneratedMigration(dir, "create_"+strings.Join(tables, "_"), "goose create-from-table", up, down)
+}
+
+// tableMigration returns the statements creating tables as in schema, and
+// dropping them in reverse order. Foreign keys are added once every table
+// is created, so that tables may reference each other.
+func tableMigration(schema dbSchema, tables []string) (up, down []string, err error) {
+	var foreignKeys []string
+	for _, name := range tables {
+		t, ok := schema[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("table %s not found", name)
+		}
+
+		defs := make([]string, 0, len(t.columns))
+		for _, c := range t.columns {
+			defs = append(defs, "    "+c.definition())
+		}
+		var review []string
+		for _, constraint := range sortedKeys(t.constraints) {
+			columns := indexColumns(t.indexes[constraint])
+			switch typ := t.constraints[constraint]; {
+			case typ == "FOREIGN KEY":
+			case typ == "PRIMARY KEY" && columns != "":
+				defs = append(defs, fmt.Sprintf("    PRIMARY KEY (%s)", columns))
+			case typ == "UNIQUE" && columns != "":
+				defs = append(defs, fmt.Sprintf("    CONSTRAINT %s UNIQUE (%s)", constraint, columns))
+			default:
+				review = append(review, reviewPrefix+fmt.Sprintf("add %s constraint %s to %s", typ, constraint, name))
+			}
+		}
+		up = append(up, fmt.Sprintf("CREATE TABLE %s (\n%s\n);", name, strings.Join(defs, ",\n")))
+		up = append(up, review...)
+
+		for _, index := range sortedKeys(t.indexes) {
+			if _, ok := t.constraints[index]; !ok {
+				up = append(up, t.indexes[index]+";")
+			}
+		}
+		for _, fk := range t.foreignKeys {
+			foreignKeys = append(foreignKeys, fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s (%s);", name, fk.column, fk.refTable, fk.refColumn))
+		}
+	}
+	up = append(up, foreignKeys...)
+
+	for i := len(tables) - 1; i >= 0; i-- {
+		down = append(down, fmt.Sprintf("DROP TABLE %s;", tables[i]))
+	}
+	return up, down, nil
+}
+
+// indexColumns returns the column list of a CREATE INDEX statement, or ""
+// if def is empty.
+func indexColumns(def string) string {
+	i, j := strings.Index(def, "("), strings.LastIndex(def, ")")
+	if i < 0 || j < i {
+		return ""
+	}
+	return def[i+1 : j]
+}
+
+// LoadSchemaFile executes the statements of a SQL file, e.g. the desired
+// schema, in db. Statements are split as in migrations, without annotations.
+func LoadSchemaFile(db *sql.DB, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return loadSchema(db, path, f)
+}
+
+func loadSchema(db *sql.DB, name string, r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	statements, _, err := getSQLStatements(bytes.NewReader(append([]byte(sqlCmdPrefix+"Up\n"), b...)), true)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// CreateMigrationFromDDL writes a new migration in dir migrating current to
+// the schema described by ddl, the CREATE statements an ORM generates from
+// its models, e.g. with GORM's DryRun mode or ent's schema.WriteTo. The DDL
+// is loaded into shadow, which must be an empty database of the same driver,
+// then diffed with current as in CreateDiffMigration, so that goose stays
+// the only tool applying migrations in production.
+func CreateMigrationFromDDL(dir, name string, current, shadow *sql.DB, ddl io.Reader) (string, error) {
+	schema, err := readSchema(shadow)
+	if err != nil {
+		return "", err
+	}
+	if len(schema) > 0 {
+		return "", fmt.Errorf("shadow database is not empty, found tables %s", strings.Join(schema.names(), ", "))
+	}
+	if err := loadSchema(shadow, "ddl", ddl); err != nil {
+		return "", err
+	}
+	return CreateDiffMigration(dir, name, current, shadow)
+}
@@ -0,0 +1,81 @@
+package goose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	from := dbSchema{}
+	users := from.table("users")
+	users.columns = []schemaColumn{{name: "id", dataType: "integer", notNull: true}, {name: "nickname", dataType: "text"}}
+	users.constraints["users_pkey"] = "PRIMARY KEY"
+	users.indexes["users_pkey"] = "CREATE UNIQUE INDEX users_pkey ON users USING btree (id)"
+
+	to := dbSchema{}
+	users = to.table("users")
+	users.columns = []schemaColumn{{name: "id", dataType: "integer", notNull: true}, {name: "email", dataType: "text", notNull: true, def: "''::text"}}
+	users.constraints["users_pkey"] = "PRIMARY KEY"
+	users.indexes["users_pkey"] = "CREATE UNIQUE INDEX users_pkey ON users USING btree (id)"
+	users.indexes["users_email"] = "CREATE INDEX users_email ON users USING btree (email)"
+	posts := to.table("posts")
+	posts.columns = []schemaColumn{{name: "id", dataType: "integer", notNull: true}}
+
+	up, down := schemaDiff(from, to)
+	wantUp := []string{
+		"CREATE TABLE posts (\n    id integer NOT NULL\n);",
+		"ALTER TABLE users ADD COLUMN email text NOT NULL DEFAULT ''::text;",
+		"-- REVIEW: ALTER TABLE users DROP COLUMN nickname;",
+		"CREATE INDEX users_email ON users USING btree (email);",
+	}
+	wantDown := []string{
+		"DROP INDEX users_email;",
+		"ALTER TABLE users DROP COLUMN email;",
+		"DROP TABLE posts;",
+	}
+	if !reflect.DeepEqual(up, wantUp) {
+		t.Errorf("incorrect up statements.\ngot  %q\nwant %q", up, wantUp)
+	}
+	if !reflect.DeepEqual(down, wantDown) {
+		t.Errorf("incorrect down statements.\ngot  %q\nwant %q", down, wantDown)
+	}
+}
+
+func TestTableMigration(t *testing.T) {
+	schema := dbSchema{}
+	users := schema.table("users")
+	users.columns = []schemaColumn{{name: "id", dataType: "integer", notNull: true}, {name: "email", dataType: "text", notNull: true}}
+	users.constraints["users_pkey"] = "PRIMARY KEY"
+	users.constraints["users_email_key"] = "UNIQUE"
+	users.constraints["users_email_check"] = "CHECK"
+	users.indexes["users_pkey"] = "CREATE UNIQUE INDEX users_pkey ON users USING btree (id)"
+	users.indexes["users_email_key"] = "CREATE UNIQUE INDEX users_email_key ON users USING btree (email)"
+	posts := schema.table("posts")
+	posts.columns = []schemaColumn{{name: "id", dataType: "integer", notNull: true}, {name: "user_id", dataType: "integer"}}
+	posts.constraints["posts_user_id_fkey"] = "FOREIGN KEY"
+	posts.indexes["posts_user_id"] = "CREATE INDEX posts_user_id ON posts USING btree (user_id)"
+	posts.foreignKeys = []schemaForeignKey{{column: "user_id", refTable: "users", refColumn: "id"}}
+
+	up, down, err := tableMigration(schema, []string{"posts", "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantUp := []string{
+		"CREATE TABLE posts (\n    id integer NOT NULL,\n    user_id integer\n);",
+		"CREATE INDEX posts_user_id ON posts USING btree (user_id);",
+		"CREATE TABLE users (\n    id integer NOT NULL,\n    email text NOT NULL,\n    CONSTRAINT users_email_key UNIQUE (email),\n    PRIMARY KEY (id)\n);",
+		"-- REVIEW: add CHECK constraint users_email_check to users",
+		"ALTER TABLE posts ADD FOREIGN KEY (user_id) REFERENCES users (id);",
+	}
+	wantDown := []string{"DROP TABLE users;", "DROP TABLE posts;"}
+	if !reflect.DeepEqual(up, wantUp) {
+		t.Errorf("incorrect up statements.\ngot  %q\nwant %q", up, wantUp)
+	}
+	if !reflect.DeepEqual(down, wantDown) {
+		t.Errorf("incorrect down statements.\ngot  %q\nwant %q", down, wantDown)
+	}
+
+	if _, _, err := tableMigration(schema, []string{"comments"}); err == nil {
+		t.Error("expected an error for a missing table")
+	}
+}
@@ -0,0 +1,152 @@
+package goose
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SchemaDoc writes the documentation of the current schema of db to w, in
+// format markdown, tables with their columns, indexes and references
+// followed by a Mermaid ER diagram, or mermaid, the diagram alone.
+func SchemaDoc(w io.Writer, db *sql.DB, format string) error {
+	if format != "markdown" && format != "mermaid" {
+		return fmt.Errorf("%q: unknown format, must be markdown or mermaid", format)
+	}
+	schema, err := readSchema(db)
+	if err != nil {
+		return err
+	}
+	if format == "mermaid" {
+		_, err = io.WriteString(w, mermaidDiagram(schema))
+		return err
+	}
+	_, err = io.WriteString(w, markdownDoc(schema))
+	return err
+}
+
+// WriteSchemaDoc writes the markdown documentation of the schema of db to
+// path.
+func WriteSchemaDoc(db *sql.DB, path string) error {
+	return writeSchemaDoc(db, path, "markdown")
+}
+
+func writeSchemaDoc(db *sql.DB, path, format string) error {
+	var b bytes.Buffer
+	if err := SchemaDoc(&b, db, format); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return err
+	}
+	log.Printf("goose: wrote schema documentation %s\n", path)
+	return nil
+}
+
+func markdownDoc(schema dbSchema) string {
+	var b strings.Builder
+	b.WriteString("# Database schema\n")
+	for _, name := range schema.names() {
+		t := schema[name]
+		fmt.Fprintf(&b, "\n## %s\n\n", name)
+		b.WriteString("| Column | Type | Nullable | Default |\n")
+		b.WriteString("|--------|------|----------|---------|\n")
+		for _, c := range t.columns {
+			nullable := "yes"
+			if c.notNull {
+				nullable = "no"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", c.name, markdownCell(c.dataType), nullable, markdownCell(c.def))
+		}
+
+		if len(t.indexes) > 0 {
+			b.WriteString("\nIndexes:\n\n")
+			for _, index := range sortedKeys(t.indexes) {
+				fmt.Fprintf(&b, "- `%s`\n", t.indexes[index])
+			}
+		}
+		if len(t.foreignKeys) > 0 {
+			b.WriteString("\nReferences:\n\n")
+			for _, fk := range t.foreignKeys {
+				fmt.Fprintf(&b, "- %s → [%s](#%s).%s\n", fk.column, fk.refTable, fk.refTable, fk.refColumn)
+			}
+		}
+	}
+
+	b.WriteString("\n## Diagram\n\n```mermaid\n")
+	b.WriteString(mermaidDiagram(schema))
+	b.WriteString("```\n")
+	return b.String()
+}
+
+// mermaidDiagram returns the schema as a Mermaid erDiagram, each foreign
+// key being a many-to-one relation.
+func mermaidDiagram(schema dbSchema) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, name := range schema.names() {
+		t := schema[name]
+		keys := map[string]bool{}
+		for _, fk := range t.foreignKeys {
+			keys[fk.column] = true
+		}
+
+		fmt.Fprintf(&b, "    %s {\n", name)
+		for _, c := range t.columns {
+			line := fmt.Sprintf("        %s %s", mermaidType(c.dataType), c.name)
+			if keys[c.name] {
+				line += " FK"
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("    }\n")
+	}
+
+	for _, name := range schema.names() {
+		t := schema[name]
+		for _, fk := range t.foreignKeys {
+			// A nullable reference may point to no row.
+			parent := "||"
+			if c, ok := t.column(fk.column); ok && !c.notNull {
+				parent = "o|"
+			}
+			fmt.Fprintf(&b, "    %s }o--%s %s : %q\n", name, parent, fk.refTable, fk.column)
+		}
+	}
+	return b.String()
+}
+
+var nonWordRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// mermaidType makes a column type a single word, as Mermaid requires, e.g.
+// character varying(255) becomes character_varying_255.
+func mermaidType(dataType string) string {
+	return strings.Trim(nonWordRegexp.ReplaceAllString(dataType, "_"), "_")
+}
+
+func markdownCell(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.Replace(s, "|", `\|`, -1) + "`"
+}
+
+// doc implements the doc command: doc [-format=markdown|mermaid] [FILE],
+// writing to stdout without FILE.
+func doc(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("doc", flag.ContinueOnError)
+	format := fs.String("format", "markdown", "markdown or mermaid")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return SchemaDoc(os.Stdout, db, *format)
+	}
+	return writeSchemaDoc(db, fs.Arg(0), *format)
+}
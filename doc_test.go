@@ -0,0 +1,38 @@
+package goose
+
+import "testing"
+
+func TestMermaidDiagram(t *testing.T) {
+	schema := dbSchema{}
+	schema.table("users").columns = []schemaColumn{
+		{name: "id", dataType: "integer", notNull: true},
+		{name: "email", dataType: "character varying(255)"},
+	}
+	posts := schema.table("posts")
+	posts.columns = []schemaColumn{
+		{name: "id", dataType: "integer", notNull: true},
+		{name: "author_id", dataType: "integer", notNull: true},
+		{name: "editor_id", dataType: "integer"},
+	}
+	posts.foreignKeys = []schemaForeignKey{
+		{column: "author_id", refTable: "users", refColumn: "id"},
+		{column: "editor_id", refTable: "users", refColumn: "id"},
+	}
+
+	expected := `erDiagram
+    posts {
+        integer id
+        integer author_id FK
+        integer editor_id FK
+    }
+    users {
+        integer id
+        character_varying_255 email
+    }
+    posts }o--|| users : "author_id"
+    posts }o--o| users : "editor_id"
+`
+	if got := mermaidDiagram(schema); got != expected {
+		t.Errorf("incorrect diagram. got:\n%s\nwant:\n%s", got, expected)
+	}
+}
@@ -1,56 +1,110 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 )
 
 // Down rolls back a single migration from the current version.
 func Down(db *sql.DB, dir string) error {
-	currentVersion, err := GetDBVersion(db)
+	return down(context.Background(), db, dir)
+}
+
+// DownContext rolls back a single migration from the current version, with
+// ctx.
+func DownContext(ctx context.Context, db *sql.DB, dir string) error {
+	return down(ctx, db, dir)
+}
+
+func down(ctx context.Context, db *sql.DB, dir string) error {
+	c := configFrom(ctx)
+	currentVersion, err := GetDBVersionContext(ctx, db)
 	if err != nil {
 		return err
 	}
 
-	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
 
 	current, err := migrations.Current(currentVersion)
 	if err != nil {
-		return fmt.Errorf("no migration %v", currentVersion)
+		stored, cleanup, err := storedMigration(ctx, db, currentVersion)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return fmt.Errorf("no migration %v", currentVersion)
+		}
+		defer cleanup()
+		current = stored
 	}
 
-	return current.Down(db)
+	return current.down(ctx, db)
 }
 
 // DownTo rolls back migrations to a specific version.
 func DownTo(db *sql.DB, dir string, version int64) error {
-	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	return downTo(context.Background(), db, dir, version)
+}
+
+// DownToContext rolls back migrations to a specific version. Canceling ctx
+// stops the migration being rolled back and the ones after it.
+func DownToContext(ctx context.Context, db *sql.DB, dir string, version int64) error {
+	return downTo(ctx, db, dir, version)
+}
+
+func downTo(ctx context.Context, db *sql.DB, dir string, version int64) error {
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
+	currentVersion, err := GetDBVersionContext(ctx, db)
+	if err != nil {
+		return err
+	}
+	var rollback Migrations
+	for _, m := range migrations {
+		if m.Version > version && m.Version <= currentVersion {
+			rollback = append(rollback, m)
+		}
+	}
+	if err := verifyChecksums(c, rollback); err != nil {
+		return err
+	}
 
 	for {
-		currentVersion, err := GetDBVersion(db)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		currentVersion, err := GetDBVersionContext(ctx, db)
 		if err != nil {
 			return err
 		}
 
-		current, err := migrations.Current(currentVersion)
-		if err != nil {
-			log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
+		if currentVersion <= version {
+			c.log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
 			return nil
 		}
 
-		if current.Version <= version {
-			log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
-			return nil
+		current, err := migrations.Current(currentVersion)
+		cleanup := func() {}
+		if err != nil {
+			if current, cleanup, err = storedMigration(ctx, db, currentVersion); err != nil {
+				return err
+			}
+			if current == nil {
+				c.log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
+				return nil
+			}
 		}
 
-		if err = current.Down(db); err != nil {
+		err = current.down(ctx, db)
+		cleanup()
+		if err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,162 @@
+package goose
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// storeDownSQL sets whether the Down SQL of migrations is stored in
+// goose_db_version when they are applied.
+var storeDownSQL = false
+
+// SetStoreDownSQL sets whether the Down section of SQL migrations is stored,
+// compressed, in goose_db_version when they are applied, so that down and
+// down-to can roll them back on hosts without the migration files.
+func SetStoreDownSQL(store bool) {
+	storeDownSQL = store
+}
+
+// storedDownScript renders the Down statements of a migration as a script
+// parsed back into the same statements: each one is kept whole between
+// StatementBegin and StatementEnd, and the file name is recorded like in
+// migration streams.
+func storedDownScript(c *config, scriptFile string) ([]byte, error) {
+	statements, _, useTx, err := readSQLStatements(c, scriptFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(streamFileMarker + filepath.Base(scriptFile) + "\n")
+	if !useTx {
+		b.WriteString(sqlCmdPrefix + "NO TRANSACTION\n")
+	}
+	b.WriteString(sqlCmdPrefix + "Down\n")
+	for _, query := range statements {
+		if _, ok := parseLoad(query); ok {
+			return nil, fmt.Errorf("fixture loads need the migration files")
+		}
+		b.WriteString(sqlCmdPrefix + "StatementBegin\n")
+		b.WriteString(query)
+		b.WriteString(sqlCmdPrefix + "StatementEnd\n")
+	}
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write(b.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return gz.Bytes(), nil
+}
+
+// downSQL returns the Down SQL to store once scriptFile is applied, or nil
+// when storing is disabled. A migration that can't be stored only logs a
+// warning, as storing is a convenience. It is read before the migration
+// runs, as checking for the down_sql column would abort its transaction on
+// PostgreSQL.
+func downSQL(ctx context.Context, db *sql.DB, scriptFile string) []byte {
+	c := configFrom(ctx)
+	if !storeDownSQL {
+		return nil
+	}
+	if !hasDownSQLColumn(ctx, db) {
+		c.log.Printf("WARNING: %s: Down SQL not stored: %s has no down_sql column\n", filepath.Base(scriptFile), c.tables.version())
+		return nil
+	}
+	script, err := storedDownScript(c, scriptFile)
+	if err != nil {
+		c.log.Printf("WARNING: %s: Down SQL not stored: %v\n", filepath.Base(scriptFile), err)
+		return nil
+	}
+	return script
+}
+
+// writeDownSQL stores script, the Down SQL returned by downSQL, in the row
+// just recorded for version v, if any. The latest id is read through a
+// derived table, as MySQL can't select from the table it updates.
+func writeDownSQL(ctx context.Context, db execer, script []byte, v int64) error {
+	if script == nil {
+		return nil
+	}
+	c := configFrom(ctx)
+	q := fmt.Sprintf("UPDATE %[1]s SET down_sql = %[2]s WHERE id = (SELECT id FROM (SELECT MAX(id) AS id FROM %[1]s WHERE version_id = %[3]s) latest)", c.tables.version(), c.placeholder(1), c.placeholder(2))
+	_, err := db.ExecContext(ctx, q, script, v)
+	return err
+}
+
+// storedDir is the folder of the sources of migrations rolling back with
+// stored Down SQL, which are kept in memory rather than read from a file.
+const storedDir = "(stored)"
+
+// storedScripts holds the Down SQL read back by storedMigration, keyed by
+// source.
+var (
+	storedScriptsMu sync.Mutex
+	storedScripts   = map[string][]byte{}
+)
+
+// storedScript returns the Down SQL of a migration returned by
+// storedMigration.
+func storedScript(source string) ([]byte, bool) {
+	if !strings.HasPrefix(source, storedDir+"/") {
+		return nil, false
+	}
+	storedScriptsMu.Lock()
+	defer storedScriptsMu.Unlock()
+	b, ok := storedScripts[source]
+	return b, ok
+}
+
+// storedMigration returns a migration rolling back version v with the Down
+// SQL stored in goose_db_version, kept in memory until cleanup is called,
+// or nil if none was stored.
+func storedMigration(ctx context.Context, db *sql.DB, v int64) (m *Migration, cleanup func(), err error) {
+	c := configFrom(ctx)
+	var script []byte
+	q := fmt.Sprintf("SELECT down_sql FROM %s WHERE version_id = %s AND down_sql IS NOT NULL ORDER BY id DESC LIMIT 1", c.tables.version(), c.placeholder(1))
+	if err := db.QueryRowContext(ctx, q, v).Scan(&script); err != nil {
+		// No stored SQL, or a table without the column.
+		return nil, nil, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(script))
+	if err != nil {
+		return nil, nil, fmt.Errorf("stored Down SQL of version %d: %v", v, err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stored Down SQL of version %d: %v", v, err)
+	}
+	name := fmt.Sprintf("%d_stored.sql", v)
+	if first := strings.SplitN(string(b), "\n", 2)[0]; strings.HasPrefix(first, streamFileMarker) {
+		name = filepath.Base(strings.TrimSpace(first[len(streamFileMarker):]))
+	}
+	// The script is a plain SQL migration, whatever the original was.
+	for _, ext := range []string{gzipSQLExt, migrateUpExt} {
+		if strings.HasSuffix(name, ext) {
+			name = strings.TrimSuffix(name, ext) + ".sql"
+		}
+	}
+
+	source := storedDir + "/" + name
+	storedScriptsMu.Lock()
+	storedScripts[source] = b
+	storedScriptsMu.Unlock()
+	cleanup = func() {
+		storedScriptsMu.Lock()
+		delete(storedScripts, source)
+		storedScriptsMu.Unlock()
+	}
+	c.log.Printf("goose: %s: rolling back with the Down SQL stored in %s\n", name, c.tables.version())
+	return &Migration{Version: v, Next: -1, Previous: -1, Source: source}, cleanup, nil
+}
@@ -0,0 +1,100 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStoredMigrationInMemory(t *testing.T) {
+	db := openDuckDB(t)
+	if _, err := EnsureDBVersion(db); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "goose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "00001_users.sql")
+	ioutil.WriteFile(path, []byte("-- +goose Up\nCREATE TABLE users (id int);\n-- +goose Down\nDROP TABLE users;\n"), 0644)
+
+	SetStoreDownSQL(true)
+	defer SetStoreDownSQL(false)
+	if _, err := db.Exec(GetDialect().insertVersionSQL(packageTables()), 1, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDownSQL(context.Background(), db, downSQL(context.Background(), db, path), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stored script is read back without touching the migrations
+	// filesystem.
+	SetBaseFS(fstest.MapFS{})
+	defer SetBaseFS(nil)
+	m, cleanup, err := storedMigration(context.Background(), db, 1)
+	if err != nil || m == nil {
+		t.Fatalf("got migration %v, error %v", m, err)
+	}
+	stmts, _, _, err := readSQLStatements(packageConfig(), m.Source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 || !strings.Contains(stmts[0], "DROP TABLE users;") {
+		t.Errorf("got statements %q, want the drop", stmts)
+	}
+
+	cleanup()
+	if _, ok := memoryFile(m.Source); ok {
+		t.Error("stored script kept after cleanup")
+	}
+}
+
+func TestStoreDownSQL(t *testing.T) {
+	db := openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id int);\n-- +goose Down\nDROP TABLE a;\n")},
+	})
+	defer SetBaseFS(nil)
+	SetStoreDownSQL(true)
+	defer SetStoreDownSQL(false)
+	ctx := context.Background()
+
+	// A version table without down_sql: the migration is applied all the
+	// same.
+	for _, q := range []string{
+		"CREATE SEQUENCE goose_db_version_id",
+		"CREATE TABLE goose_db_version (id integer NOT NULL default nextval('goose_db_version_id'), version_id bigint NOT NULL, is_applied boolean NOT NULL, tstamp timestamp NULL default current_timestamp, PRIMARY KEY(id))",
+		"INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, true)",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := runSQLMigration(ctx, db, "migrations/00001_a.sql", 1, true); err != nil {
+		t.Fatalf("migration failed without down_sql: %v", err)
+	}
+	if _, err := runSQLMigration(ctx, db, "migrations/00001_a.sql", 1, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the row of the latest run is written.
+	if err := addDownSQLColumn(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runSQLMigration(ctx, db, "migrations/00001_a.sql", 1, true); err != nil {
+		t.Fatal(err)
+	}
+	var stored int
+	if err := db.QueryRow("SELECT COUNT(*) FROM goose_db_version WHERE version_id = 1 AND down_sql IS NOT NULL").Scan(&stored); err != nil || stored != 1 {
+		t.Errorf("got %d rows with Down SQL, %v, want 1", stored, err)
+	}
+}
@@ -0,0 +1,50 @@
+package goose
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoredDownScript(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "00001_users.sql")
+	ioutil.WriteFile(path, []byte(`-- +goose NO TRANSACTION
+-- +goose Up
+CREATE TABLE users (id int);
+-- +goose Down
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS int AS $$
+BEGIN RETURN 1; END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+DROP TABLE users;
+`), 0644)
+
+	script, err := storedDownScript(packageConfig(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(r)
+	if !bytes.HasPrefix(b, []byte("-- +goose File 00001_users.sql\n")) {
+		t.Errorf("missing file name in %q", b)
+	}
+
+	got, _, tx, _ := parseSQLStatements(packageConfig(), bytes.NewReader(b), false)
+	if tx || len(got) != 2 || !strings.Contains(got[0], "BEGIN RETURN 1; END;") || !strings.Contains(got[1], "DROP TABLE users;") {
+		t.Errorf("got statements %q, tx %v; want the function and the drop, without transaction", got, tx)
+	}
+}
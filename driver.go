@@ -0,0 +1,215 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// debugDriverPrefix is prepended to the name of wrapped drivers.
+const debugDriverPrefix = "goose-debug-"
+
+var debugDriversMu sync.Mutex
+
+// DebugDriver registers a database/sql driver wrapping the driver registered
+// as name, which logs every query with its arguments, the number of affected
+// rows and its latency. It returns the name to pass to sql.Open.
+//
+// Arguments are logged as is: only use it for debugging, as they may contain
+// sensitive data.
+func DebugDriver(name string) (string, error) {
+	debugDriversMu.Lock()
+	defer debugDriversMu.Unlock()
+
+	debugName := debugDriverPrefix + name
+	for _, d := range sql.Drivers() {
+		if d == debugName {
+			return debugName, nil
+		}
+	}
+
+	// sql.Open doesn't connect, it's only used to look the driver up.
+	db, err := sql.Open(name, "")
+	if err != nil {
+		return "", err
+	}
+	sql.Register(debugName, debugDriver{db.Driver()})
+	return debugName, nil
+}
+
+func logQuery(query string, args []driver.NamedValue, rows int64, started time.Time, err error) {
+	values := make([]interface{}, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	msg := fmt.Sprintf("goose: sql (%v): %s", time.Since(started).Round(time.Microsecond), redactSQL(query))
+	if len(values) > 0 {
+		msg += fmt.Sprintf(" args=%v", values)
+	}
+	if rows >= 0 {
+		msg += fmt.Sprintf(" rows=%d", rows)
+	}
+	if err != nil {
+		msg += fmt.Sprintf(" err=%v", err)
+	}
+	log.Println(msg)
+}
+
+func affectedRows(res driver.Result, err error) int64 {
+	if err != nil {
+		return -1
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+type debugDriver struct {
+	driver.Driver
+}
+
+func (d debugDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return debugConn{c}, nil
+}
+
+// debugConn falls back to the prepared statement path with driver.ErrSkip
+// when the wrapped connection doesn't implement an optional interface.
+type debugConn struct {
+	driver.Conn
+}
+
+func (c debugConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c debugConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return debugStmt{Stmt: s, query: query}, nil
+}
+
+func (c debugConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c debugConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	started := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		logQuery(query, args, affectedRows(res, err), started, err)
+	}
+	return res, err
+}
+
+func (c debugConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	started := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		logQuery(query, args, -1, started, err)
+	}
+	return rows, err
+}
+
+func (c debugConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c debugConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c debugConn) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+type debugStmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s debugStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	started := time.Now()
+	var res driver.Result
+	var err error
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			res, err = s.Stmt.Exec(values)
+		}
+	}
+	logQuery(s.query, args, affectedRows(res, err), started, err)
+	return res, err
+}
+
+func (s debugStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	started := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	logQuery(s.query, args, -1, started, err)
+	return rows, err
+}
+
+func (s debugStmt) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, fmt.Errorf("driver does not support named parameter %s", a.Name)
+		}
+		values[i] = a.Value
+	}
+	return values, nil
+}
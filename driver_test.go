@@ -0,0 +1,91 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+// Prepare fails on queries using a missing column, and returns a nil
+// statement otherwise.
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.Contains(query, "missing") {
+		return nil, errors.New(`column "missing" does not exist`)
+	}
+	return fakeStmt{}, nil
+}
+
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not implemented")
+}
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(2), nil
+}
+
+type captureLogger struct {
+	stdLogger
+	lines []string
+}
+
+func (l *captureLogger) Println(v ...interface{}) {
+	l.lines = append(l.lines, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+func init() {
+	sql.Register("goose-fake", fakeDriver{})
+}
+
+func TestDebugDriver(t *testing.T) {
+	name, err := DebugDriver("goose-fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := DebugDriver("goose-fake"); again != name {
+		t.Errorf("driver registered twice: %s, %s", name, again)
+	}
+
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("UPDATE post SET title = $1", "goose"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logger.lines) != 1 {
+		t.Fatalf("expected 1 logged query, got %q", logger.lines)
+	}
+	if line := logger.lines[0]; !strings.Contains(line, "UPDATE post SET title = $1 args=[goose] rows=2") {
+		t.Errorf("incorrect log line %q", line)
+	}
+}
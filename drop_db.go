@@ -1,6 +1,9 @@
 package goose
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+)
 
 // DropDB drops database
 func DropDB(dbstring string) error {
@@ -11,6 +14,10 @@ func DropDB(dbstring string) error {
 		return fmt.Errorf("failed to get db name: %v", err)
 	}
 
+	if _, ok := d.(*DuckDBDialect); ok {
+		return dropDuckDB(dbName)
+	}
+
 	db, err := d.connectToServer(dbstring)
 	if err != nil {
 		return fmt.Errorf("failed to connect to the server: %v", err)
@@ -19,3 +26,14 @@ func DropDB(dbstring string) error {
 	_, err = db.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", dbName))
 	return err
 }
+
+// dropDuckDB removes the DuckDB database file path and its write-ahead log,
+// if they exist.
+func dropDuckDB(path string) error {
+	for _, name := range []string{path, path + ".wal"} {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+var dryRun = false
+
+// SetDryRun sets whether up, up-by-one, up-to, down, down-to and redo only
+// print the statements they would execute, with the version table inserts,
+// instead of running them.
+func SetDryRun(d bool) {
+	dryRun = d
+}
+
+// dryRunCommands are the commands SetDryRun applies to.
+var dryRunCommands = map[string]bool{
+	"up":        true,
+	"up-by-one": true,
+	"up-to":     true,
+	"down":      true,
+	"down-to":   true,
+	"redo":      true,
+}
+
+// printDryRun writes the statements command would execute to w. The
+// migrations are the ones of its plan, nothing is executed nor recorded.
+func printDryRun(ctx context.Context, w io.Writer, db *sql.DB, dir, command string, args []string) error {
+	planCommand := command
+	switch command {
+	case "up-by-one":
+		planCommand = "up"
+	case "redo":
+		planCommand = "down"
+	}
+	plan, err := GetPlanContext(ctx, db, dir, planCommand, args...)
+	if err != nil {
+		return err
+	}
+	steps := plan.Migrations
+	target := plan.TargetVersion
+	switch {
+	case command == "up-by-one" && len(steps) > 1:
+		steps, target = steps[:1], steps[0].Version
+	case command == "redo" && len(steps) == 1:
+		up := steps[0]
+		up.Direction = "up"
+		steps, target = append(steps, up), up.Version
+	}
+
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "-- goose dry run: %s, version %d -> %d\n", command, plan.CurrentVersion, target)
+	for _, step := range steps {
+		m, err := migrations.Current(step.Version)
+		if err != nil {
+			return err
+		}
+		if err := dryRunMigration(c, w, m, step.Direction == "up"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dryRunMigration(c *config, w io.Writer, m *Migration, direction bool) error {
+	name := filepath.Base(m.Source)
+	dir := "up"
+	if !direction {
+		dir = "down"
+	}
+	fmt.Fprintf(w, "\n-- version %d: %s (%s)\n", m.Version, name, dir)
+
+	if !isSQLMigration(m.Source) {
+		fmt.Fprintln(w, "-- Go migration, its statements are only known when it runs")
+		fmt.Fprintln(w, insertVersionLiteral(c, m.Version, direction))
+		return nil
+	}
+
+	statements, useTx, skip, err := scriptStatements(c, m, direction)
+	if err != nil {
+		return err
+	}
+	if skip {
+		fmt.Fprintln(w, "-- skipped in this environment, only recorded")
+	}
+	writeScript(c, w, m.Version, statements, useTx, direction)
+	return nil
+}
@@ -0,0 +1,34 @@
+package goose
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDryRunMigration(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Migration{Version: 3, Source: "./examples/sql-migrations/00003_no_transaction.sql"}
+	if err := dryRunMigration(packageConfig(), &buf, m, false); err != nil {
+		t.Fatal(err)
+	}
+	m = &Migration{Version: 4, Source: "00004_backfill.go"}
+	if err := dryRunMigration(packageConfig(), &buf, m, true); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"-- version 3: 00003_no_transaction.sql (down)\n",
+		"VALUES (3, false, now() at time zone 'utc');\n",
+		"-- version 4: 00004_backfill.go (up)\n-- Go migration",
+		"VALUES (4, true, now() at time zone 'utc');\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "BEGIN;") {
+		t.Errorf("expected NO TRANSACTION migration to run outside of a transaction:\n%s", out)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+// openDuckDB returns an in-memory DuckDB database, setting the duckdb
+// dialect until the test ends.
+func openDuckDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDialect("duckdb"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetDialect("postgres")
+		db.Close()
+	})
+	return db
+}
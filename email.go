@@ -0,0 +1,123 @@
+package goose
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// EmailOptions configures the summary email sent when a run fails.
+type EmailOptions struct {
+	// Addr is the host:port of the SMTP server.
+	Addr string
+	// Username and Password authenticate with PLAIN auth when set.
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Template is a text/template executed with a RunNotification, rendering
+	// the message body. It may start with a "Subject: ..." line.
+	Template string
+}
+
+var (
+	email         *EmailOptions
+	emailTemplate *template.Template
+)
+
+var defaultEmailTemplate = `Subject: goose {{.Command}} failed{{if .Database}} on {{.Database}}{{end}}
+
+goose {{.Command}} failed{{if .Database}} on {{.Database}}{{end}} after {{len .Migrations}} migrations:
+
+{{.Error}}
+{{range .Migrations}}
+  {{.File}} ({{.Direction}}, {{.Duration}})
+{{- end}}
+`
+
+// EnableEmail sends a summary email when a run changing the database fails,
+// for environments where webhooks aren't permitted.
+func EnableEmail(opts EmailOptions) error {
+	if opts.Addr == "" || opts.From == "" || len(opts.To) == 0 {
+		return fmt.Errorf("email: SMTP address, sender and recipients are required")
+	}
+	text := opts.Template
+	if text == "" {
+		text = defaultEmailTemplate
+	}
+	t, err := template.New("goose.email").Parse(text)
+	if err != nil {
+		return fmt.Errorf("email: %v", err)
+	}
+	email, emailTemplate = &opts, t
+	return nil
+}
+
+// emailRunFailure sends the failure summary of a run. Delivery failures are
+// logged, they never fail the run.
+func emailRunFailure(r *runReport, err error) {
+	if email == nil || err == nil || err == ErrNoChange {
+		return
+	}
+	n := RunNotification{
+		Event:      "failure",
+		Command:    r.command,
+		Database:   databaseName,
+		Migrations: r.migrations,
+		Duration:   time.Since(r.started),
+		Error:      err.Error(),
+	}
+	if err := sendEmail(n); err != nil {
+		log.Printf("goose: email: %v\n", err)
+	}
+}
+
+func sendEmail(n RunNotification) error {
+	msg, err := emailMessage(n)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if email.Username != "" {
+		host, _, err := net.SplitHostPort(email.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", email.Username, email.Password, host)
+	}
+	return smtp.SendMail(email.Addr, auth, email.From, email.To, msg)
+}
+
+// emailMessage renders the template into an RFC 5322 message.
+func emailMessage(n RunNotification) ([]byte, error) {
+	var body bytes.Buffer
+	if err := emailTemplate.Execute(&body, n); err != nil {
+		return nil, err
+	}
+
+	subject := "goose " + n.Command + " failed"
+	text := body.String()
+	if strings.HasPrefix(text, "Subject:") {
+		i := strings.Index(text, "\n")
+		if i < 0 {
+			i = len(text)
+		}
+		subject = strings.TrimSpace(text[len("Subject:"):i])
+		text = strings.TrimLeft(text[i:], "\n")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", email.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(text, "\n", "\r\n", -1))
+	return msg.Bytes(), nil
+}
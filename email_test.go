@@ -0,0 +1,34 @@
+package goose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmailMessage(t *testing.T) {
+	defer func() { email, emailTemplate = nil, nil }()
+	if err := EnableEmail(EmailOptions{Addr: "localhost:25", From: "goose@example.com", To: []string{"dba@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := emailMessage(RunNotification{
+		Command:    "up",
+		Database:   "app",
+		Migrations: []AppliedMigration{{Version: 1, File: "00001_users.sql", Direction: "up"}},
+		Error:      "FAIL 00002_posts.sql:3: syntax error",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"To: dba@example.com\r\n",
+		"Subject: goose up failed on app\r\n",
+		"\r\n\r\ngoose up failed on app after 1 migrations:\r\n\r\nFAIL 00002_posts.sql:3: syntax error\r\n",
+		"00001_users.sql (up, 0s)",
+	} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("message %q doesn't contain %q", msg, want)
+		}
+	}
+}
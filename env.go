@@ -0,0 +1,51 @@
+package goose
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// environment is the environment goose runs in, matched against the Env
+// annotations of migrations.
+var environment string
+
+// SetEnvironment sets the environment goose runs in, e.g. production. SQL
+// migrations annotated with "-- +goose Env production,staging" only run in
+// the listed environments; in the others they are recorded as applied or
+// rolled back without being executed, so that all environments share the
+// same version history.
+func SetEnvironment(env string) {
+	environment = env
+}
+
+// migrationEnvironments returns the environments listed by the Env
+// annotation of a migration, or nil if it runs everywhere.
+func migrationEnvironments(c *config, path string) ([]string, error) {
+	value, err := readAnnotation(c, path, "Env")
+	if err != nil || value == "" {
+		return nil, err
+	}
+	return strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }), nil
+}
+
+// skipForEnvironment reports whether a SQL migration is gated to other
+// environments than the current one. Gated migrations need the environment
+// to be set, lest they are skipped by mistake.
+func skipForEnvironment(c *config, path string) (bool, error) {
+	envs, err := migrationEnvironments(c, path)
+	if err != nil || envs == nil {
+		return false, err
+	}
+	if environment == "" {
+		file := filepath.Base(path)
+		return false, &ValidationError{File: file, Err: fmt.Errorf("%s only runs in %s, set the environment with -env or GOOSE_ENV", file, strings.Join(envs, ", "))}
+	}
+	for _, env := range envs {
+		if env == environment {
+			return false, nil
+		}
+	}
+	c.log.Printf("goose: %s: skipped, only runs in %s\n", filepath.Base(path), strings.Join(envs, ", "))
+	return true, nil
+}
@@ -0,0 +1,47 @@
+package goose
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSkipForEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gated := filepath.Join(dir, "00001_fdw.sql")
+	ioutil.WriteFile(gated, []byte("-- +goose Env production, staging\n-- +goose Up\nCREATE SERVER reports FOREIGN DATA WRAPPER postgres_fdw;\n"), 0644)
+	plain := filepath.Join(dir, "00002_users.sql")
+	ioutil.WriteFile(plain, []byte("-- +goose Up\nCREATE TABLE users (id int);\n"), 0644)
+
+	defer SetEnvironment("")
+	tests := []struct {
+		env  string
+		path string
+		skip bool
+	}{
+		{"production", gated, false},
+		{"staging", gated, false},
+		{"development", gated, true},
+		{"development", plain, false},
+		{"", plain, false},
+	}
+	for _, test := range tests {
+		SetEnvironment(test.env)
+		if skip, err := skipForEnvironment(packageConfig(), test.path); err != nil || skip != test.skip {
+			t.Errorf("%s in %q: got %v, %v; want %v", filepath.Base(test.path), test.env, skip, err, test.skip)
+		}
+	}
+
+	SetEnvironment("")
+	var validationErr *ValidationError
+	if _, err := skipForEnvironment(packageConfig(), gated); !errors.As(err, &validationErr) {
+		t.Errorf("got %v, want a validation error without environment", err)
+	}
+}
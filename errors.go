@@ -0,0 +1,88 @@
+package goose
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoChange is returned by Run in strict mode when a command applying
+// migrations had nothing to do.
+var ErrNoChange = errors.New("no migrations to apply")
+
+// ErrPending is returned by status and pending with -check when migrations
+// haven't been applied yet.
+var ErrPending = errors.New("migrations are pending")
+
+var strict = false
+
+// SetStrict sets whether Run returns ErrNoChange when a command applying
+// migrations finds the database already up to date.
+func SetStrict(s bool) {
+	strict = s
+}
+
+// ValidationError is returned when the migration files can't be collected
+// or parsed.
+type ValidationError struct {
+	// File is the path of the offending migration, when known.
+	File string
+	Err  error
+}
+
+func (e *ValidationError) Error() string { return e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// MigrationError is returned when a migration fails to apply.
+type MigrationError struct {
+	Version int64
+	Source  string
+	Err     error
+	// Stack is the stack trace of a Go migration that panicked.
+	Stack []byte
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("FAIL %v, quitting migration", e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *MigrationError) Unwrap() error { return e.Err }
+
+// StatementError is returned when an SQL statement of a migration fails.
+type StatementError struct {
+	File  string
+	Line  int // 1-based line the statement starts at
+	Query string
+	Err   error
+}
+
+// maxExcerptLen is the maximum length of the statement excerpt in errors.
+const maxExcerptLen = 80
+
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("%s:%d: %v\n\t%s", e.File, e.Line, e.Err, e.excerpt())
+}
+
+// Unwrap returns the underlying error.
+func (e *StatementError) Unwrap() error { return e.Err }
+
+// excerpt returns the first line of the statement, skipping comments, with
+// credentials redacted.
+func (e *StatementError) excerpt() string {
+	var first string
+	for _, line := range strings.Split(e.Query, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			first = line
+			break
+		}
+	}
+	first = redactSQL(first)
+	if len(first) > maxExcerptLen {
+		first = first[:maxExcerptLen] + "..."
+	}
+	return first
+}
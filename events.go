@@ -0,0 +1,78 @@
+package goose
+
+import (
+	"context"
+	"time"
+)
+
+// Event is passed to the handler set with WithEventHandler. It is one of
+// RunStarted, MigrationApplied, MigrationProgress, StatementFailed and
+// RunFinished.
+type Event interface {
+	event()
+}
+
+// RunStarted is emitted when a command applying migrations starts.
+type RunStarted struct {
+	Command string
+	Time    time.Time
+}
+
+// MigrationApplied is emitted after a migration was applied or rolled back.
+type MigrationApplied struct {
+	AppliedMigration
+}
+
+// MigrationProgress is emitted after each statement of a migration running
+// outside of a transaction.
+type MigrationProgress struct {
+	Version   int64
+	File      string
+	Statement int // 1-based
+	Total     int
+}
+
+// Percent returns how much of the migration has been executed.
+func (p MigrationProgress) Percent() float64 {
+	return float64(p.Statement) * 100 / float64(p.Total)
+}
+
+// StatementFailed is emitted when an SQL statement fails.
+type StatementFailed struct {
+	Version   int64
+	File      string
+	Statement int // 1-based
+	Line      int // 1-based line the statement starts at
+	Err       error
+}
+
+// RunFinished is emitted when a command applying migrations completes.
+// Err is nil on success.
+type RunFinished struct {
+	Command    string
+	Migrations []AppliedMigration
+	Duration   time.Duration
+	Err        error
+}
+
+func (RunStarted) event()        {}
+func (MigrationApplied) event()  {}
+func (MigrationProgress) event() {}
+func (StatementFailed) event()   {}
+func (RunFinished) event()       {}
+
+type eventHandlerKey struct{}
+
+func withEventHandler(ctx context.Context, h func(Event)) context.Context {
+	if h == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, eventHandlerKey{}, h)
+}
+
+// emit passes e to the event handler of ctx, if any.
+func emit(ctx context.Context, e Event) {
+	if h, ok := ctx.Value(eventHandlerKey{}).(func(Event)); ok {
+		h(e)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/gojuno/goose"
+
+	_ "github.com/lib/pq"
+)
+
+// The migrations are compiled into the binary: it runs from any folder,
+// without the SQL files next to it.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+var dbstring = flag.String("dbstring", os.Getenv("GOOSE_DBSTRING"), "postgres connection string")
+
+func main() {
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"up"}
+	}
+
+	db, err := sql.Open("postgres", *dbstring)
+	if err != nil {
+		log.Fatalf("-dbstring=%q: %v", *dbstring, err)
+	}
+	defer db.Close()
+
+	goose.SetBaseFS(migrations)
+	if err := goose.Run(args[0], db, "migrations", args[1:]...); err != nil {
+		log.Fatalf("goose run: %v", err)
+	}
+}
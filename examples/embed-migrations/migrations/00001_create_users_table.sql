@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE users (
+    id int NOT NULL PRIMARY KEY,
+    username text,
+    name text,
+    surname text
+);
+
+INSERT INTO users VALUES
+(0, 'root', '', ''),
+(1, 'vojtechvitek', 'Vojtech', 'Vitek');
+
+-- +goose Down
+DROP TABLE users;
@@ -0,0 +1,75 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Exec runs an SQL script without recording a version, so that operational
+// scripts get the annotations and transaction handling of migrations:
+// StatementBegin/StatementEnd, NO TRANSACTION, fixture loads and the
+// statements running outside of a transaction. The script is run as the Up
+// section of a migration; the Up annotation is optional.
+func Exec(db *sql.DB, path string) error {
+	if err := runScript(context.Background(), db, path, nil); err != nil {
+		return err
+	}
+	log.Println(colorize(colorGreen, "OK   "), filepath.Base(path))
+	return nil
+}
+
+// runScript executes a script as the Up section of a migration, then
+// record, if not nil, in the same transaction unless the script runs outside
+// of one.
+func runScript(ctx context.Context, db *sql.DB, path string, record func(execer) error) error {
+	c := configFrom(ctx)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	offset := 0
+	if !bytes.Contains(b, []byte(sqlCmdPrefix+"Up")) {
+		b = append([]byte(sqlCmdPrefix+"Up\n"), b...)
+		offset = 1
+	}
+	statements, lines, useTx, err := parseSQLStatements(c, bytes.NewReader(b), true)
+	if err != nil {
+		return &ValidationError{File: path, Err: err}
+	}
+	if useTx, err = needsTransaction(c, path, statements, lines, useTx); err != nil {
+		return err
+	}
+
+	result := newExecResult()
+	var exec execer = db
+	var tx *sql.Tx
+	if useTx {
+		if tx, err = db.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		exec = tx
+	}
+	for i, query := range statements {
+		if err := execStatement(ctx, exec, path, 0, i, lines[i]-offset, query, &result); err != nil {
+			if tx != nil {
+				tx.Rollback()
+			}
+			return err
+		}
+	}
+	if record != nil {
+		if err := record(exec); err != nil {
+			if tx != nil {
+				tx.Rollback()
+			}
+			return err
+		}
+	}
+	if tx != nil {
+		return tx.Commit()
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package goose
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	noTx := filepath.Join(dir, "cleanup.sql")
+	ioutil.WriteFile(noTx, []byte("-- +goose NO TRANSACTION\nDELETE FROM sessions;\nUPDATE users SET active = false;\n"), 0644)
+	tx := filepath.Join(dir, "backfill.sql")
+	ioutil.WriteFile(tx, []byte("UPDATE users SET active = true;\n"), 0644)
+
+	db, err := sql.Open("goose-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+
+	if err := Exec(db, noTx); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.lines) != 1 || !strings.HasSuffix(logger.lines[0], "cleanup.sql") {
+		t.Errorf("got log lines %q", logger.lines)
+	}
+	// The fake driver has no transactions.
+	if err := Exec(db, tx); err == nil || !strings.Contains(err.Error(), "not implemented") {
+		t.Errorf("got %v, want the script to run in a transaction", err)
+	}
+}
@@ -0,0 +1,121 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var explainRows int64
+
+// SetExplain enables a pre-flight analysis of the pending migrations: before
+// up applies anything, their UPDATE and DELETE statements are EXPLAINed and
+// a warning is logged for every full scan of a table estimated at more than
+// rows rows. Zero, the default, disables it.
+func SetExplain(rows int64) {
+	explainRows = rows
+}
+
+// fullScan is a sequential scan found in a query plan.
+type fullScan struct {
+	table string
+	rows  int64
+}
+
+var (
+	dmlRegexp     = regexp.MustCompile(`(?i)^\s*(UPDATE|DELETE)\b`)
+	seqScanRegexp = regexp.MustCompile(`Seq Scan on (\S+).*\brows=(\d+)`)
+)
+
+// explainPending warns about the full scans of the UPDATE and DELETE
+// statements of the pending migrations. Statements that can't be
+// explained, e.g. because they use a table created by a pending migration,
+// are skipped.
+func explainPending(ctx context.Context, db *sql.DB, pending Migrations) error {
+	c := configFrom(ctx)
+	for _, m := range pending {
+		if !isSQLMigration(m.Source) {
+			continue
+		}
+
+		statements, lines, _, err := readSQLStatements(c, m.Source, true)
+		if err != nil {
+			return err
+		}
+
+		for i, query := range statements {
+			if !dmlRegexp.MatchString(stripComments(query)) {
+				continue
+			}
+			scans, err := explainFullScans(ctx, db, query)
+			if err != nil {
+				if c.verbose {
+					c.log.Printf("goose: can't EXPLAIN %s statement %d: %v\n", filepath.Base(m.Source), i+1, err)
+				}
+				continue
+			}
+			for _, s := range scans {
+				if s.rows > explainRows {
+					c.log.Printf("WARNING: %s:%d: full scan of %s (~%d rows)\n", filepath.Base(m.Source), lines[i], s.table, s.rows)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// explainFullScans returns the full table scans of the plan of query.
+func explainFullScans(ctx context.Context, db *sql.DB, query string) ([]fullScan, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	column := func(name string) string {
+		for i, c := range columns {
+			if strings.EqualFold(c, name) {
+				return values[i].String
+			}
+		}
+		return ""
+	}
+
+	var scans []fullScan
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		switch configFrom(ctx).dialect.(type) {
+		case *MySQLDialect, *TiDBDialect:
+			// One row per table, "ALL" being a full scan.
+			if column("type") == "ALL" {
+				n, _ := strconv.ParseInt(column("rows"), 10, 64)
+				scans = append(scans, fullScan{table: column("table"), rows: n})
+			}
+		default:
+			// One line of the text plan per row.
+			if m := seqScanRegexp.FindStringSubmatch(values[0].String); m != nil {
+				n, _ := strconv.ParseInt(m[2], 10, 64)
+				scans = append(scans, fullScan{table: m[1], rows: n})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("EXPLAIN: %v", err)
+	}
+	return scans, nil
+}
@@ -0,0 +1,27 @@
+package goose
+
+import "testing"
+
+func TestSeqScanRegexp(t *testing.T) {
+	tests := []struct {
+		line  string
+		table string
+		rows  string
+	}{
+		{"  ->  Seq Scan on users  (cost=0.00..18334.00 rows=1000000 width=10)", "users", "1000000"},
+		{"  ->  Seq Scan on public.orders o  (cost=0.00..35.50 rows=2550 width=6)", "public.orders", "2550"},
+		{"  ->  Index Scan using users_pkey on users  (cost=0.42..8.44 rows=1 width=10)", "", ""},
+	}
+	for _, test := range tests {
+		m := seqScanRegexp.FindStringSubmatch(test.line)
+		if test.table == "" {
+			if m != nil {
+				t.Errorf("%q: unexpected match %q", test.line, m)
+			}
+			continue
+		}
+		if m == nil || m[1] != test.table || m[2] != test.rows {
+			t.Errorf("%q: got %q", test.line, m)
+		}
+	}
+}
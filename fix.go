@@ -0,0 +1,96 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fix renames the timestamp-versioned migrations of dir to the sequential
+// versions following the last sequential one, in version order, e.g.
+// 20240312101500_add_email.sql to 00004_add_email.sql, so that migrations
+// created with timestamps during development are numbered before release.
+// With a database, applied timestamp versions are refused, as renaming them
+// would apply them again. goose.lock and the manifest are written again if
+// the folder has them.
+func Fix(db *sql.DB, dir string) error {
+	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+
+	last := int64(0)
+	var timestamped Migrations
+	for _, m := range migrations {
+		if isTimestampVersion(m.Version) {
+			timestamped = append(timestamped, m)
+		} else if m.Version > last {
+			last = m.Version
+		}
+	}
+	if len(timestamped) == 0 {
+		log.Printf("goose: no timestamp migrations to fix\n")
+		return nil
+	}
+
+	if db != nil {
+		statuses, err := GetStatus(db, dir)
+		if err != nil {
+			return err
+		}
+		var applied []string
+		for _, s := range statuses {
+			if s.Applied && isTimestampVersion(s.Version) {
+				applied = append(applied, filepath.Base(s.Source))
+			}
+		}
+		if len(applied) > 0 {
+			return fmt.Errorf("can't renumber applied migrations: %s", strings.Join(applied, ", "))
+		}
+	}
+
+	// Every rename is checked before the first one, not to leave the folder
+	// half renamed.
+	type rename struct{ from, to string }
+	var renames []rename
+	for i, m := range timestamped {
+		version := fmt.Sprintf("%05v", last+int64(i)+1)
+		files := []string{filepath.Join(dir, filepath.Base(m.Source))}
+		if isPairedMigration(m.Source) {
+			files = append(files, pairedDownFile(files[0]))
+		}
+		for _, from := range files {
+			if _, err := os.Stat(from); os.IsNotExist(err) && from != files[0] {
+				continue // paired migration without down file
+			} else if err != nil {
+				return err
+			}
+			name := filepath.Base(from)
+			to := filepath.Join(dir, version+name[strings.Index(name, "_"):])
+			if _, err := os.Stat(to); err == nil {
+				return fmt.Errorf("can't rename %s: %s already exists", name, filepath.Base(to))
+			}
+			renames = append(renames, rename{from, to})
+		}
+	}
+
+	for _, r := range renames {
+		if err := os.Rename(r.from, r.to); err != nil {
+			return err
+		}
+		log.Printf("goose: renamed %s to %s\n", filepath.Base(r.from), filepath.Base(r.to))
+	}
+
+	// The lock is collected with the manifest, which goes first.
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil {
+		if err := WriteManifest(dir); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, LockFile)); err == nil {
+		return WriteLock(dir)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package goose
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFix(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"00001_users.sql":               "-- +goose Up\nCREATE TABLE users (id int);\n",
+		"20240313094500_phone.sql":      "-- +goose Up\nALTER TABLE users ADD phone text;\n",
+		"20240312101500_email.up.sql":   "ALTER TABLE users ADD email text;\n",
+		"20240312101500_email.down.sql": "ALTER TABLE users DROP email;\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := WriteLock(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Fix(nil, dir); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	want := []string{"00001_users.sql", "00002_email.down.sql", "00002_email.up.sql", "00003_phone.sql"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("got files %v, want %v", files, want)
+	}
+	if err := VerifyLock(dir); err != nil {
+		t.Errorf("goose.lock wasn't written again: %v", err)
+	}
+}
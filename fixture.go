@@ -0,0 +1,221 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fixtureBatchSize is the number of rows inserted per statement when COPY
+// isn't available.
+const fixtureBatchSize = 500
+
+// loadDirective is a "-- +goose Load FILE INTO TABLE" annotation, loading a
+// CSV file, whose header names the columns, or a JSON array of objects into
+// a table.
+type loadDirective struct {
+	file  string
+	table string
+}
+
+// parseLoad parses a load directive, emitted by the parser as a statement of
+// its own.
+func parseLoad(query string) (loadDirective, bool) {
+	cmd, ok := annotationCommand([]byte(strings.TrimSpace(query)))
+	if !ok || !bytes.HasPrefix(cmd, []byte("Load ")) {
+		return loadDirective{}, false
+	}
+	fields := strings.Fields(string(cmd[len("Load "):]))
+	if len(fields) != 3 || !strings.EqualFold(fields[1], "INTO") {
+		return loadDirective{}, false
+	}
+	return loadDirective{file: fields[0], table: fields[2]}, true
+}
+
+type copyInKey struct{}
+
+// withCopyIn records whether the driver of db supports COPY FROM STDIN
+// through prepared statements, as lib/pq does.
+func withCopyIn(ctx context.Context, db *sql.DB) context.Context {
+	_, pg := configFrom(ctx).dialect.(*PostgresDialect)
+	return context.WithValue(ctx, copyInKey{}, pg && fmt.Sprintf("%T", db.Driver()) == "*pq.Driver")
+}
+
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// execLoad loads the fixture of a directive, its path being relative to the
+// migration, with COPY where the driver supports it, batched inserts
+// otherwise. It returns the number of rows loaded.
+func execLoad(ctx context.Context, db execer, scriptFile string, load loadDirective) (int64, error) {
+	path := load.file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(scriptFile), path)
+	}
+	c := configFrom(ctx)
+	columns, rows, err := readFixture(c, path)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	if p, ok := db.(preparer); ok && ctx.Value(copyInKey{}) == true {
+		return copyIn(ctx, p, load.table, columns, rows)
+	}
+
+	for start := 0; start < len(rows); start += fixtureBatchSize {
+		end := start + fixtureBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		query, args := insertBatchSQL(c, load.table, columns, rows[start:end])
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(rows)), nil
+}
+
+func copyIn(ctx context.Context, db preparer, table string, columns []string, rows [][]interface{}) (int64, error) {
+	stmt, err := db.PrepareContext(ctx, fmt.Sprintf("COPY %s (%s) FROM STDIN", table, strings.Join(columns, ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return 0, err
+		}
+	}
+	// Executing without arguments flushes the buffered rows.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
+
+// insertBatchSQL returns a multi-row INSERT with the placeholders of the
+// current dialect.
+func insertBatchSQL(c *config, table string, columns []string, rows [][]interface{}) (string, []interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for j, v := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			args = append(args, v)
+			b.WriteString(c.placeholder(len(args)))
+		}
+		b.WriteString(")")
+	}
+	return b.String(), args
+}
+
+// readFixture reads the columns and rows of a .csv or .json fixture. Empty
+// CSV fields are NULL.
+func readFixture(c *config, path string) ([]string, [][]interface{}, error) {
+	f, err := c.openFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	switch filepath.Ext(path) {
+	case ".csv":
+		columns, rows, err := readCSVFixture(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return columns, rows, nil
+	case ".json":
+		columns, rows, err := readJSONFixture(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return columns, rows, nil
+	}
+	return nil, nil, fmt.Errorf("%s: fixtures must be .csv or .json files", path)
+}
+
+func readCSVFixture(r io.Reader) ([]string, [][]interface{}, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("missing header")
+	}
+
+	rows := make([][]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make([]interface{}, len(record))
+		for i, v := range record {
+			if v != "" {
+				row[i] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return records[0], rows, nil
+}
+
+// readJSONFixture reads an array of objects. The columns are the keys of all
+// the objects, missing keys being NULL; nested values are loaded as JSON.
+func readJSONFixture(r io.Reader) ([]string, [][]interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var objects []map[string]interface{}
+	if err := dec.Decode(&objects); err != nil {
+		return nil, nil, err
+	}
+
+	keys := map[string]bool{}
+	for _, o := range objects {
+		for k := range o {
+			keys[k] = true
+		}
+	}
+	columns := make([]string, 0, len(keys))
+	for k := range keys {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	rows := make([][]interface{}, 0, len(objects))
+	for _, o := range objects {
+		row := make([]interface{}, len(columns))
+		for i, c := range columns {
+			switch v := o[c].(type) {
+			case map[string]interface{}, []interface{}:
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, nil, err
+				}
+				row[i] = string(b)
+			case json.Number:
+				row[i] = string(v)
+			default:
+				row[i] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
@@ -0,0 +1,48 @@
+package goose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadDirective(t *testing.T) {
+	sql := `-- +goose Up
+CREATE TABLE countries (code text, name text);
+-- +goose Load fixtures/countries.csv INTO countries
+
+-- +goose Down
+DROP TABLE countries;
+`
+	stmts, lines, _, _ := parseSQLStatements(packageConfig(), strings.NewReader(sql), true)
+	if len(stmts) != 2 || !reflect.DeepEqual(lines, []int{2, 3}) {
+		t.Fatalf("unexpected statements %q at lines %v", stmts, lines)
+	}
+	load, ok := parseLoad(stmts[1])
+	if !ok || load != (loadDirective{file: "fixtures/countries.csv", table: "countries"}) {
+		t.Errorf("unexpected load directive %+v", load)
+	}
+}
+
+func TestReadFixtures(t *testing.T) {
+	columns, rows, err := readCSVFixture(strings.NewReader("code,name\nfr,France\nxx,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(columns, []string{"code", "name"}) || !reflect.DeepEqual(rows, [][]interface{}{{"fr", "France"}, {"xx", nil}}) {
+		t.Errorf("unexpected CSV fixture %v %v", columns, rows)
+	}
+
+	columns, rows, err = readJSONFixture(strings.NewReader(`[{"id": 1, "tags": ["a"]}, {"id": 2, "name": "b"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(columns, []string{"id", "name", "tags"}) || !reflect.DeepEqual(rows, [][]interface{}{{"1", nil, `["a"]`}, {"2", "b", nil}}) {
+		t.Errorf("unexpected JSON fixture %v %v", columns, rows)
+	}
+
+	query, args := insertBatchSQL(packageConfig(), "countries", []string{"code", "name"}, [][]interface{}{{"fr", "France"}, {"de", "Germany"}})
+	if query != "INSERT INTO countries (code, name) VALUES ($1, $2), ($3, $4)" || len(args) != 4 {
+		t.Errorf("unexpected insert %q %v", query, args)
+	}
+}
@@ -0,0 +1,78 @@
+package goose
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// baseFS is the filesystem migrations are read from, the local filesystem
+// when nil.
+var baseFS fs.FS
+
+// SetBaseFS sets the filesystem migrations are read from, e.g. an embed.FS
+// or a testing/fstest.MapFS. The migrations folder is then a path within
+// fsys. nil restores the local filesystem.
+//
+// Only reading migrations goes through fsys: commands writing files, such
+// as create or fix, still use the local filesystem.
+func SetBaseFS(fsys fs.FS) {
+	baseFS = fsys
+
+	// Parsed files are cached by path, which may now name another file.
+	parsedCacheMu.Lock()
+	parsedCache, parsedCacheBytes = map[parsedKey]*parsedSQL{}, 0
+	parsedCacheMu.Unlock()
+}
+
+// fsPath converts a path to the form fs.FS expects: slash-separated,
+// unrooted and without "." or ".." elements.
+func fsPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func statFile(name string) (fs.FileInfo, error) {
+	return packageConfig().statFile(name)
+}
+
+func openFile(name string) (fs.File, error) {
+	return packageConfig().openFile(name)
+}
+
+func readFile(name string) ([]byte, error) {
+	return packageConfig().readFile(name)
+}
+
+func globFiles(pattern string) ([]string, error) {
+	return packageConfig().globFiles(pattern)
+}
+
+func (c *config) statFile(name string) (fs.FileInfo, error) {
+	if c.fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(c.fsys, fsPath(name))
+}
+
+func (c *config) openFile(name string) (fs.File, error) {
+	if c.fsys == nil {
+		return os.Open(name)
+	}
+	return c.fsys.Open(fsPath(name))
+}
+
+func (c *config) readFile(name string) ([]byte, error) {
+	if c.fsys == nil {
+		return ioutil.ReadFile(name)
+	}
+	return fs.ReadFile(c.fsys, fsPath(name))
+}
+
+func (c *config) globFiles(pattern string) ([]string, error) {
+	if c.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	return fs.Glob(c.fsys, fsPath(pattern))
+}
@@ -1,10 +1,13 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"strconv"
 	"sync"
+	"time"
 )
 
 var (
@@ -15,13 +18,100 @@ var (
 
 // Run runs a goose command.
 func Run(command string, db *sql.DB, dir string, args ...string) error {
+	return RunWithOptions(command, db, dir, args)
+}
+
+// RunWithContext runs a goose command with ctx, which cancels the
+// migrations, sets their deadline and carries the parent span of its trace.
+func RunWithContext(ctx context.Context, command string, db *sql.DB, dir string, args ...string) error {
+	return runWithOptions(ctx, command, db, dir, args)
+}
+
+// RunWithOptions runs a goose command with additional options.
+func RunWithOptions(command string, db *sql.DB, dir string, args []string, opts ...OptionsFunc) error {
+	return runWithOptions(context.Background(), command, db, dir, args, opts...)
+}
+
+// RunWithOptionsContext runs a goose command with additional options, as
+// RunWithContext.
+func RunWithOptionsContext(ctx context.Context, command string, db *sql.DB, dir string, args []string, opts ...OptionsFunc) error {
+	return runWithOptions(ctx, command, db, dir, args, opts...)
+}
+
+func runWithOptions(ctx context.Context, command string, db *sql.DB, dir string, args []string, opts ...OptionsFunc) (err error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ctx, span := tracer.Start(ctx, "goose.run", map[string]interface{}{
+		"goose.command": command,
+		"goose.dir":     dir,
+	})
+	defer func() { endSpan(span, err) }()
+	ctx = withEventHandler(ctx, o.eventHandler)
+
+	// The settings are read once, a run isn't affected by later changes.
+	c := configFrom(ctx)
+	ctx = withConfig(ctx, c)
+
+	if c.dryRun && dryRunCommands[command] {
+		return printDryRun(ctx, os.Stdout, db, dir, command, args)
+	}
+
+	if migratingCommands[command] {
+		report := &runReport{command: command, started: time.Now()}
+		ctx = withReport(ctx, report)
+
+		defer func() {
+			if err == nil && c.strict && len(report.migrations) == 0 {
+				err = ErrNoChange
+			}
+		}()
+
+		if !o.noLock {
+			unlock, err := lockMigrations(ctx, db)
+			if err != nil {
+				return err
+			}
+			defer unlock()
+		}
+		if db != nil {
+			if err := upgradeVersionTable(ctx, db); err != nil {
+				c.log.Printf("WARNING: failed to upgrade %s: %v\n", c.tables.version(), err)
+			}
+		}
+
+		notifyRunStarted(report)
+		emit(ctx, RunStarted{Command: command, Time: report.started})
+		defer func() {
+			report.printSummary(c.log)
+			notifyRunFinished(report, err)
+			reportToSentry(command, err)
+			annotateGrafana(report, err)
+			sendRunMetrics(report, err)
+			emailRunFailure(report, err)
+			emit(ctx, RunFinished{Command: command, Migrations: report.migrations, Duration: time.Since(report.started), Err: err})
+		}()
+	}
+
 	switch command {
 	case "up":
-		if err := Up(db, dir); err != nil {
+		phase, err := parseUpArgs(args)
+		if err != nil {
+			return err
+		}
+		if phase != "" {
+			if err := upPhase(ctx, db, dir, phase); err != nil {
+				return err
+			}
+			break
+		}
+		if err := upTo(ctx, db, dir, maxVersion); err != nil {
 			return err
 		}
 	case "up-by-one":
-		if err := UpByOne(db, dir); err != nil {
+		if err := upByOne(ctx, db, dir); err != nil {
 			return err
 		}
 	case "up-to":
@@ -33,51 +123,138 @@ func Run(command string, db *sql.DB, dir string, args ...string) error {
 		if err != nil {
 			return fmt.Errorf("version must be a number (got '%s')", args[0])
 		}
-		if err := UpTo(db, dir, version); err != nil {
+		if err := upTo(ctx, db, dir, version); err != nil {
 			return err
 		}
 	case "create":
-		if len(args) == 0 {
-			return fmt.Errorf("create must be of form: goose [OPTIONS] DRIVER DBSTRING create NAME [go|sql]")
+		name, migrationType, opts, err := parseCreateArgs(args)
+		if err != nil {
+			return err
 		}
-
-		migrationType := "go"
-		if len(args) == 2 {
-			migrationType = args[1]
+		if err := createMigration(dir, nil, name, migrationType, opts); err != nil {
+			return err
 		}
-		if err := Create(db, dir, args[0], migrationType); err != nil {
+	case "create-from-table":
+		if _, err := CreateTableMigration(dir, db, args...); err != nil {
 			return err
 		}
 	case "down":
-		if err := Down(db, dir); err != nil {
+		if err := down(ctx, db, dir); err != nil {
 			return err
 		}
 	case "down-to":
 		if len(args) == 0 {
-			return fmt.Errorf("down-to must be of form: goose [OPTIONS] DRIVER DBSTRING down-to VERSION")
+			return fmt.Errorf("down-to must be of form: goose [OPTIONS] DRIVER DBSTRING down-to VERSION|TIME")
 		}
 
-		version, err := strconv.ParseInt(args[0], 10, 64)
+		version, err := parseDownTarget(args[0])
 		if err != nil {
-			return fmt.Errorf("version must be a number (got '%s')", args[0])
+			return err
 		}
-		if err := DownTo(db, dir, version); err != nil {
+		if err := downTo(ctx, db, dir, version); err != nil {
 			return err
 		}
 	case "redo":
-		if err := Redo(db, dir); err != nil {
+		if err := redo(ctx, db, dir); err != nil {
 			return err
 		}
 	case "reset":
-		if err := Reset(db, dir); err != nil {
+		if err := reset(ctx, db, dir); err != nil {
+			return err
+		}
+	case "validate":
+		if err := Validate(dir); err != nil {
+			return err
+		}
+	case "conflicts":
+		if err := Conflicts(db, dir); err != nil {
+			return err
+		}
+	case "fix":
+		if err := Fix(db, dir); err != nil {
+			return err
+		}
+	case "lock":
+		if err := WriteLock(dir); err != nil {
+			return err
+		}
+	case "verify-lock":
+		if err := VerifyLock(dir); err != nil {
+			return err
+		}
+	case "manifest":
+		if err := WriteManifest(dir); err != nil {
+			return err
+		}
+	case "script":
+		current, target, err := parseScriptArgs(args)
+		if err != nil {
+			return err
+		}
+		if err := Script(os.Stdout, dir, current, target); err != nil {
+			return err
+		}
+	case "status", "pending":
+		opts, err := parseStatusArgs(command, args)
+		if err != nil {
+			return err
+		}
+		if err := printStatus(ctx, db, dir, opts, command == "pending"); err != nil {
+			return err
+		}
+	case "import":
+		if err := importCommand(db, dir, args); err != nil {
+			return err
+		}
+	case "export":
+		if len(args) != 1 || args[0] != "golang-migrate" {
+			return fmt.Errorf("export must be of form: goose [OPTIONS] export golang-migrate")
+		}
+		if err := ExportToMigrate(db); err != nil {
+			return err
+		}
+	case "convert":
+		if err := convertCommand(dir, args); err != nil {
+			return err
+		}
+	case "exec":
+		if len(args) != 1 {
+			return fmt.Errorf("exec must be of form: goose [OPTIONS] DRIVER DBSTRING exec FILE")
+		}
+		if err := Exec(db, args[0]); err != nil {
+			return err
+		}
+	case "seed":
+		if err := seedCommand(db, args); err != nil {
+			return err
+		}
+	case "plan":
+		if err := printPlan(os.Stdout, db, dir, args); err != nil {
+			return err
+		}
+	case "apply":
+		plan := o.plan
+		if plan == nil {
+			if len(args) != 1 {
+				return fmt.Errorf("apply must be of form: goose [OPTIONS] apply PLAN")
+			}
+			if plan, err = ReadPlan(args[0]); err != nil {
+				return err
+			}
+		}
+		if err := applyPlan(ctx, db, dir, plan); err != nil {
+			return err
+		}
+	case "doc":
+		if err := doc(db, args); err != nil {
 			return err
 		}
-	case "status":
-		if err := Status(db, dir); err != nil {
+	case "snapshot":
+		if err := snapshot(db, dir, args); err != nil {
 			return err
 		}
 	case "version":
-		if err := Version(db, dir); err != nil {
+		if err := version(ctx, db); err != nil {
 			return err
 		}
 	default:
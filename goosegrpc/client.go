@@ -0,0 +1,92 @@
+package goosegrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gojuno/goose"
+	"github.com/gojuno/goose/goosegrpc/goosepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Client drives the migrations of a remote Migrator service.
+type Client struct {
+	c goosepb.MigratorClient
+}
+
+// NewClient returns a client of the Migrator service served on conn.
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{c: goosepb.NewMigratorClient(conn)}
+}
+
+// Status returns the version of the database and the status of the
+// migrations, only the pending ones with pendingOnly.
+func (c *Client) Status(ctx context.Context, pendingOnly bool) (int64, []goose.MigrationStatus, error) {
+	resp, err := c.c.Status(ctx, &goosepb.StatusRequest{PendingOnly: pendingOnly})
+	if err != nil {
+		return 0, nil, clientError(err)
+	}
+	statuses := make([]goose.MigrationStatus, 0, len(resp.Migrations))
+	for _, m := range resp.Migrations {
+		s := goose.MigrationStatus{Version: m.Version, Source: m.File, Applied: m.State == "applied"}
+		if m.AppliedAt != "" {
+			s.AppliedAt, _ = time.Parse(time.RFC3339, m.AppliedAt)
+		}
+		statuses = append(statuses, s)
+	}
+	return resp.CurrentVersion, statuses, nil
+}
+
+// Plan returns the migrations command would run: up, up-to, down or
+// down-to, target being the version of up-to and down-to.
+func (c *Client) Plan(ctx context.Context, command, target string) (*goose.Plan, error) {
+	p, err := c.c.Plan(ctx, &goosepb.PlanRequest{Command: command, Target: target})
+	if err != nil {
+		return nil, clientError(err)
+	}
+	return planFromProto(p), nil
+}
+
+// Apply runs plan, passing the events of the run to handler, if not nil, as
+// they happen.
+func (c *Client) Apply(ctx context.Context, plan *goose.Plan, handler func(goose.Event)) error {
+	stream, err := c.c.Apply(ctx, &goosepb.ApplyRequest{Plan: planToProto(plan)})
+	if err != nil {
+		return clientError(err)
+	}
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return clientError(err)
+		}
+		if ev := eventFromProto(e); ev != nil && handler != nil {
+			handler(ev)
+		}
+	}
+}
+
+// clientError turns the status codes of the server back into the errors of
+// goose: *goose.ValidationError, *goose.MigrationError and
+// goose.ErrNoChange. Other errors are returned as they are.
+func clientError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch s.Code() {
+	case codes.InvalidArgument:
+		return &goose.ValidationError{Err: errors.New(s.Message())}
+	case codes.Aborted:
+		return &goose.MigrationError{Err: errors.New(s.Message())}
+	case codes.AlreadyExists:
+		return goose.ErrNoChange
+	}
+	return err
+}
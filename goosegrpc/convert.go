@@ -0,0 +1,105 @@
+package goosegrpc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gojuno/goose"
+	"github.com/gojuno/goose/goosegrpc/goosepb"
+)
+
+func planToProto(plan *goose.Plan) *goosepb.MigrationPlan {
+	p := &goosepb.MigrationPlan{Command: plan.Command, CurrentVersion: plan.CurrentVersion, TargetVersion: plan.TargetVersion}
+	for _, m := range plan.Migrations {
+		p.Migrations = append(p.Migrations, &goosepb.PlannedMigration{
+			Version:       m.Version,
+			File:          m.File,
+			Direction:     m.Direction,
+			Go:            m.Go,
+			Phase:         m.Phase,
+			NoTransaction: m.NoTransaction,
+			Statements:    int32(m.Statements),
+			Sha256:        m.SHA256,
+		})
+	}
+	return p
+}
+
+func planFromProto(p *goosepb.MigrationPlan) *goose.Plan {
+	plan := &goose.Plan{Command: p.Command, CurrentVersion: p.CurrentVersion, TargetVersion: p.TargetVersion, Migrations: []goose.PlannedMigration{}}
+	for _, m := range p.Migrations {
+		plan.Migrations = append(plan.Migrations, goose.PlannedMigration{
+			Version:       m.Version,
+			File:          m.File,
+			Direction:     m.Direction,
+			Go:            m.Go,
+			Phase:         m.Phase,
+			NoTransaction: m.NoTransaction,
+			Statements:    int(m.Statements),
+			SHA256:        m.Sha256,
+		})
+	}
+	return plan
+}
+
+func appliedToProto(m goose.AppliedMigration) *goosepb.AppliedMigration {
+	return &goosepb.AppliedMigration{Version: m.Version, File: m.File, Direction: m.Direction, DurationNs: int64(m.Duration), RowsAffected: m.RowsAffected}
+}
+
+func appliedFromProto(m *goosepb.AppliedMigration) goose.AppliedMigration {
+	return goose.AppliedMigration{Version: m.Version, File: m.File, Direction: m.Direction, Duration: time.Duration(m.DurationNs), RowsAffected: m.RowsAffected}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func eventToProto(e goose.Event) *goosepb.Event {
+	switch e := e.(type) {
+	case goose.RunStarted:
+		return &goosepb.Event{Event: &goosepb.Event_RunStarted{RunStarted: &goosepb.RunStarted{Command: e.Command, Time: e.Time.UTC().Format(time.RFC3339Nano)}}}
+	case goose.MigrationApplied:
+		return &goosepb.Event{Event: &goosepb.Event_MigrationApplied{MigrationApplied: &goosepb.MigrationApplied{Migration: appliedToProto(e.AppliedMigration)}}}
+	case goose.MigrationProgress:
+		return &goosepb.Event{Event: &goosepb.Event_MigrationProgress{MigrationProgress: &goosepb.MigrationProgress{Version: e.Version, File: e.File, Statement: int32(e.Statement), Total: int32(e.Total)}}}
+	case goose.StatementFailed:
+		return &goosepb.Event{Event: &goosepb.Event_StatementFailed{StatementFailed: &goosepb.StatementFailed{Version: e.Version, File: e.File, Statement: int32(e.Statement), Line: int32(e.Line), Error: errorString(e.Err)}}}
+	case goose.RunFinished:
+		f := &goosepb.RunFinished{Command: e.Command, DurationNs: int64(e.Duration), Error: errorString(e.Err)}
+		for _, m := range e.Migrations {
+			f.Migrations = append(f.Migrations, appliedToProto(m))
+		}
+		return &goosepb.Event{Event: &goosepb.Event_RunFinished{RunFinished: f}}
+	}
+	return &goosepb.Event{}
+}
+
+func eventFromProto(e *goosepb.Event) goose.Event {
+	switch e := e.Event.(type) {
+	case *goosepb.Event_RunStarted:
+		t, _ := time.Parse(time.RFC3339Nano, e.RunStarted.Time)
+		return goose.RunStarted{Command: e.RunStarted.Command, Time: t}
+	case *goosepb.Event_MigrationApplied:
+		return goose.MigrationApplied{AppliedMigration: appliedFromProto(e.MigrationApplied.Migration)}
+	case *goosepb.Event_MigrationProgress:
+		p := e.MigrationProgress
+		return goose.MigrationProgress{Version: p.Version, File: p.File, Statement: int(p.Statement), Total: int(p.Total)}
+	case *goosepb.Event_StatementFailed:
+		f := e.StatementFailed
+		return goose.StatementFailed{Version: f.Version, File: f.File, Statement: int(f.Statement), Line: int(f.Line), Err: errors.New(f.Error)}
+	case *goosepb.Event_RunFinished:
+		f := e.RunFinished
+		finished := goose.RunFinished{Command: f.Command, Duration: time.Duration(f.DurationNs)}
+		for _, m := range f.Migrations {
+			finished.Migrations = append(finished.Migrations, appliedFromProto(m))
+		}
+		if f.Error != "" {
+			finished.Err = errors.New(f.Error)
+		}
+		return finished
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build duckdb
+// +build duckdb
+
+package goosegrpc
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gojuno/goose"
+	"github.com/gojuno/goose/goosegrpc/goosepb"
+	_ "github.com/marcboeker/go-duckdb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// applyStream is the server side of an Apply call, with the context of the
+// call.
+type applyStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events []*goosepb.Event
+}
+
+func (s *applyStream) Context() context.Context { return s.ctx }
+
+func (s *applyStream) Send(e *goosepb.Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestCanceledCalls(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := goose.SetDialect("duckdb"); err != nil {
+		t.Fatal(err)
+	}
+	defer goose.SetDialect("postgres")
+
+	s := NewServer(db, "../examples/sql-migrations")
+	plan, err := s.Plan(context.Background(), &goosepb.PlanRequest{Command: "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.Status(ctx, &goosepb.StatusRequest{}); status.Code(err) != codes.Canceled {
+		t.Errorf("Status: got %v, want codes.Canceled", err)
+	}
+	if _, err := s.Plan(ctx, &goosepb.PlanRequest{Command: "up"}); status.Code(err) != codes.Canceled {
+		t.Errorf("Plan: got %v, want codes.Canceled", err)
+	}
+	stream := &applyStream{ctx: ctx}
+	if err := s.Apply(&goosepb.ApplyRequest{Plan: plan}, stream); status.Code(err) != codes.Canceled {
+		t.Errorf("Apply: got %v, want codes.Canceled", err)
+	}
+	if v, err := goose.GetDBVersion(db); err != nil || v != 0 {
+		t.Errorf("got version %d, %v after a canceled Apply, want 0", v, err)
+	}
+}
@@ -0,0 +1,64 @@
+package goosegrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gojuno/goose"
+	"github.com/gojuno/goose/goosegrpc/goosepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestConversions(t *testing.T) {
+	plan := &goose.Plan{Command: "up", CurrentVersion: 1, TargetVersion: 3, Migrations: []goose.PlannedMigration{
+		{Version: 2, File: "00002_users.sql", Direction: "up", Phase: "expand", Statements: 2, SHA256: "abc"},
+		{Version: 3, File: "00003_seed.go", Direction: "up", Go: true, Phase: "contract", NoTransaction: true},
+	}}
+	if got := planFromProto(planToProto(plan)); !reflect.DeepEqual(got, plan) {
+		t.Errorf("plan round trip: got %+v, want %+v", got, plan)
+	}
+
+	events := []goose.Event{
+		goose.RunStarted{Command: "apply", Time: time.Date(2019, 5, 6, 8, 25, 27, 0, time.UTC)},
+		goose.MigrationApplied{AppliedMigration: goose.AppliedMigration{Version: 2, File: "00002_users.sql", Direction: "up", Duration: time.Second, RowsAffected: 3}},
+		goose.MigrationProgress{Version: 2, File: "00002_users.sql", Statement: 1, Total: 2},
+		goose.StatementFailed{Version: 3, File: "00003_seed.sql", Statement: 1, Line: 4, Err: errors.New("syntax error")},
+		goose.RunFinished{Command: "apply", Duration: time.Second, Err: errors.New("failed")},
+	}
+	for _, e := range events {
+		if got := eventFromProto(eventToProto(e)); !reflect.DeepEqual(got, e) {
+			t.Errorf("event round trip: got %#v, want %#v", got, e)
+		}
+	}
+}
+
+func TestApplyWithoutPlan(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	NewServer(nil, "../examples/sql-migrations").Register(gs)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := goosepb.NewMigratorClient(conn).Apply(context.Background(), &goosepb.ApplyRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	var validationErr *goose.ValidationError
+	if err = clientError(err); !errors.As(err, &validationErr) {
+		t.Errorf("got %v, want a validation error", err)
+	}
+}
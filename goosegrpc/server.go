@@ -0,0 +1,122 @@
+// Package goosegrpc serves goose migrations over gRPC, so that deploy
+// controllers can observe and run them remotely, and provides the client of
+// the service. The service is defined in goosepb/goose.proto.
+package goosegrpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/gojuno/goose"
+	"github.com/gojuno/goose/goosegrpc/goosepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Migrator service for the migrations of a folder.
+type Server struct {
+	goosepb.UnimplementedMigratorServer
+
+	db      *sql.DB
+	dir     string
+	running chan struct{} // holds a value while migrations run
+}
+
+// NewServer returns the Migrator service of the migrations of dir, applied
+// to db. Authentication is left to the interceptors of the gRPC server.
+func NewServer(db *sql.DB, dir string) *Server {
+	return &Server{db: db, dir: dir, running: make(chan struct{}, 1)}
+}
+
+// Register registers the Migrator service of s with a gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	goosepb.RegisterMigratorServer(gs, s)
+}
+
+// Status returns the status of the migrations.
+func (s *Server) Status(ctx context.Context, req *goosepb.StatusRequest) (*goosepb.StatusResponse, error) {
+	statuses, err := goose.GetStatusContext(ctx, s.db, s.dir)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	version, err := goose.GetDBVersionContext(ctx, s.db)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &goosepb.StatusResponse{CurrentVersion: version}
+	for _, st := range statuses {
+		if req.PendingOnly && st.Applied {
+			continue
+		}
+		m := &goosepb.MigrationStatus{Version: st.Version, File: filepath.Base(st.Source), State: st.State()}
+		if st.Applied {
+			m.AppliedAt = st.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		resp.Migrations = append(resp.Migrations, m)
+	}
+	return resp, nil
+}
+
+// Plan returns the migrations a command would run.
+func (s *Server) Plan(ctx context.Context, req *goosepb.PlanRequest) (*goosepb.MigrationPlan, error) {
+	var args []string
+	if req.Target != "" {
+		args = []string{req.Target}
+	}
+	plan, err := goose.GetPlanContext(ctx, s.db, s.dir, req.Command, args...)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return planToProto(plan), nil
+}
+
+// Apply runs a plan, streaming the events of the run. Only one plan runs at
+// a time, concurrent calls fail with codes.Unavailable.
+func (s *Server) Apply(req *goosepb.ApplyRequest, stream goosepb.Migrator_ApplyServer) error {
+	if req.Plan == nil {
+		return status.Error(codes.InvalidArgument, "missing plan")
+	}
+	select {
+	case s.running <- struct{}{}:
+		defer func() { <-s.running }()
+	default:
+		return status.Error(codes.Unavailable, "migrations are already running")
+	}
+
+	// Events are sent from the run, stopping at the first failed send.
+	// When the client goes away, the run stops before the next migration.
+	var sendErr error
+	err := goose.RunWithOptionsContext(stream.Context(), "apply", s.db, s.dir, nil, goose.WithPlan(planFromProto(req.Plan)), goose.WithEventHandler(func(e goose.Event) {
+		if sendErr == nil {
+			sendErr = stream.Send(eventToProto(e))
+		}
+	}))
+	if err != nil {
+		return statusError(err)
+	}
+	return sendErr
+}
+
+// statusError maps the errors of goose to gRPC status codes, for the client
+// to return typed errors.
+func statusError(err error) error {
+	var validationErr *goose.ValidationError
+	var migrationErr *goose.MigrationError
+	switch {
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &migrationErr):
+		// The client wraps the message in a MigrationError again.
+		return status.Error(codes.Aborted, migrationErr.Err.Error())
+	case errors.Is(err, goose.ErrNoChange):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
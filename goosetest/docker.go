@@ -0,0 +1,179 @@
+package goosetest
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gojuno/goose"
+
+	// Drivers of the databases started by the container helpers.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// Container is a database started in Docker, with all migrations applied.
+type Container struct {
+	ID  string
+	DSN string
+	DB  *sql.DB
+}
+
+// Close closes the database and removes the container.
+func (c *Container) Close() error {
+	c.DB.Close()
+	return docker("rm", "-f", "-v", c.ID)
+}
+
+type containerConfig struct {
+	image   string
+	timeout time.Duration
+}
+
+// ContainerOption configures a database container.
+type ContainerOption func(*containerConfig)
+
+// WithImage sets the Docker image of the database, e.g. postgres:13-alpine.
+func WithImage(image string) ContainerOption {
+	return func(c *containerConfig) { c.image = image }
+}
+
+// WithStartTimeout sets how long to wait for the database to accept
+// connections, one minute by default.
+func WithStartTimeout(d time.Duration) ContainerOption {
+	return func(c *containerConfig) { c.timeout = d }
+}
+
+// containerPassword is the password of the throwaway databases.
+const containerPassword = "goosetest"
+
+// StartPostgres starts a PostgreSQL container and applies the migrations of
+// fsys. It's meant for TestMain, where the container can be shared by the
+// tests of a package; call Close when done.
+func StartPostgres(fsys fs.FS, opts ...ContainerOption) (*Container, error) {
+	return startContainer(fsys, "postgres", "postgres:13", "5432", []string{"POSTGRES_PASSWORD=" + containerPassword},
+		func(hostPort string) string {
+			return fmt.Sprintf("postgres://postgres:%s@%s/postgres?sslmode=disable", containerPassword, hostPort)
+		}, opts)
+}
+
+// StartMySQL starts a MySQL container and applies the migrations of fsys.
+// It's meant for TestMain; call Close when done.
+func StartMySQL(fsys fs.FS, opts ...ContainerOption) (*Container, error) {
+	return startContainer(fsys, "mysql", "mysql:8", "3306", []string{"MYSQL_ROOT_PASSWORD=" + containerPassword, "MYSQL_DATABASE=goosetest"},
+		func(hostPort string) string {
+			return fmt.Sprintf("root:%s@tcp(%s)/goosetest?parseTime=true&multiStatements=true", containerPassword, hostPort)
+		}, opts)
+}
+
+// Postgres starts a PostgreSQL container for the test, skipping it when
+// Docker isn't available. The container is removed with the test.
+func Postgres(t testing.TB, fsys fs.FS, opts ...ContainerOption) *sql.DB {
+	t.Helper()
+	return testContainer(t, StartPostgres, fsys, opts)
+}
+
+// MySQL starts a MySQL container for the test, skipping it when Docker
+// isn't available. The container is removed with the test.
+func MySQL(t testing.TB, fsys fs.FS, opts ...ContainerOption) *sql.DB {
+	t.Helper()
+	return testContainer(t, StartMySQL, fsys, opts)
+}
+
+func testContainer(t testing.TB, start func(fs.FS, ...ContainerOption) (*Container, error), fsys fs.FS, opts []ContainerOption) *sql.DB {
+	t.Helper()
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("goosetest: docker is not available")
+	}
+	c, err := start(fsys, opts...)
+	if err != nil {
+		t.Fatalf("goosetest: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c.DB
+}
+
+func startContainer(fsys fs.FS, dialect, image, port string, env []string, dsn func(hostPort string) string, opts []ContainerOption) (*Container, error) {
+	cfg := containerConfig{image: image, timeout: time.Minute}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	dir, err := extractDir(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("extracting migrations: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"run", "-d", "-p", "127.0.0.1::" + port}
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+	id, err := dockerOutput(append(args, cfg.image)...)
+	if err != nil {
+		return nil, err
+	}
+	c := &Container{ID: id}
+
+	hostPort, err := dockerOutput("port", id, port+"/tcp")
+	if err == nil {
+		// Docker may list an IPv4 and an IPv6 binding.
+		hostPort = strings.SplitN(hostPort, "\n", 2)[0]
+		c.DSN = dsn(hostPort)
+		c.DB, err = sql.Open(dialect, c.DSN)
+	}
+	if err == nil {
+		err = waitReady(c.DB, cfg.timeout)
+	}
+	if err == nil {
+		if err = goose.SetDialect(dialect); err == nil {
+			err = goose.Up(c.DB, dir)
+		}
+	}
+	if err != nil {
+		if c.DB != nil {
+			c.DB.Close()
+		}
+		docker("rm", "-f", "-v", id)
+		return nil, err
+	}
+	return c, nil
+}
+
+// waitReady waits for the database in the container to accept connections.
+func waitReady(db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("database not ready after %v: %v", timeout, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func docker(args ...string) error {
+	_, err := dockerOutput(args...)
+	return err
+}
+
+func dockerOutput(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,181 @@
+// Package goosetest provides helpers giving application tests a database
+// migrated with goose.
+//
+//	func TestUsers(t *testing.T) {
+//		db := openTestDB(t)
+//		goosetest.ResetBetweenTests(t, db, os.DirFS("../db/migrations"), goosetest.Truncate)
+//		...
+//	}
+package goosetest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gojuno/goose"
+)
+
+// ResetStrategy tells how ResetBetweenTests restores the database.
+type ResetStrategy int
+
+const (
+	// Truncate empties every table but the goose version tables. It's fast, but
+	// doesn't undo schema changes made by the test.
+	Truncate ResetStrategy = iota
+	// DownUp rolls back all migrations and applies them again.
+	DownUp
+)
+
+// MigrateUp applies all the migrations of fsys to db, failing the test with
+// the goose error if a migration fails. The migrations are expected at the
+// root of fsys, use fs.Sub for a subdirectory of an embed.FS.
+func MigrateUp(t testing.TB, db *sql.DB, fsys fs.FS) {
+	t.Helper()
+
+	dir := extract(t, fsys)
+	if err := goose.Up(db, dir); err != nil {
+		t.Fatalf("goosetest: migrating up: %v", err)
+	}
+}
+
+// ResetBetweenTests migrates db up, and restores it with strategy once the
+// test and its subtests complete.
+func ResetBetweenTests(t testing.TB, db *sql.DB, fsys fs.FS, strategy ResetStrategy) {
+	t.Helper()
+
+	dir := extract(t, fsys)
+	if err := goose.Up(db, dir); err != nil {
+		t.Fatalf("goosetest: migrating up: %v", err)
+	}
+
+	t.Cleanup(func() {
+		var err error
+		switch strategy {
+		case Truncate:
+			err = truncateTables(db)
+		case DownUp:
+			if err = goose.Reset(db, dir); err == nil {
+				err = goose.Up(db, dir)
+			}
+		default:
+			err = fmt.Errorf("unknown reset strategy %d", strategy)
+		}
+		if err != nil {
+			t.Errorf("goosetest: resetting database: %v", err)
+		}
+	})
+}
+
+// extract copies the migrations to a temporary directory removed with the
+// test.
+func extract(t testing.TB, fsys fs.FS) string {
+	t.Helper()
+
+	dir, err := extractDir(fsys)
+	if err != nil {
+		t.Fatalf("goosetest: extracting migrations: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// extractDir copies the migrations at the root of fsys to a temporary
+// directory, as goose reads migrations from the file system.
+func extractDir(fsys fs.FS) (string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "", err
+	}
+	dir, err := ioutil.TempDir("", "goosetest")
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, e.Name())
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(dir, e.Name()), b, 0644)
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// truncateTables empties the tables of the current schema, except the goose
+// version table.
+func truncateTables(db *sql.DB) error {
+	ctx := context.Background()
+
+	var query string
+	mysql := false
+	switch goose.GetDialect().(type) {
+	case *goose.MySQLDialect, *goose.TiDBDialect:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'"
+		mysql = true
+	default:
+		query = "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()"
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return err
+		}
+		if !strings.HasPrefix(table, goose.TableName()) {
+			tables = append(tables, table)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(tables) == 0 {
+		return err
+	}
+
+	if !mysql {
+		_, err := db.ExecContext(ctx, "TRUNCATE TABLE "+strings.Join(quote(tables, `"`), ", ")+" RESTART IDENTITY CASCADE")
+		return err
+	}
+
+	// Foreign key checks are per session, all statements must share the
+	// connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+	for _, table := range quote(tables, "`") {
+		if _, err := conn.ExecContext(ctx, "TRUNCATE TABLE "+table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quote(names []string, q string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = q + strings.Replace(name, q, q+q, -1) + q
+	}
+	return quoted
+}
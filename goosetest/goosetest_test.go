@@ -0,0 +1,34 @@
+package goosetest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExtract(t *testing.T) {
+	fsys := fstest.MapFS{
+		"00001_users.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n")},
+		"testdata/ignored": {Data: []byte("ignored")},
+	}
+
+	dir := extract(t, fsys)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "00001_users.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != string(fsys["00001_users.sql"].Data) {
+		t.Errorf("incorrect migration %q", b)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("expected only the migration, got %v", files)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	got := quote([]string{"users", `we"ird`}, `"`)
+	if got[0] != `"users"` || got[1] != `"we""ird"` {
+		t.Errorf("incorrect quoting %v", got)
+	}
+}
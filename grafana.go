@@ -0,0 +1,100 @@
+package goose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GrafanaOptions configures the annotation posted to Grafana when a run
+// applies migrations.
+type GrafanaOptions struct {
+	// URL is the base URL of Grafana, e.g. https://grafana.example.com.
+	URL string
+	// Token is a service account token allowed to create annotations.
+	Token string
+	// Tags are added to the annotation, along with "goose" and the database
+	// name.
+	Tags []string
+}
+
+var grafana *GrafanaOptions
+
+// EnableGrafanaAnnotations posts an annotation to Grafana after every run
+// that applied or rolled back migrations, so that schema changes show up on
+// dashboards.
+func EnableGrafanaAnnotations(opts GrafanaOptions) {
+	opts.URL = strings.TrimSuffix(opts.URL, "/")
+	grafana = &opts
+}
+
+// grafanaAnnotation is the payload of Grafana's POST /api/annotations.
+type grafanaAnnotation struct {
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"timeEnd"`
+	Tags    []string `json:"tags"`
+	Text    string   `json:"text"`
+}
+
+// annotateGrafana posts the annotation of a finished run. Delivery failures
+// are logged, they never fail the run.
+func annotateGrafana(r *runReport, err error) {
+	if grafana == nil || len(r.migrations) == 0 {
+		return
+	}
+	if err := postGrafanaAnnotation(newGrafanaAnnotation(r, err)); err != nil {
+		log.Printf("goose: grafana: %v\n", err)
+	}
+}
+
+func newGrafanaAnnotation(r *runReport, err error) grafanaAnnotation {
+	tags := append([]string{"goose"}, grafana.Tags...)
+	if databaseName != "" {
+		tags = append(tags, databaseName)
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "goose %s: %d migrations", r.command, len(r.migrations))
+	if err != nil {
+		fmt.Fprintf(&text, ", failed: %v", err)
+	}
+	for _, m := range r.migrations {
+		fmt.Fprintf(&text, "\n%s (%s)", m.File, m.Direction)
+	}
+
+	return grafanaAnnotation{
+		Time:    r.started.UnixNano() / int64(time.Millisecond),
+		TimeEnd: time.Now().UnixNano() / int64(time.Millisecond),
+		Tags:    tags,
+		Text:    text.String(),
+	}
+}
+
+func postGrafanaAnnotation(a grafanaAnnotation) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	url := grafana.URL + "/api/annotations"
+	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if grafana.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+grafana.Token)
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// importedMigration is a migration applied by another migration tool.
+type importedMigration struct {
+	version int64
+	name    string // description, used to name the renamed file
+	script  string // file name in the migrations folder, if any
+}
+
+// Import marks the migrations applied by another tool as applied in
+// goose_db_version, without executing them, so that a database can switch
+// to goose without being reset. tool is "flyway", "liquibase" or
+// "golang-migrate".
+//
+// With rename, the tool's migration files found in dir are renamed to goose
+// conventions, with a "-- +goose Up" annotation added.
+func Import(db *sql.DB, dir, tool string, rename bool) error {
+	var migrations []importedMigration
+	var err error
+	switch tool {
+	case "flyway":
+		migrations, err = flywayMigrations(db)
+	case "liquibase":
+		migrations, err = liquibaseMigrations(db)
+	case "golang-migrate":
+		migrations, err = migrateMigrations(db, dir)
+	default:
+		return fmt.Errorf("%q: unknown tool, must be flyway, liquibase or golang-migrate", tool)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := markApplied(db, migrations); err != nil {
+		return err
+	}
+	if rename {
+		return renameImported(dir, migrations)
+	}
+	return nil
+}
+
+// importCommand implements the import command: import [-rename] TOOL.
+func importCommand(db *sql.DB, dir string, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	rename := fs.Bool("rename", false, "rename the migration files to goose conventions")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import must be of form: goose [OPTIONS] import [-rename] TOOL")
+	}
+	return Import(db, dir, fs.Arg(0), *rename)
+}
+
+// markApplied records the migrations not yet applied in goose_db_version.
+func markApplied(db *sql.DB, migrations []importedMigration) error {
+	versions := make([]int64, len(migrations))
+	for i, m := range migrations {
+		versions[i] = m.version
+	}
+	if _, err := EnsureDBVersion(db); err != nil && err != ErrNoNextVersion {
+		return err
+	}
+	applied := map[int64]bool{}
+	if len(versions) > 0 {
+		var err error
+		if applied, err = appliedVersions(context.Background(), db, versions...); err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if _, err := tx.Exec(GetDialect().insertVersionSQL(packageTables()), m.version, true); err != nil {
+			tx.Rollback()
+			return err
+		}
+		n++
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("goose: imported %d migrations, %d already recorded\n", n, len(migrations)-n)
+	return nil
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// renameImported renames the migration files to goose conventions, adding
+// the Up annotation goose requires.
+func renameImported(dir string, migrations []importedMigration) error {
+	for _, m := range migrations {
+		if m.script == "" {
+			continue
+		}
+		src := filepath.Join(dir, filepath.Base(m.script))
+		b, err := ioutil.ReadFile(src)
+		if os.IsNotExist(err) {
+			log.Printf("goose: %s: not found, skipping rename\n", src)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(m.name), "_"), "_")
+		dst := filepath.Join(dir, fmt.Sprintf("%05d_%s.sql", m.version, slug))
+		if _, err := os.Stat(dst); err == nil {
+			return fmt.Errorf("failed to rename %s: %s already exists", src, dst)
+		}
+
+		if !bytes.Contains(b, []byte(sqlCmdPrefix+"Up")) {
+			b = append([]byte(sqlCmdPrefix+"Up\n"), b...)
+		}
+		if err := ioutil.WriteFile(dst, b, 0644); err != nil {
+			return err
+		}
+		if err := os.Remove(src); err != nil {
+			return err
+		}
+		log.Printf("goose: renamed %s to %s\n", filepath.Base(src), filepath.Base(dst))
+	}
+	return nil
+}
+
+// flywayMigrations reads the successful versioned migrations of
+// flyway_schema_history. Integer Flyway versions are kept, otherwise
+// migrations are numbered in version order.
+func flywayMigrations(db *sql.DB) ([]importedMigration, error) {
+	rows, err := db.Query(`SELECT version, description, script FROM flyway_schema_history
+		WHERE success AND version IS NOT NULL AND type <> 'UNDO_SQL' ORDER BY installed_rank`)
+	if err != nil {
+		return nil, fmt.Errorf("reading flyway_schema_history: %v", err)
+	}
+	defer rows.Close()
+
+	type flywayMigration struct {
+		version []int64
+		importedMigration
+	}
+	var flyway []flywayMigration
+	integers := true
+	for rows.Next() {
+		var version, description, script string
+		if err := rows.Scan(&version, &description, &script); err != nil {
+			return nil, err
+		}
+		parts, err := parseFlywayVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		integers = integers && len(parts) == 1
+		flyway = append(flyway, flywayMigration{
+			version:           parts,
+			importedMigration: importedMigration{name: description, script: script},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(flyway, func(i, j int) bool {
+		a, b := flyway[i].version, flyway[j].version
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	migrations := make([]importedMigration, len(flyway))
+	for i, f := range flyway {
+		migrations[i] = f.importedMigration
+		migrations[i].version = int64(i + 1)
+		if integers {
+			migrations[i].version = f.version[0]
+		}
+		if migrations[i].version <= 0 {
+			return nil, fmt.Errorf("flyway version %d: goose versions must be greater than zero", f.version[0])
+		}
+	}
+	return migrations, nil
+}
+
+// parseFlywayVersion splits a Flyway version like 1.2.3 or 1_2 into its
+// numeric parts.
+func parseFlywayVersion(version string) ([]int64, error) {
+	var parts []int64
+	for _, p := range strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '_' }) {
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("flyway version %q: %v", version, err)
+		}
+		parts = append(parts, n)
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("flyway version %q: empty", version)
+	}
+	return parts, nil
+}
+
+// liquibaseMigrations reads the changesets applied according to
+// DATABASECHANGELOG, one goose version each. Changesets with unique integer
+// IDs keep them as versions, otherwise they're numbered in execution order.
+// Changesets live in changelogs rather than in their own file, so there's
+// nothing to rename.
+func liquibaseMigrations(db *sql.DB) ([]importedMigration, error) {
+	rows, err := db.Query(`SELECT id, author, filename, description FROM DATABASECHANGELOG
+		WHERE exectype IN ('EXECUTED', 'MARK_RAN', 'RERAN') ORDER BY orderexecuted`)
+	if err != nil {
+		return nil, fmt.Errorf("reading DATABASECHANGELOG: %v", err)
+	}
+	defer rows.Close()
+
+	var migrations []importedMigration
+	// A changeset is identified by its id, author and changelog, and is
+	// listed again when rerun.
+	seen := map[string]bool{}
+	ids := map[int64]bool{}
+	integers := true
+	for rows.Next() {
+		var id, author, filename string
+		var description sql.NullString
+		if err := rows.Scan(&id, &author, &filename, &description); err != nil {
+			return nil, err
+		}
+		key := id + "\x00" + author + "\x00" + filename
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || n <= 0 || ids[n] {
+			integers = false
+		}
+		ids[n] = true
+
+		name := id
+		if description.Valid && description.String != "" {
+			name = description.String
+		}
+		migrations = append(migrations, importedMigration{version: n, name: name})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !integers {
+		for i := range migrations {
+			migrations[i].version = int64(i + 1)
+		}
+	}
+	return migrations, nil
+}
@@ -0,0 +1,52 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFlywayVersion(t *testing.T) {
+	tests := map[string][]int64{
+		"1":     {1},
+		"1.2.3": {1, 2, 3},
+		"2_1":   {2, 1},
+	}
+	for version, want := range tests {
+		got, err := parseFlywayVersion(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", version, got, want)
+		}
+	}
+	if _, err := parseFlywayVersion("1.a"); err == nil {
+		t.Error("expected an error for 1.a")
+	}
+}
+
+func TestRenameImported(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "V1_1__Create users.sql"), []byte("CREATE TABLE users (id int);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = renameImported(dir, []importedMigration{{version: 2, name: "Create users", script: "db/migration/V1_1__Create users.sql"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "00002_create_users.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "-- +goose Up\nCREATE TABLE users (id int);\n" {
+		t.Errorf("incorrect migration %q", b)
+	}
+}
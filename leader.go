@@ -0,0 +1,67 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStartupTimeout is returned by MigrateOnStartup when the schema isn't
+// current before the timeout.
+var ErrStartupTimeout = errors.New("timed out waiting for migrations")
+
+// startupPollInterval is the delay between two checks of an instance waiting
+// for the leader.
+var startupPollInterval = time.Second
+
+// MigrateOnStartup applies the migrations of dir when several instances of a
+// service start at once, returning only when the schema is current. The
+// instance taking the migration lock, a PostgreSQL advisory lock or a MySQL
+// named lock, runs up; the others wait for the leader to finish, taking over
+// if it died, until timeout. The lock takes a connection of its own, db
+// mustn't be limited to one by SetMaxOpenConns. opts apply to the leader's
+// run:
+//
+//	if err := goose.MigrateOnStartup(ctx, db, "migrations", 5*time.Minute); err != nil {
+//		log.Fatalf("migrations: %v", err)
+//	}
+func MigrateOnStartup(ctx context.Context, db *sql.DB, dir string, timeout time.Duration, opts ...OptionsFunc) error {
+	if GetDialect().tryLockSQL(packageTables()) == "" {
+		return fmt.Errorf("%T doesn't support migration locks", GetDialect())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	unlock, err := holdMigrationLock(ctx, db, func(attempt int) (bool, error) {
+		// The version table may not exist until the leader creates it, so
+		// errors only mean the schema isn't current yet.
+		if ok, _, err := IsUpToDate(ctx, db, dir); err == nil && ok {
+			return true, nil
+		}
+		if attempt == 0 {
+			log.Println("goose: another instance is applying migrations, waiting")
+		}
+		return false, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ErrStartupTimeout
+		}
+		return err
+	}
+	if unlock == nil {
+		return nil
+	}
+	defer unlock()
+
+	// The timeout only bounds the wait, a running migration isn't
+	// interrupted.
+	err = RunWithOptions("up", db, dir, nil, append(opts, withoutMigrationLock())...)
+	if err == ErrNoChange {
+		return nil
+	}
+	return err
+}
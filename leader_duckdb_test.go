@@ -0,0 +1,153 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+// lockingDuckDB takes PostgreSQL advisory locks, which leaderConn emulates
+// on top of DuckDB.
+type lockingDuckDB struct {
+	DuckDBDialect
+}
+
+func (lockingDuckDB) tryLockSQL(t gooseTables) string { return PostgresDialect{}.tryLockSQL(t) }
+func (lockingDuckDB) unlockSQL(t gooseTables) string  { return PostgresDialect{}.unlockSQL(t) }
+
+// leaderConnector shares an advisory lock between the connections of a
+// DuckDB database, like lockDriver.
+type leaderConnector struct {
+	driver.Connector
+	mu     sync.Mutex
+	holder *leaderConn
+}
+
+func (c *leaderConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &leaderConn{Conn: conn, c: c}, nil
+}
+
+type leaderConn struct {
+	driver.Conn
+	c *leaderConnector
+}
+
+func (c *leaderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "pg_try_advisory_lock") {
+		c.c.mu.Lock()
+		defer c.c.mu.Unlock()
+		locked := c.c.holder == nil || c.c.holder == c
+		if locked {
+			c.c.holder = c
+		}
+		return &boolRows{value: locked}, nil
+	}
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *leaderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "pg_advisory_unlock") {
+		c.release()
+		return driver.RowsAffected(0), nil
+	}
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *leaderConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *leaderConn) CheckNamedValue(v *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+func (c *leaderConn) Close() error {
+	c.release()
+	return c.Conn.Close()
+}
+
+func (c *leaderConn) release() {
+	c.c.mu.Lock()
+	if c.c.holder == c {
+		c.c.holder = nil
+	}
+	c.c.mu.Unlock()
+}
+
+func TestMigrateOnStartupConcurrent(t *testing.T) {
+	connector, err := duckdb.NewConnector("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(&leaderConnector{Connector: connector})
+	defer db.Close()
+
+	RegisterDialect("duckdb-locking", &lockingDuckDB{})
+	defer delete(registeredDialects, "duckdb-locking")
+	if err := SetDialect("duckdb-locking"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDialect("postgres")
+
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n")},
+		"migrations/00002_email.sql": {Data: []byte("-- +goose Up\nALTER TABLE users ADD email text;\n")},
+	})
+	defer SetBaseFS(nil)
+
+	savedInterval := startupPollInterval
+	startupPollInterval = 10 * time.Millisecond
+	defer func() { startupPollInterval = savedInterval }()
+
+	// Another instance is running the migrations.
+	ctx := context.Background()
+	unlock, err := lockMigrations(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateOnStartup(ctx, db, "migrations", 50*time.Millisecond); err != ErrStartupTimeout {
+		t.Errorf("waiting for a leader that never finishes: got %v, want ErrStartupTimeout", err)
+	}
+
+	// Once it's gone, one of two starting instances applies the
+	// migrations and the other one waits for it.
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- MigrateOnStartup(ctx, db, "migrations", time.Minute) }()
+	}
+	time.Sleep(50 * time.Millisecond)
+	unlock()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("instance %d: %v", i, err)
+		}
+	}
+
+	var applied int
+	if err := db.QueryRow("SELECT count(*) FROM goose_db_version WHERE version_id > 0").Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != 2 {
+		t.Errorf("got %d applied migrations, want each of the 2 applied once", applied)
+	}
+	if ok, _, err := IsUpToDate(ctx, db, "migrations"); err != nil || !ok {
+		t.Errorf("IsUpToDate after startup = %v, %v; want true", ok, err)
+	}
+}
@@ -0,0 +1,139 @@
+package goose
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LockFile is the name of the lockfile in the migrations folder.
+const LockFile = "goose.lock"
+
+type lockEntry struct {
+	version int64
+	file    string
+	sum     string
+}
+
+// WriteLock records the version and SHA-256 of every migration in the
+// folder's goose.lock, that of a paired migration covering its down file.
+func WriteLock(dir string) error {
+	entries, err := lockEntries(packageConfig(), dir)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by goose lock. DO NOT EDIT.\n")
+	for _, e := range entries {
+		// Tab-separated, as file names may have spaces.
+		fmt.Fprintf(&buf, "%d\t%s\t%s\n", e.version, e.file, e.sum)
+	}
+
+	path := filepath.Join(dir, LockFile)
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote %s with %d migrations\n", path, len(entries))
+	return nil
+}
+
+// VerifyLock checks that the migrations in the folder are exactly the ones
+// recorded in goose.lock.
+func VerifyLock(dir string) error {
+	return verifyLock(packageConfig(), dir)
+}
+
+func verifyLock(c *config, dir string) error {
+	locked, err := readLock(c, filepath.Join(dir, LockFile))
+	if err != nil {
+		return err
+	}
+	entries, err := lockEntries(c, dir)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, e := range entries {
+		l, ok := locked[e.version]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s is not in %s", e.file, LockFile))
+		case l.file != e.file:
+			problems = append(problems, fmt.Sprintf("%s was renamed from %s", e.file, l.file))
+		case l.sum != e.sum:
+			problems = append(problems, fmt.Sprintf("%s has changed", e.file))
+		}
+		delete(locked, e.version)
+	}
+	for _, l := range locked {
+		problems = append(problems, fmt.Sprintf("%s is missing", l.file))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s verification failed:\n\t%s", LockFile, strings.Join(problems, "\n\t"))
+	}
+
+	c.log.Printf("goose: %s OK\n", LockFile)
+	return nil
+}
+
+func lockEntries(c *config, dir string) ([]lockEntry, error) {
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]lockEntry, 0, len(migrations))
+	for _, m := range migrations {
+		e := lockEntry{version: m.Version, file: filepath.Base(m.Source), sum: "-"}
+
+		// Registered Go migrations may have been compiled elsewhere.
+		path := filepath.Join(dir, e.file)
+		if _, err := c.statFile(path); err == nil {
+			if e.sum, err = migrationSHA256(c, path); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func readLock(c *config, path string) (map[int64]lockEntry, error) {
+	f, err := c.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := map[int64]lockEntry{}
+	scanner := newLineScanner(f)
+	defer scanner.release()
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(string(scanner.Bytes()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) == 1 {
+			// Lockfiles written before fields were tab-separated.
+			fields = strings.Fields(line)
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed line", path, n)
+		}
+		v, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		entries[v] = lockEntry{version: v, file: fields[1], sum: fields[2]}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,89 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, sql := range map[string]string{
+		"00001_create users.sql": "-- +goose Up\nCREATE TABLE users (id int);\n",
+		"00002_add_email.sql":    "-- +goose Up\nALTER TABLE users ADD email text;\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := WriteLock(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	locked, err := readLock(packageConfig(), filepath.Join(dir, LockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := lockEntries(packageConfig(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locked) != len(entries) {
+		t.Fatalf("read %d entries, want %d", len(locked), len(entries))
+	}
+	for _, e := range entries {
+		if locked[e.version] != e {
+			t.Errorf("read %+v, want %+v", locked[e.version], e)
+		}
+	}
+	if err := VerifyLock(dir); err != nil {
+		t.Errorf("VerifyLock: %v", err)
+	}
+}
+
+func TestVerifyLockDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, sql string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("00001_users.sql", "-- +goose Up\nCREATE TABLE users (id int);\n")
+	write("00002_email.sql", "-- +goose Up\nALTER TABLE users ADD email text;\n")
+	write("00003_phone.sql", "-- +goose Up\nALTER TABLE users ADD phone text;\n")
+	if err := WriteLock(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	write("00001_users.sql", "-- +goose Up\nCREATE TABLE users (id bigint);\n")
+	os.Rename(filepath.Join(dir, "00002_email.sql"), filepath.Join(dir, "00002_add_email.sql"))
+	os.Remove(filepath.Join(dir, "00003_phone.sql"))
+	write("00004_orders.sql", "-- +goose Up\nCREATE TABLE orders (id int);\n")
+
+	err = VerifyLock(dir)
+	if err == nil {
+		t.Fatal("expected a verification error")
+	}
+	for _, want := range []string{
+		"00001_users.sql has changed",
+		"00002_add_email.sql was renamed from 00002_email.sql",
+		"00003_phone.sql is missing",
+		"00004_orders.sql is not in goose.lock",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not report %q:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package goose
+
+import (
+	std "log"
+)
+
+var log Logger = &stdLogger{}
+
+// Logger is the interface used by goose for its output.
+type Logger interface {
+	Fatal(v ...interface{})
+	Fatalf(format string, v ...interface{})
+	Print(v ...interface{})
+	Println(v ...interface{})
+	Printf(format string, v ...interface{})
+}
+
+// SetLogger sets the logger for goose's output, which goes through Print,
+// Println and Printf. goose returns its errors and never calls Fatal or
+// Fatalf, which are kept for compatibility.
+func SetLogger(l Logger) {
+	log = l
+}
+
+type stdLogger struct{}
+
+func (*stdLogger) Fatal(v ...interface{})                 { std.Fatal(v...) }
+func (*stdLogger) Fatalf(format string, v ...interface{}) { std.Fatalf(format, v...) }
+func (*stdLogger) Print(v ...interface{})                 { std.Print(v...) }
+func (*stdLogger) Println(v ...interface{})               { std.Println(v...) }
+func (*stdLogger) Printf(format string, v ...interface{}) { std.Printf(format, v...) }
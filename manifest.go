@@ -0,0 +1,192 @@
+package goose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ManifestFile is the name of the optional manifest in the migrations folder.
+// When present, it is used instead of globbing the folder.
+const ManifestFile = "migrations.yaml"
+
+// Manifest lists the migrations of a folder in order.
+type Manifest struct {
+	Migrations []ManifestEntry `yaml:"migrations"`
+}
+
+// ManifestEntry is a single migration file, relative to the migrations
+// folder. SHA256 is optional; when set, the file must match it. That of a
+// paired migration covers both its files, see migrationSHA256.
+type ManifestEntry struct {
+	File   string `yaml:"file"`
+	SHA256 string `yaml:"sha256,omitempty"`
+}
+
+// ReadManifest reads the manifest of the migrations folder.
+// It returns nil if the folder has no manifest.
+func ReadManifest(dir string) (*Manifest, error) {
+	return readManifest(packageConfig(), dir)
+}
+
+func readManifest(c *config, dir string) (*Manifest, error) {
+	b, err := c.readFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(b)
+}
+
+func parseManifest(b []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", ManifestFile, err)
+	}
+	return &m, nil
+}
+
+// WriteManifest generates the manifest of the migrations folder, recording
+// the checksum of every migration file.
+func WriteManifest(dir string) error {
+	migrations, err := collectDirMigrations(packageConfig(), dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	sort.Sort(migrations)
+
+	var m Manifest
+	for _, migration := range migrations {
+		entry := ManifestEntry{File: filepath.Base(migration.Source)}
+
+		path := filepath.Join(dir, entry.File)
+		if _, err := os.Stat(path); err == nil {
+			if entry.SHA256, err = migrationSHA256(packageConfig(), path); err != nil {
+				return err
+			}
+		}
+		m.Migrations = append(m.Migrations, entry)
+	}
+
+	b, err := yaml.Marshal(&m)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, ManifestFile)
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote %s with %d migrations\n", path, len(m.Migrations))
+	return nil
+}
+
+// collect returns the migrations listed in the manifest, verifying their
+// order. Their checksums are verified before a run applies or rolls them
+// back, or by Validate.
+func (m *Manifest) collect(c *config, dir string, current, target int64) (Migrations, error) {
+	var migrations Migrations
+
+	listed := map[int64]bool{}
+	prev := int64(0)
+	for _, entry := range m.Migrations {
+		v, err := NumericComponent(entry.File)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", ManifestFile, entry.File, err)
+		}
+		if v <= prev {
+			return nil, fmt.Errorf("%s: %s is listed out of order", ManifestFile, entry.File)
+		}
+		prev = v
+		listed[v] = true
+
+		if !versionFilter(v, current, target) {
+			continue
+		}
+		path := filepath.Join(dir, entry.File)
+		if registered, ok := registeredGoMigrations[v]; ok {
+			if entry.SHA256 != "" {
+				if err := verifyChecksum(c, path, entry.SHA256); err != nil {
+					return nil, err
+				}
+			}
+			migrations = append(migrations, registered)
+			continue
+		}
+		if _, err := c.statFile(path); err != nil {
+			return nil, fmt.Errorf("%s: %v", ManifestFile, err)
+		}
+		// A signed manifest only vouches for the files it has checksums
+		// of. Go migrations are compiled in, not read from the folder.
+		if signatureVerifier != nil && entry.SHA256 == "" {
+			return nil, fmt.Errorf("%s: %s has no sha256, required by signature verification", ManifestFile, entry.File)
+		}
+		// The checksum is verified when the migration runs, so that
+		// collecting thousands of migrations doesn't read them all.
+		migrations = append(migrations, &Migration{Version: v, Next: -1, Previous: -1, Source: path, sha256: entry.SHA256})
+	}
+
+	for v, registered := range registeredGoMigrations {
+		if !listed[v] {
+			return nil, fmt.Errorf("%s: registered Go migration %s is not listed", ManifestFile, filepath.Base(registered.Source))
+		}
+	}
+
+	return migrations, nil
+}
+
+// verifyChecksum checks the SHA-256 of a migration listed in the manifest.
+func verifyChecksum(c *config, path, sum string) error {
+	actual, err := migrationSHA256(c, path)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ManifestFile, err)
+	}
+	if actual != sum {
+		return fmt.Errorf("%s: checksum mismatch for %s", ManifestFile, filepath.Base(path))
+	}
+	return nil
+}
+
+func fileSHA256(c *config, path string) (string, error) {
+	h := sha256.New()
+	if err := hashFile(c, h, path); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// migrationSHA256 returns the SHA-256 of a migration file. That of a paired
+// migration covers its down file, hashed after the up file.
+func migrationSHA256(c *config, path string) (string, error) {
+	if !isPairedMigration(path) {
+		return fileSHA256(c, path)
+	}
+	h := sha256.New()
+	for _, p := range []string{path, pairedDownFile(path)} {
+		if err := hashFile(c, h, p); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(c *config, h hash.Hash, path string) error {
+	f, err := c.openFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(h, f)
+	return err
+}
@@ -0,0 +1,41 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpVerifiesChecksumsFirst(t *testing.T) {
+	db := openDuckDB(t)
+	dir, err := ioutil.TempDir("", "goose-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, sql string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("00001_users.sql", "-- +goose Up\nCREATE TABLE users (id int);\n")
+	write("00002_email.sql", "-- +goose Up\nALTER TABLE users ADD email text;\n")
+	if err := WriteManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	write("00002_email.sql", "-- +goose Up\nALTER TABLE users ADD email varchar;\n")
+
+	// The tampered migration is found before the first one is applied.
+	if err := Up(db, dir); err == nil || !strings.Contains(err.Error(), "checksum mismatch for 00002_email.sql") {
+		t.Fatalf("got %v, want a checksum mismatch", err)
+	}
+	if v, err := GetDBVersion(db); err != nil || v != 0 {
+		t.Errorf("got version %d, %v, want 0", v, err)
+	}
+}
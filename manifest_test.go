@@ -0,0 +1,40 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestChecksums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "00001_users.sql")
+	if err := ioutil.WriteFile(path, []byte("-- +goose Up\nCREATE TABLE users (id int);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte("-- +goose Up\nCREATE TABLE users (id bigint);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Collecting doesn't read the files, running or validating them does.
+	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		t.Fatalf("CollectMigrations: %v", err)
+	}
+	if err := migrations[0].verifyChecksum(packageConfig()); err == nil || !strings.Contains(err.Error(), "checksum mismatch for 00001_users.sql") {
+		t.Errorf("verifyChecksum: got %v", err)
+	}
+	if err := Validate(dir); err == nil {
+		t.Error("Validate: expected a checksum mismatch")
+	}
+}
@@ -0,0 +1,172 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// meta is the metadata table, recording the version of the schema of
+// goose's own tables, so that databases created by older goose releases are
+// upgraded to the tables the current release expects.
+func (t gooseTables) meta() string {
+	return t.version() + "_meta"
+}
+
+// metadataUpgrade is a change of the schema of goose's tables. Upgrades must
+// be safe to run again on a database they were already applied to, as two
+// goose processes may upgrade the same database at once.
+type metadataUpgrade struct {
+	description string
+	apply       func(ctx context.Context, db *sql.DB) error
+}
+
+// metadataUpgrades are applied in order; the metadata schema version of a
+// database is the number of upgrades it has. Append new upgrades, never
+// reorder or remove them.
+var metadataUpgrades = []metadataUpgrade{
+	{"index goose_db_version on version_id", addVersionIndex},
+	{"add goose_db_version.down_sql, storing the Down SQL of applied migrations", addDownSQLColumn},
+}
+
+// metadataVersion is the metadata schema version of the current release.
+func metadataVersion() int {
+	return len(metadataUpgrades)
+}
+
+// upgradedDBs records the version tables upgraded by upgradeVersionTable.
+var upgradedDBs sync.Map
+
+// dbTable identifies a goose table of a database handle, for the tables
+// upgraded or created once per handle. Handles are weakly referenced, and
+// their entries are dropped once they are garbage collected, so that the
+// handles opened for a single run, e.g. by RunTenants, aren't retained.
+type dbTable struct {
+	db    weak.Pointer[sql.DB]
+	table string
+}
+
+// markTable records table of db in tables, until db is garbage collected.
+func markTable(tables *sync.Map, db *sql.DB, table string) {
+	key := dbTable{weak.Make(db), table}
+	if _, loaded := tables.LoadOrStore(key, true); !loaded {
+		runtime.AddCleanup(db, func(key dbTable) { tables.Delete(key) }, key)
+	}
+}
+
+// tableMarked reports whether table of db is recorded in tables.
+func tableMarked(tables *sync.Map, db *sql.DB, table string) bool {
+	_, ok := tables.Load(dbTable{weak.Make(db), table})
+	return ok
+}
+
+// upgradeVersionTable brings the goose tables of databases created by older
+// goose releases up to date, once per database handle, by applying the
+// upgrades newer than their metadata schema version. A missing
+// version table is left to createVersionTable. It runs at the start of the
+// migrating commands, under their migration lock, so that read-only
+// commands never change the database. A failed upgrade is retried on the
+// next call.
+func upgradeVersionTable(ctx context.Context, db *sql.DB) error {
+	c := configFrom(ctx)
+	table := c.tables.version()
+	if tableMarked(&upgradedDBs, db, table) {
+		return nil
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE 1 = 0", table)).Scan(&count); err != nil {
+		// No table yet.
+		return nil
+	}
+
+	current, err := readMetadataVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current > metadataVersion() {
+		c.log.Printf("WARNING: the goose tables were upgraded by a newer goose release (metadata schema version %d, this release knows %d)\n", current, metadataVersion())
+		markTable(&upgradedDBs, db, table)
+		return nil
+	}
+
+	for v := current + 1; v <= metadataVersion(); v++ {
+		u := metadataUpgrades[v-1]
+		if err := u.apply(ctx, db); err != nil {
+			return fmt.Errorf("metadata schema version %d (%s): %v", v, u.description, err)
+		}
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET schema_version = %s", c.tables.meta(), c.placeholder(1)), v); err != nil {
+			return err
+		}
+		c.log.Printf("goose: upgraded the goose tables to metadata schema version %d: %s\n", v, u.description)
+	}
+	markTable(&upgradedDBs, db, table)
+	return nil
+}
+
+// readMetadataVersion returns the metadata schema version of db, creating the
+// metadata table at version 0 for databases created before it existed.
+func readMetadataVersion(ctx context.Context, db *sql.DB) (int, error) {
+	table := configFrom(ctx).tables.meta()
+	var v int
+	err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT schema_version FROM %s", table)).Scan(&v)
+	if err == nil {
+		return v, nil
+	}
+	if err != sql.ErrNoRows {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (schema_version integer NOT NULL)", table)); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (schema_version) VALUES (0)", table)); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// createMetadataTable creates the metadata table of a new goose_db_version
+// table, at the current metadata schema version.
+func createMetadataTable(ctx context.Context, txn *sql.Tx) error {
+	table := configFrom(ctx).tables.meta()
+	if _, err := txn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (schema_version integer NOT NULL)", table)); err != nil {
+		return err
+	}
+	_, err := txn.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (schema_version) VALUES (%d)", table, metadataVersion()))
+	return err
+}
+
+// addVersionIndex indexes the version table on version_id, unless the dialect
+// has no indexes or the index exists.
+func addVersionIndex(ctx context.Context, db *sql.DB) error {
+	c := configFrom(ctx)
+	q := c.dialect.versionIndexQuery(c.tables)
+	if q == "" {
+		return nil
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, q).Scan(&n); err != nil || n > 0 {
+		return err
+	}
+	_, err := db.ExecContext(ctx, c.dialect.createVersionIndexSQL(c.tables))
+	return err
+}
+
+// addDownSQLColumn adds the down_sql column to the version table, unless it
+// exists.
+func addDownSQLColumn(ctx context.Context, db *sql.DB) error {
+	if hasDownSQLColumn(ctx, db) {
+		return nil
+	}
+	c := configFrom(ctx)
+	_, err := db.ExecContext(ctx, c.dialect.addDownSQLColumnSQL(c.tables))
+	return err
+}
+
+// hasDownSQLColumn reports whether the version table has the down_sql column.
+func hasDownSQLColumn(ctx context.Context, db *sql.DB) bool {
+	var count int
+	return db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE down_sql IS NULL AND 1 = 0", configFrom(ctx).tables.version())).Scan(&count) == nil
+}
@@ -0,0 +1,114 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUpgradeVersionTable(t *testing.T) {
+	db := openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id int);\n")},
+	})
+	defer SetBaseFS(nil)
+
+	// A version table of a release without goose_db_version_meta.
+	for _, q := range []string{
+		"CREATE SEQUENCE goose_db_version_id",
+		"CREATE TABLE goose_db_version (id integer NOT NULL default nextval('goose_db_version_id'), version_id bigint NOT NULL, is_applied boolean NOT NULL, tstamp timestamp NULL default current_timestamp, PRIMARY KEY(id))",
+		"INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, true)",
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schemaVersion := func() int {
+		var v int
+		if err := db.QueryRow("SELECT schema_version FROM goose_db_version_meta").Scan(&v); err != nil {
+			return -1
+		}
+		return v
+	}
+
+	// Read-only commands leave the tables alone.
+	if _, err := GetDBVersion(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := RunWithContext(context.Background(), "status", db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if v := schemaVersion(); v != -1 {
+		t.Errorf("read-only commands upgraded the tables to %d", v)
+	}
+
+	if err := RunWithContext(context.Background(), "up", db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if v := schemaVersion(); v != metadataVersion() {
+		t.Errorf("got metadata schema version %d after up, want %d", v, metadataVersion())
+	}
+	if _, err := db.Exec("SELECT down_sql FROM goose_db_version"); err != nil {
+		t.Errorf("down_sql column not added: %v", err)
+	}
+}
+
+func TestUpgradeVersionTableRetried(t *testing.T) {
+	db := openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id int);\n")},
+	})
+	defer SetBaseFS(nil)
+	if _, err := EnsureDBVersion(db); err != nil {
+		t.Fatal(err)
+	}
+	defer func(upgrades []metadataUpgrade) { metadataUpgrades = upgrades }(metadataUpgrades)
+	failures := 1
+	metadataUpgrades = append(metadataUpgrades[:len(metadataUpgrades):len(metadataUpgrades)], metadataUpgrade{"fail once", func(context.Context, *sql.DB) error {
+		if failures > 0 {
+			failures--
+			return errors.New("permission denied")
+		}
+		return nil
+	}})
+
+	schemaVersion := func() int {
+		var v int
+		if err := db.QueryRow("SELECT schema_version FROM goose_db_version_meta").Scan(&v); err != nil {
+			return -1
+		}
+		return v
+	}
+
+	// A failed upgrade only warns, the run goes on.
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+	if err := RunWithContext(context.Background(), "up", db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(logger.lines, "\n"), fmt.Sprintf("WARNING: failed to upgrade goose_db_version: metadata schema version %d (fail once): permission denied", metadataVersion())) {
+		t.Errorf("got log %q, want a warning about the failed upgrade", logger.lines)
+	}
+	if v := schemaVersion(); v != metadataVersion()-1 {
+		t.Errorf("got metadata schema version %d after the failed upgrade, want %d", v, metadataVersion()-1)
+	}
+
+	// The next run retries it.
+	if err := RunWithContext(context.Background(), "up", db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	if v := schemaVersion(); v != metadataVersion() {
+		t.Errorf("got metadata schema version %d after the retry, want %d", v, metadataVersion())
+	}
+	if v, err := GetDBVersion(db); err != nil || v != 1 {
+		t.Errorf("got version %d, %v; want 1", v, err)
+	}
+}
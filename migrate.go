@@ -1,14 +1,15 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
 )
 
 var (
@@ -25,15 +26,11 @@ var (
 // Migrations slice.
 type Migrations []*Migration
 
-// helpers so we can use pkg sort
-func (ms Migrations) Len() int      { return len(ms) }
-func (ms Migrations) Swap(i, j int) { ms[i], ms[j] = ms[j], ms[i] }
-func (ms Migrations) Less(i, j int) bool {
-	if ms[i].Version == ms[j].Version {
-		log.Fatalf("goose: duplicate version %v detected:\n%v\n%v", ms[i].Version, ms[i].Source, ms[j].Source)
-	}
-	return ms[i].Version < ms[j].Version
-}
+// helpers so we can use pkg sort; duplicate versions are rejected by
+// validateMigrationNames before sorting.
+func (ms Migrations) Len() int           { return len(ms) }
+func (ms Migrations) Swap(i, j int)      { ms[i], ms[j] = ms[j], ms[i] }
+func (ms Migrations) Less(i, j int) bool { return ms[i].Version < ms[j].Version }
 
 // Current gets the current migration.
 func (ms Migrations) Current(current int64) (*Migration, error) {
@@ -85,16 +82,55 @@ func (ms Migrations) String() string {
 	return str
 }
 
-// AddMigration adds a migration.
+// AddMigration registers a Go migration, versioned by the name of the file
+// calling it, e.g. 00002_rename_root.go. It is called from the init function
+// of the migration file, and runs interleaved with the SQL migrations by
+// version.
 func AddMigration(up func(*sql.Tx) error, down func(*sql.Tx) error) {
 	_, filename, _, _ := runtime.Caller(1)
 	AddNamedMigration(filename, up, down)
 }
 
-// AddNamedMigration : Add a named migration.
+// AddNamedMigration registers a Go migration versioned by filename. It panics
+// if filename has no valid version or the version is already registered.
 func AddNamedMigration(filename string, up func(*sql.Tx) error, down func(*sql.Tx) error) {
-	v, _ := NumericComponent(filename)
-	migration := &Migration{Version: v, Next: -1, Previous: -1, Registered: true, UpFn: up, DownFn: down, Source: filename}
+	addGoMigration(&Migration{Source: filename, UpFn: up, DownFn: down})
+}
+
+// AddMigrationContext registers a Go migration like AddMigration, whose
+// functions receive the context of the run, canceled when it is.
+func AddMigrationContext(up, down func(context.Context, *sql.Tx) error) {
+	_, filename, _, _ := runtime.Caller(1)
+	AddNamedMigrationContext(filename, up, down)
+}
+
+// AddNamedMigrationContext registers a Go migration like AddNamedMigration,
+// whose functions receive the context of the run.
+func AddNamedMigrationContext(filename string, up, down func(context.Context, *sql.Tx) error) {
+	addGoMigration(&Migration{Source: filename, UpFnContext: up, DownFnContext: down})
+}
+
+// AddMigrationNoTxContext registers a Go migration run outside of a
+// transaction, e.g. to create indexes concurrently or to backfill a big
+// table in batches. Its version is recorded once its function returns.
+func AddMigrationNoTxContext(up, down func(context.Context, *sql.DB) error) {
+	_, filename, _, _ := runtime.Caller(1)
+	AddNamedMigrationNoTxContext(filename, up, down)
+}
+
+// AddNamedMigrationNoTxContext registers a Go migration like
+// AddMigrationNoTxContext, versioned by filename.
+func AddNamedMigrationNoTxContext(filename string, up, down func(context.Context, *sql.DB) error) {
+	addGoMigration(&Migration{Source: filename, UpFnNoTx: up, DownFnNoTx: down, NoTx: true})
+}
+
+func addGoMigration(migration *Migration) {
+	filename := migration.Source
+	v, err := NumericComponent(filename)
+	if err != nil {
+		panic(fmt.Sprintf("failed to add migration %q: %v", filename, err))
+	}
+	migration.Version, migration.Next, migration.Previous, migration.Registered = v, -1, -1, true
 
 	if existing, ok := registeredGoMigrations[v]; ok {
 		panic(fmt.Sprintf("failed to add migration %q: version conflicts with %q", filename, existing.Source))
@@ -105,22 +141,97 @@ func AddNamedMigration(filename string, up func(*sql.Tx) error, down func(*sql.T
 
 // CollectMigrations returns all the valid looking migration scripts in the
 // migrations folder and go func registry, and key them by version.
+// Errors are reported as *ValidationError.
 func CollectMigrations(dirpath string, current, target int64) (Migrations, error) {
-	if _, err := os.Stat(dirpath); os.IsNotExist(err) {
+	return collectMigrations(packageConfig(), dirpath, current, target)
+}
+
+// collectMigrations is CollectMigrations with the settings of c.
+func collectMigrations(c *config, dirpath string, current, target int64) (Migrations, error) {
+	migrations, err := readMigrations(c, dirpath, current, target)
+	if err != nil {
+		if _, ok := err.(*ValidationError); ok {
+			return nil, err
+		}
+		return nil, &ValidationError{Err: err}
+	}
+	return migrations, nil
+}
+
+func readMigrations(c *config, dirpath string, current, target int64) (Migrations, error) {
+	if dirpath == StreamDir {
+		if signatureVerifier != nil {
+			return nil, fmt.Errorf("signature verification requires a signed %s, which migration streams don't have", ManifestFile)
+		}
+		migrations, err := collectStreamMigrations(current, target)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateMigrationNames(migrations); err != nil {
+			return nil, err
+		}
+		return sortAndConnectMigrations(migrations), nil
+	}
+
+	if _, err := c.statFile(dirpath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("%s directory does not exists", dirpath)
 	}
 
+	manifest, err := readSignedManifest(c, dirpath)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations Migrations
+	if manifest != nil {
+		migrations, err = manifest.collect(c, dirpath, current, target)
+	} else {
+		migrations, err = collectDirMigrations(c, dirpath, current, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMigrationNames(migrations); err != nil {
+		return nil, err
+	}
+
+	migrations = sortAndConnectMigrations(migrations)
+
+	return migrations, nil
+}
+
+// collectDirMigrations globs the migrations folder and adds the Go migrations
+// registered via goose.AddMigration().
+func collectDirMigrations(c *config, dirpath string, current, target int64) (Migrations, error) {
 	var migrations Migrations
 
-	// SQL migration files.
-	sqlMigrationFiles, err := filepath.Glob(dirpath + "/**.sql")
+	// SQL migration files, optionally gzip-compressed.
+	sqlMigrationFiles, err := c.globFiles(dirpath + "/**.sql")
 	if err != nil {
 		return nil, err
 	}
+	gzMigrationFiles, err := c.globFiles(dirpath + "/**.sql.gz")
+	if err != nil {
+		return nil, err
+	}
+	sqlMigrationFiles = append(sqlMigrationFiles, gzMigrationFiles...)
 	for _, file := range sqlMigrationFiles {
+		// The down file of a paired migration goes with its up file.
+		if strings.HasSuffix(file, migrateDownExt) {
+			up := strings.TrimSuffix(file, migrateDownExt) + migrateUpExt
+			if _, err := c.statFile(up); err != nil {
+				return nil, &ValidationError{File: file, Err: fmt.Errorf("%s: no matching %s file", filepath.Base(file), migrateUpExt)}
+			}
+			continue
+		}
+		if isPairedMigration(file) {
+			if _, err := c.statFile(pairedDownFile(file)); err != nil {
+				return nil, &ValidationError{File: file, Err: fmt.Errorf("%s: no matching %s file", filepath.Base(file), migrateDownExt)}
+			}
+		}
 		v, err := NumericComponent(file)
 		if err != nil {
-			return nil, err
+			return nil, &ValidationError{File: file, Err: fmt.Errorf("%s: %v", filepath.Base(file), err)}
 		}
 		if versionFilter(v, current, target) {
 			migration := &Migration{Version: v, Next: -1, Previous: -1, Source: file}
@@ -140,7 +251,7 @@ func CollectMigrations(dirpath string, current, target int64) (Migrations, error
 	}
 
 	// Go migration files
-	goMigrationFiles, err := filepath.Glob(dirpath + "/**.go")
+	goMigrationFiles, err := c.globFiles(dirpath + "/**.go")
 	if err != nil {
 		return nil, err
 	}
@@ -161,8 +272,6 @@ func CollectMigrations(dirpath string, current, target int64) (Migrations, error
 		}
 	}
 
-	migrations = sortAndConnectMigrations(migrations)
-
 	return migrations, nil
 }
 
@@ -196,70 +305,108 @@ func versionFilter(v, current, target int64) bool {
 	return false
 }
 
+// versionPageSize is the number of history rows EnsureDBVersion reads at
+// once, the current version usually being in the first page.
+const versionPageSize = 100
+
 // EnsureDBVersion retrieves the current version for this DB.
 // Create and initialize the DB version table if it doesn't exist.
 func EnsureDBVersion(db *sql.DB) (int64, error) {
-	rows, err := GetDialect().dbVersionQuery(db)
-	if err != nil {
-		return 0, createVersionTable(db)
-	}
-	defer rows.Close()
+	return ensureDBVersion(context.Background(), db)
+}
 
+func ensureDBVersion(ctx context.Context, db *sql.DB) (int64, error) {
 	// The most recent record for each migration specifies
 	// whether it has been applied or rolled back.
 	// The first version we find that has been applied is the current version.
+	// The history is read from the most recent record by pages, so that long
+	// histories aren't read entirely.
 
-	toSkip := make([]int64, 0)
-
-	for rows.Next() {
-		var row MigrationRecord
-		if err = rows.Scan(&row.VersionID, &row.IsApplied); err != nil {
-			log.Fatal("error scanning rows:", err)
+	toSkip := map[int64]bool{}
+	lastID := int64(-1)
+	for {
+		rows, err := versionPage(ctx, db, lastID)
+		if err != nil {
+			if lastID < 0 {
+				return 0, createVersionTable(ctx, db)
+			}
+			return 0, err
 		}
 
-		// have we already marked this version to be skipped?
-		skip := false
-		for _, v := range toSkip {
-			if v == row.VersionID {
-				skip = true
-				break
+		n := 0
+		for rows.Next() {
+			var row MigrationRecord
+			if err = rows.Scan(&lastID, &row.VersionID, &row.IsApplied); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("error scanning rows: %v", err)
 			}
-		}
+			n++
 
-		if skip {
-			continue
-		}
+			// have we already marked this version to be skipped?
+			if toSkip[row.VersionID] {
+				continue
+			}
 
-		// if version has been applied we're done
-		if row.IsApplied {
-			return row.VersionID, nil
-		}
+			// if version has been applied we're done
+			if row.IsApplied {
+				rows.Close()
+				return row.VersionID, nil
+			}
 
-		// latest version of migration has not been applied.
-		toSkip = append(toSkip, row.VersionID)
+			// latest version of migration has not been applied.
+			toSkip[row.VersionID] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return 0, err
+		}
+		if n < versionPageSize {
+			return 0, ErrNoNextVersion
+		}
 	}
+}
 
-	return 0, ErrNoNextVersion
+// versionPage returns a page of the version history, from the most recent
+// record older than the record beforeID, or the most recent one if it is
+// negative.
+func versionPage(ctx context.Context, db *sql.DB, beforeID int64) (*sql.Rows, error) {
+	c := configFrom(ctx)
+	if beforeID < 0 {
+		return db.QueryContext(ctx, fmt.Sprintf("SELECT id, version_id, is_applied FROM %s ORDER BY id DESC LIMIT %d", c.tables.version(), versionPageSize))
+	}
+	return db.QueryContext(ctx, fmt.Sprintf("SELECT id, version_id, is_applied FROM %s WHERE id < %s ORDER BY id DESC LIMIT %d", c.tables.version(), c.placeholder(1), versionPageSize), beforeID)
 }
 
-// Create the goose_db_version table
+// Create the version table
 // and insert the initial 0 value into it
-func createVersionTable(db *sql.DB) error {
-	txn, err := db.Begin()
+func createVersionTable(ctx context.Context, db *sql.DB) error {
+	txn, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	d := GetDialect()
+	c := configFrom(ctx)
 
-	if _, err := txn.Exec(d.createVersionTableSQL()); err != nil {
+	if _, err := txn.ExecContext(ctx, c.dialect.createVersionTableSQL(c.tables)); err != nil {
+		txn.Rollback()
+		return err
+	}
+	if q := c.dialect.createVersionIndexSQL(c.tables); q != "" {
+		if _, err := txn.ExecContext(ctx, q); err != nil {
+			txn.Rollback()
+			return err
+		}
+	}
+
+	if err := createMetadataTable(ctx, txn); err != nil {
 		txn.Rollback()
 		return err
 	}
 
 	version := 0
 	applied := true
-	if _, err := txn.Exec(d.insertVersionSQL(), version, applied); err != nil {
+	if _, err := txn.ExecContext(ctx, c.dialect.insertVersionSQL(c.tables), version, applied); err != nil {
 		txn.Rollback()
 		return err
 	}
@@ -270,7 +417,12 @@ func createVersionTable(db *sql.DB) error {
 // GetDBVersion is a wrapper for EnsureDBVersion for callers that don't already
 // have their own DB instance
 func GetDBVersion(db *sql.DB) (int64, error) {
-	version, err := EnsureDBVersion(db)
+	return GetDBVersionContext(context.Background(), db)
+}
+
+// GetDBVersionContext is GetDBVersion with a context, canceling its queries.
+func GetDBVersionContext(ctx context.Context, db *sql.DB) (int64, error) {
+	version, err := ensureDBVersion(ctx, db)
 	if err != nil {
 		return -1, err
 	}
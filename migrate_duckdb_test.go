@@ -0,0 +1,114 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUpAllowMissing(t *testing.T) {
+	db := openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE a (id int);\n")},
+		"migrations/00002_b.sql": {Data: []byte("-- +goose Up\nCREATE TABLE b (id int);\n")},
+		"migrations/00003_c.sql": {Data: []byte("-- +goose Up\nCREATE TABLE c (id int);\n")},
+		"migrations/00004_d.sql": {Data: []byte("-- +goose Phase contract\n-- +goose Up\nCREATE TABLE d (id int);\n")},
+	})
+	defer SetBaseFS(nil)
+	if _, err := EnsureDBVersion(db); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []int64{1, 3} {
+		if _, err := db.Exec(GetDialect().insertVersionSQL(packageTables()), v, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	migrations, err := CollectMigrations("migrations", minVersion, maxVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without -allow-missing, the missing migration is left pending with a
+	// warning.
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+	pending, err := pendingMigrations(context.Background(), db, migrations)
+	if err != nil || len(pending) != 1 || pending[0].Version != 4 {
+		t.Errorf("got pending migrations %v, %v, want 4", pending, err)
+	}
+	if len(logger.lines) != 1 || !strings.HasPrefix(logger.lines[0], "WARNING: 1 migrations missing before version 3 are not applied: 2;") {
+		t.Errorf("got log %q, want a warning about version 2", logger.lines)
+	}
+
+	SetAllowMissing(true)
+	defer SetAllowMissing(false)
+	if err := upPhase(context.Background(), db, "migrations", PhaseExpand); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := appliedVersions(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !applied[2] || applied[4] {
+		t.Errorf("got applied versions %v, want the missing expand migration 2 only", applied)
+	}
+}
+
+func TestEnsureDBVersionPages(t *testing.T) {
+	db := openDuckDB(t)
+	if _, err := EnsureDBVersion(db); err != nil {
+		t.Fatal(err)
+	}
+	record := func(v int64, applied bool) {
+		if _, err := db.Exec(GetDialect().insertVersionSQL(packageTables()), v, applied); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		history  func()
+		expected int64
+	}{
+		{"within the first page", func() {
+			for v := int64(1); v <= 150; v++ {
+				record(v, true)
+			}
+		}, 150},
+		// The most recent page only holds rolled back versions.
+		{"past a page of rollbacks", func() {
+			for v := int64(150); v > 50; v-- {
+				record(v, false)
+			}
+		}, 50},
+		{"on a page boundary", func() {
+			record(50, false)
+		}, 49},
+		// Rollbacks hide the older records of their versions, whatever
+		// page these are in.
+		{"all rolled back", func() {
+			for v := int64(49); v > 0; v-- {
+				record(v, false)
+			}
+		}, 0},
+	}
+	for _, test := range tests {
+		test.history()
+		if v, err := EnsureDBVersion(db); err != nil || v != test.expected {
+			t.Errorf("%s: got version %d, %v, want %d", test.name, v, err, test.expected)
+		}
+	}
+
+	// 300 records, the last page being full.
+	if _, err := db.Exec("DELETE FROM " + TableName() + " WHERE version_id = 0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnsureDBVersion(db); err != ErrNoNextVersion {
+		t.Errorf("without applied versions: got %v, want ErrNoNextVersion", err)
+	}
+}
@@ -1,7 +1,11 @@
 package goose
 
 import (
+	"context"
+	"database/sql"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func newMigration(v int64, src string) *Migration {
@@ -56,3 +60,159 @@ func validateMigrationSort(t *testing.T, ms Migrations, sorted []int64) {
 
 	t.Log(ms)
 }
+
+func TestValidateMigrationNames(t *testing.T) {
+	tests := []struct {
+		names []string
+		valid bool
+	}{
+		{names: []string{"00001_create.sql", "00002_alter.go"}, valid: true},
+		{names: []string{"00001_Users.sql", "00001_users.sql"}, valid: false},
+		{names: []string{"001_a.sql", "1_b.sql"}, valid: false},
+		{names: []string{"+1_a.sql"}, valid: false},
+		{names: []string{"00001_what?.sql"}, valid: false},
+		{names: []string{"00001_a:b.sql"}, valid: false},
+	}
+
+	for _, test := range tests {
+		var ms Migrations
+		for _, name := range test.names {
+			v, _ := NumericComponent(name)
+			ms = append(ms, newMigration(v, "migrations/"+name))
+		}
+		if err := validateMigrationNames(ms); (err == nil) != test.valid {
+			t.Errorf("%v: got error %v, want valid=%v", test.names, err, test.valid)
+		}
+	}
+}
+
+func TestCollectMigrationsBaseFS(t *testing.T) {
+	SetBaseFS(fstest.MapFS{
+		"db/migrations/00001_create.sql":     {Data: []byte("-- +goose Up\nCREATE TABLE t (id int);\n-- +goose Down\nDROP TABLE t;\n")},
+		"db/migrations/00002_alter.up.sql":   {Data: []byte("ALTER TABLE t ADD name text;\n")},
+		"db/migrations/00002_alter.down.sql": {Data: []byte("ALTER TABLE t DROP name;\n")},
+		"db/migrations/README.md":            {Data: []byte("not a migration")},
+	})
+	defer SetBaseFS(nil)
+
+	ms, err := CollectMigrations("./db/migrations", 0, maxVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateMigrationSort(t, ms, []int64{1, 2})
+
+	stmts, _, _, err := readSQLStatements(packageConfig(), ms[0].Source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 || !strings.Contains(stmts[0], "DROP TABLE t;") {
+		t.Errorf("got down statements %q", stmts)
+	}
+
+	// The down file of the paired migration is read through the base
+	// filesystem too.
+	if stmts, _, _, err = readSQLStatements(packageConfig(), ms[1].Source, false); err != nil || len(stmts) != 1 || stmts[0] != "ALTER TABLE t DROP name;\n" {
+		t.Errorf("got down statements %q, error %v", stmts, err)
+	}
+
+	if _, err := CollectMigrations("db/missing", 0, maxVersion); err == nil {
+		t.Error("collected migrations of a folder missing from the filesystem")
+	}
+}
+
+func TestEmbeddedMigrationsOrder(t *testing.T) {
+	// Embedded files are listed by name: 10 comes before 2.
+	SetBaseFS(fstest.MapFS{
+		"migrations/1_users.sql":           {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n")},
+		"migrations/10_orders.sql":         {Data: []byte("-- +goose Up\nCREATE TABLE orders (id int);\n")},
+		"migrations/2_accounts.sql":        {Data: []byte("-- +goose Up\nCREATE TABLE accounts (id int);\n")},
+		"migrations/fixtures/accounts.csv": {Data: []byte("id\n1\n")},
+	})
+	defer SetBaseFS(nil)
+
+	ms, err := CollectMigrations("migrations", 0, maxVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateMigrationSort(t, ms, []int64{1, 2, 10})
+
+	columns, rows, err := readFixture(packageConfig(), "migrations/fixtures/accounts.csv")
+	if err != nil || len(columns) != 1 || len(rows) != 1 {
+		t.Errorf("got fixture %v %v, error %v", columns, rows, err)
+	}
+}
+
+func TestRegisteredGoMigrations(t *testing.T) {
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_users.sql":    {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n")},
+		"migrations/00003_accounts.sql": {Data: []byte("-- +goose Up\nCREATE TABLE accounts (id int);\n")},
+	})
+	defer SetBaseFS(nil)
+
+	noop := func(*sql.Tx) error { return nil }
+	AddNamedMigration("migrations/00002_rename_root.go", noop, noop)
+	defer delete(registeredGoMigrations, 2)
+
+	ms, err := CollectMigrations("migrations", 0, maxVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateMigrationSort(t, ms, []int64{1, 2, 3})
+	if !ms[1].Registered || ms[1].UpFn == nil {
+		t.Errorf("migration 2 isn't the registered Go migration: %v", ms[1])
+	}
+
+	for _, filename := range []string{"migrations/00002_again.go", "migrations/rename_root.go"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: registered without panicking", filename)
+				}
+			}()
+			AddNamedMigration(filename, noop, noop)
+		}()
+	}
+}
+
+func TestGoMigrationContext(t *testing.T) {
+	type key struct{}
+	var got interface{}
+	backfill := func(ctx context.Context, db *sql.DB) error {
+		got = ctx.Value(key{})
+		return nil
+	}
+	AddNamedMigrationNoTxContext("migrations/00004_backfill.go", backfill, nil)
+	defer delete(registeredGoMigrations, 4)
+	index := func(ctx context.Context, tx *sql.Tx) error { return nil }
+	AddNamedMigrationContext("migrations/00005_index.go", index, index)
+	defer delete(registeredGoMigrations, 5)
+
+	db, err := sql.Open("goose-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The fake driver doesn't support transactions: only the migration
+	// running outside of one succeeds.
+	ctx := context.WithValue(context.Background(), key{}, "run")
+	if err := registeredGoMigrations[4].UpContext(ctx, db); err != nil || got != "run" {
+		t.Errorf("got context value %v, error %v", got, err)
+	}
+	if err := registeredGoMigrations[4].DownContext(ctx, db); err != nil {
+		t.Errorf("down without function: %v", err)
+	}
+	if err := registeredGoMigrations[5].UpContext(ctx, db); err == nil || !strings.Contains(err.Error(), "db.Begin") {
+		t.Errorf("got error %v, want a db.Begin error", err)
+	}
+}
+
+func TestUnappliedMigrations(t *testing.T) {
+	ms := Migrations{newMigration(1, "00001_a.sql"), newMigration(2, "00002_b.sql"), newMigration(3, "00003_c.sql"), newMigration(4, "00004_d.sql")}
+	applied := map[int64]bool{0: true, 1: true, 3: true}
+
+	pending := unapplied(ms, applied)
+	if len(pending) != 2 || pending[0].Version != 2 || pending[1].Version != 4 {
+		t.Errorf("got pending migrations %v, want 2 and 4", pending)
+	}
+}
@@ -1,11 +1,12 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 	"path/filepath"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"time"
@@ -27,68 +28,213 @@ type Migration struct {
 	Registered bool
 	UpFn       func(*sql.Tx) error // Up go migration function
 	DownFn     func(*sql.Tx) error // Down go migration function
+
+	// Go migration functions registered with AddMigrationContext, used
+	// instead of UpFn and DownFn.
+	UpFnContext   func(context.Context, *sql.Tx) error
+	DownFnContext func(context.Context, *sql.Tx) error
+
+	// Go migration functions registered with AddMigrationNoTxContext, run
+	// outside of a transaction.
+	UpFnNoTx   func(context.Context, *sql.DB) error
+	DownFnNoTx func(context.Context, *sql.DB) error
+	NoTx       bool
+
+	sha256 string // checksum listed in the manifest, verified before running
 }
 
 func (m *Migration) String() string {
-	return fmt.Sprintf(m.Source)
+	return m.Source
 }
 
 // Up runs an up migration.
 func (m *Migration) Up(db *sql.DB) error {
-	if err := m.run(db, true); err != nil {
+	return m.up(context.Background(), db)
+}
+
+// Down runs a down migration.
+func (m *Migration) Down(db *sql.DB) error {
+	return m.down(context.Background(), db)
+}
+
+// UpContext runs an up migration, with ctx.
+func (m *Migration) UpContext(ctx context.Context, db *sql.DB) error {
+	return m.up(ctx, db)
+}
+
+// DownContext runs a down migration, with ctx.
+func (m *Migration) DownContext(ctx context.Context, db *sql.DB) error {
+	return m.down(ctx, db)
+}
+
+func (m *Migration) up(ctx context.Context, db *sql.DB) error {
+	if err := m.run(ctx, db, true); err != nil {
 		return err
 	}
-	log.Println("OK   ", filepath.Base(m.Source))
+	configFrom(ctx).log.Println(colorize(colorGreen, "OK   "), filepath.Base(m.Source))
 	return nil
 }
 
-// Down runs a down migration.
-func (m *Migration) Down(db *sql.DB) error {
-	if err := m.run(db, false); err != nil {
+func (m *Migration) down(ctx context.Context, db *sql.DB) error {
+	if err := m.run(ctx, db, false); err != nil {
 		return err
 	}
-	log.Println("OK   ", filepath.Base(m.Source))
+	configFrom(ctx).log.Println(colorize(colorGreen, "OK   "), filepath.Base(m.Source))
 	return nil
 }
 
-func (m *Migration) run(db *sql.DB, direction bool) error {
-	switch filepath.Ext(m.Source) {
-	case ".sql":
-		if err := runSQLMigration(db, m.Source, m.Version, direction); err != nil {
-			return fmt.Errorf("FAIL %v, quitting migration", err)
+func (m *Migration) run(ctx context.Context, db *sql.DB, direction bool) (err error) {
+	ctx, span := tracer.Start(ctx, "goose.migration", map[string]interface{}{
+		"goose.version":   m.Version,
+		"goose.file":      filepath.Base(m.Source),
+		"goose.direction": directionName(direction),
+	})
+	defer func() { endSpan(span, err) }()
+
+	if err := m.verifyChecksum(configFrom(ctx)); err != nil {
+		return err
+	}
+
+	started := time.Now()
+	result, err := m.exec(ctx, db, direction)
+	if err != nil {
+		migrationErr := &MigrationError{Version: m.Version, Source: m.Source, Err: err}
+		if p, ok := err.(*goPanic); ok {
+			migrationErr.Stack = p.stack
 		}
+		return migrationErr
+	}
 
-	case ".go":
-		if !m.Registered {
-			log.Fatalf("failed to apply Go migration %q: Go functions must be registered and built into a custom binary (see https://github.com/gojuno/goose/tree/master/examples/go-migrations)", m.Source)
+	applied := AppliedMigration{
+		Version:      m.Version,
+		File:         filepath.Base(m.Source),
+		Direction:    directionName(direction),
+		Duration:     time.Since(started),
+		RowsAffected: result.rows,
+	}
+	recordMigration(ctx, applied)
+	emit(ctx, MigrationApplied{applied})
+
+	writeAudit(ctx, db, applied, result.checksum())
+	writeRunContext(ctx, db, m.Version, direction)
+	return nil
+}
+
+// verifyChecksum checks the migration file against the checksum listed in
+// the manifest, if any.
+func (m *Migration) verifyChecksum(c *config) error {
+	if m.sha256 == "" {
+		return nil
+	}
+	if err := verifyChecksum(c, m.Source, m.sha256); err != nil {
+		return &ValidationError{File: m.Source, Err: err}
+	}
+	return nil
+}
+
+// verifyChecksums checks the migrations about to run against the manifest
+// before the first one runs, so that a tampered file doesn't stop a run
+// halfway.
+func verifyChecksums(c *config, migrations Migrations) error {
+	for _, m := range migrations {
+		if err := m.verifyChecksum(c); err != nil {
+			return err
 		}
-		tx, err := db.Begin()
+	}
+	return nil
+}
+
+func (m *Migration) exec(ctx context.Context, db *sql.DB, direction bool) (execResult, error) {
+	c := configFrom(ctx)
+	switch {
+	case isSQLMigration(m.Source):
+		skip, err := skipForEnvironment(c, m.Source)
 		if err != nil {
-			log.Fatal("db.Begin: ", err)
+			return execResult{}, err
+		}
+		if skip {
+			// Recorded all the same, to keep the version history linear.
+			_, err := db.ExecContext(ctx, c.insertVersionSQL(), m.Version, direction)
+			return newExecResult(), err
+		}
+		return runSQLMigration(ctx, db, m.Source, m.Version, direction)
+
+	case filepath.Ext(m.Source) == ".go":
+		if !m.Registered {
+			return execResult{}, fmt.Errorf("failed to apply Go migration %q: Go functions must be registered and built into a custom binary (see https://github.com/gojuno/goose/tree/master/examples/go-migrations)", m.Source)
+		}
+		if m.NoTx {
+			fn := m.UpFnNoTx
+			if !direction {
+				fn = m.DownFnNoTx
+			}
+			if fn != nil {
+				if err := callGoMigration(func() error { return fn(ctx, db) }); err != nil {
+					return execResult{}, err
+				}
+			}
+			_, err := db.ExecContext(ctx, c.insertVersionSQL(), m.Version, direction)
+			return execResult{}, err
 		}
 
-		fn := m.UpFn
-		if !direction {
-			fn = m.DownFn
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return execResult{}, fmt.Errorf("db.Begin: %v", err)
 		}
+
+		fn := m.goFunc(direction)
 		if fn != nil {
-			if err := fn(tx); err != nil {
+			if err := callGoMigration(func() error { return fn(ctx, tx) }); err != nil {
 				tx.Rollback()
-				log.Fatalf("FAIL %s (%v), quitting migration.", filepath.Base(m.Source), err)
-				return err
+				return execResult{}, err
 			}
 		}
-		if _, err := tx.Exec(GetDialect().insertVersionSQL(), m.Version, direction); err != nil {
+		if _, err := tx.ExecContext(ctx, c.insertVersionSQL(), m.Version, direction); err != nil {
 			tx.Rollback()
-			return err
+			return execResult{}, err
 		}
 
-		return tx.Commit()
+		return execResult{}, tx.Commit()
 	}
 
+	return execResult{}, nil
+}
+
+// goPanic is the error of a Go migration that panicked.
+type goPanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (p *goPanic) Error() string { return fmt.Sprintf("panic: %v", p.value) }
+
+// goFunc returns the function of a transactional Go migration for a
+// direction, or nil if there is none.
+func (m *Migration) goFunc(direction bool) func(context.Context, *sql.Tx) error {
+	fnContext, fn := m.UpFnContext, m.UpFn
+	if !direction {
+		fnContext, fn = m.DownFnContext, m.DownFn
+	}
+	switch {
+	case fnContext != nil:
+		return fnContext
+	case fn != nil:
+		return func(_ context.Context, tx *sql.Tx) error { return fn(tx) }
+	}
 	return nil
 }
 
+// callGoMigration runs fn, turning a panic into a *goPanic error so that the
+// transaction is rolled back and the stack trace can be reported.
+func callGoMigration(fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &goPanic{value: v, stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
 // NumericComponent looks for migration scripts with names in the form:
 // XXX_descriptivename.ext where XXX specifies the version number
 // and ext specifies the type of migration
@@ -96,7 +242,7 @@ func NumericComponent(name string) (int64, error) {
 
 	base := filepath.Base(name)
 
-	if ext := filepath.Ext(base); ext != ".go" && ext != ".sql" {
+	if ext := filepath.Ext(base); ext != ".go" && !isSQLMigration(base) {
 		return 0, errors.New("not a recognized migration file type")
 	}
 
@@ -3,45 +3,163 @@ package goose
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
-	"log"
-	"os"
+	"io/fs"
+	"path/filepath"
 	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
-	sqlCmdPrefix   = "-- +goose "
-	scannerBufSize = 4 * 1024 * 1024
+	sqlCmdPrefix = "-- +goose "
+
+	gzipSQLExt = ".sql.gz"
+
+	// readerBufSize is the read buffer of the parser. Longer lines are
+	// accumulated in a line buffer growing on demand.
+	readerBufSize = 64 * 1024
+	// retainedLineSize is the largest line buffer kept for reuse after a
+	// parse, so that a giant migration doesn't pin its memory.
+	retainedLineSize = 1024 * 1024
 )
 
-var bufferPool = sync.Pool{
+var maxLineSize = 64 * 1024 * 1024
+
+// SetMaxLineSize sets the length of the longest line a SQL migration may
+// have, 64MB by default. Parse buffers start small and grow up to it.
+func SetMaxLineSize(n int) {
+	maxLineSize = n
+}
+
+// lineScanner reads lines like a bufio.Scanner, from buffers pooled across
+// files, its line buffer growing on demand up to maxLineSize.
+type lineScanner struct {
+	r    *bufio.Reader
+	line []byte
+	err  error
+}
+
+var lineScannerPool = sync.Pool{
 	New: func() interface{} {
-		return make([]byte, scannerBufSize)
+		return &lineScanner{r: bufio.NewReaderSize(nil, readerBufSize)}
 	},
 }
 
+func newLineScanner(r io.Reader) *lineScanner {
+	s := lineScannerPool.Get().(*lineScanner)
+	s.r.Reset(r)
+	s.err = nil
+	return s
+}
+
+// release returns the scanner to the pool.
+func (s *lineScanner) release() {
+	s.r.Reset(nil)
+	if cap(s.line) > retainedLineSize {
+		s.line = nil
+	}
+	lineScannerPool.Put(s)
+}
+
+// Scan reads the next line, reporting whether there is one.
+func (s *lineScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	s.line = s.line[:0]
+	for {
+		chunk, err := s.r.ReadSlice('\n')
+		if len(s.line)+len(chunk) > maxLineSize {
+			s.err = fmt.Errorf("line longer than %d bytes, see SetMaxLineSize", maxLineSize)
+			return false
+		}
+		s.line = append(s.line, chunk...)
+		switch err {
+		case nil:
+			s.line = bytes.TrimSuffix(s.line[:len(s.line)-1], []byte("\r"))
+			return true
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			s.err = io.EOF
+			s.line = bytes.TrimSuffix(s.line, []byte("\r"))
+			return len(s.line) > 0
+		default:
+			s.err = err
+			return false
+		}
+	}
+}
+
+// Bytes returns the line read by Scan, without its line ending. It is only
+// valid until the next call to Scan.
+func (s *lineScanner) Bytes() []byte {
+	return s.line
+}
+
+// Err returns the error that stopped the scan, nil at the end of the input.
+func (s *lineScanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
 // Checks the line to see if the line has a statement-ending semicolon
 // or if the line contains a double-dash comment.
+//
+// The line is split into whitespace-separated words in place, the last word
+// before a word starting with "--" deciding, without allocating.
 func endsWithSemicolon(line []byte) bool {
-	prev := ""
-	scanner := bufio.NewScanner(bytes.NewReader(line))
-	buf := bufferPool.Get().([]byte)
-	defer bufferPool.Put(buf)
-
-	scanner.Buffer(buf, cap(buf))
-	scanner.Split(bufio.ScanWords)
+	var prev []byte
+	for i := 0; i < len(line); {
+		n := spaceLen(line[i:])
+		if n > 0 {
+			i += n
+			continue
+		}
 
-	for scanner.Scan() {
-		word := scanner.Text()
-		if strings.HasPrefix(word, "--") {
+		start := i
+		for i < len(line) && spaceLen(line[i:]) == 0 {
+			i++
+		}
+		word := line[start:i]
+		if len(word) >= 2 && word[0] == '-' && word[1] == '-' {
 			break
 		}
 		prev = word
 	}
 
-	return strings.HasSuffix(prev, ";")
+	return len(prev) > 0 && prev[len(prev)-1] == ';'
+}
+
+// spaceLen returns the length of the whitespace character starting b, or 0
+// if it doesn't start with one. Non-ASCII whitespace is recognized like
+// bufio.ScanWords does.
+func spaceLen(b []byte) int {
+	if c := b[0]; c < utf8.RuneSelf {
+		switch c {
+		case ' ', '\t', '\n', '\v', '\f', '\r':
+			return 1
+		}
+		return 0
+	}
+	r, n := utf8.DecodeRune(b)
+	if unicode.IsSpace(r) {
+		return n
+	}
+	return 0
 }
 
 // Split the given sql script into individual statements.
@@ -53,14 +171,19 @@ func endsWithSemicolon(line []byte) bool {
 // within a statement. For these cases, we provide the explicit annotations
 // 'StatementBegin' and 'StatementEnd' to allow the script to
 // tell us to ignore semicolons.
-func getSQLStatements(r io.Reader, direction bool) (stmts []string, tx bool) {
-	var buf bytes.Buffer
+func getSQLStatements(r io.Reader, direction bool) (stmts []string, tx bool, err error) {
+	stmts, _, tx, err = parseSQLStatements(packageConfig(), r, direction)
+	return stmts, tx, err
+}
 
-	buff := bufferPool.Get().([]byte)
-	defer bufferPool.Put(buff)
+// parseSQLStatements is getSQLStatements also returning the 1-based line
+// each statement starts at, skipping leading blank lines and comments.
+func parseSQLStatements(c *config, r io.Reader, direction bool) (stmts []string, lines []int, tx bool, err error) {
+	var buf bytes.Buffer
+	lineNum, stmtLine := 0, 0
 
-	scanner := bufio.NewScanner(r)
-	scanner.Buffer(buff, cap(buff))
+	scanner := newLineScanner(r)
+	defer scanner.release()
 
 	// track the count of each section
 	// so we can diagnose scripts with no annotations
@@ -71,13 +194,34 @@ func getSQLStatements(r io.Reader, direction bool) (stmts []string, tx bool) {
 	ignoreSemicolons := false
 	directionIsActive := false
 	tx = true
+	// delimiter ends statements instead of semicolons after a MySQL client
+	// "DELIMITER //" line, until "DELIMITER ;".
+	var delimiter []byte
+	delimiters := hasDelimiterCommand(c)
 
 	for scanner.Scan() {
 		line := scanner.Bytes()
+		lineNum++
+
+		// A DELIMITER line can't be part of a statement, e.g. a column
+		// named delimiter.
+		if d, ok := delimiterCommand(line); ok && delimiters && stmtLine == 0 {
+			if bytes.Equal(d, []byte(";")) {
+				delimiter = nil
+			} else {
+				delimiter = append(delimiter[:0], d...)
+			}
+			continue
+		}
 
 		// handle any goose-specific commands
-		if bytes.HasPrefix(line, []byte(sqlCmdPrefix)) {
-			cmd := bytes.TrimSpace(line[len(sqlCmdPrefix):])
+		if cmd, ok := annotationCommand(line); ok {
+			// sql-migrate marks migrations running outside of a
+			// transaction with "Up notransaction".
+			if c := bytes.TrimSuffix(cmd, []byte(" notransaction")); len(c) < len(cmd) {
+				tx = false
+				cmd = c
+			}
 			switch string(cmd) {
 			case "Up":
 				directionIsActive = (direction == true)
@@ -105,6 +249,14 @@ func getSQLStatements(r io.Reader, direction bool) (stmts []string, tx bool) {
 			case "NO TRANSACTION":
 				tx = false
 				break
+
+			default:
+				// Fixture loads are statements of their own.
+				if bytes.HasPrefix(cmd, []byte("Load ")) && directionIsActive {
+					stmts = append(stmts, string(line)+"\n")
+					lines = append(lines, lineNum)
+					continue
+				}
 			}
 		}
 
@@ -112,45 +264,119 @@ func getSQLStatements(r io.Reader, direction bool) (stmts []string, tx bool) {
 			continue
 		}
 
-		if _, err := buf.Write(line); err != nil {
-			log.Fatalf("io err: %v", err)
+		if trimmed := bytes.TrimSpace(line); stmtLine == 0 && len(trimmed) > 0 && !bytes.HasPrefix(trimmed, []byte("--")) {
+			stmtLine = lineNum
 		}
-
-		if _, err := buf.WriteString("\n"); err != nil {
-			log.Fatalf("io err: %v", err)
+		if delimiter != nil {
+			if trimmed := bytes.TrimRightFunc(line, unicode.IsSpace); bytes.HasSuffix(trimmed, delimiter) {
+				line = trimmed[:len(trimmed)-len(delimiter)]
+				statementEnded = true
+			}
 		}
+		buf.Write(line)
+		buf.WriteString("\n")
 
 		// Wrap up the two supported cases: 1) basic with semicolon; 2) psql statement
 		// Lines that end with semicolon that are in a statement block
 		// do not conclude statement.
-		if (!ignoreSemicolons && endsWithSemicolon(line)) || statementEnded {
+		if (!ignoreSemicolons && delimiter == nil && endsWithSemicolon(line)) || statementEnded {
 			statementEnded = false
 			stmts = append(stmts, buf.String())
+			lines = append(lines, stmtLine)
 			buf.Reset()
+			stmtLine = 0
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Fatalf("scanning migration: %v", err)
+		return nil, nil, false, fmt.Errorf("scanning migration: %v", err)
 	}
 
 	// diagnose likely migration script errors
 	if ignoreSemicolons {
-		log.Println("WARNING: saw '-- +goose StatementBegin' with no matching '-- +goose StatementEnd'")
+		c.log.Println("WARNING: saw '-- +goose StatementBegin' with no matching '-- +goose StatementEnd'")
 	}
 
-	if bufferRemaining := strings.TrimSpace(buf.String()); len(bufferRemaining) > 0 {
-		log.Printf("WARNING: Unexpected unfinished SQL query: %s. Missing a semicolon?\n", bufferRemaining)
+	if bufferRemaining := strings.TrimSpace(stripComments(buf.String())); len(bufferRemaining) > 0 {
+		c.log.Printf("WARNING: Unexpected unfinished SQL query: %s. Missing a semicolon?\n", bufferRemaining)
 	}
 
 	if upSections == 0 && downSections == 0 {
-		log.Fatalf(`ERROR: no Up/Down annotations found, so no statements were executed.
-			See https://bitbucket.org/liamstask/goose/overview for details.`)
+		return nil, nil, false, errors.New("no Up/Down annotations found, so no statements were executed")
 	}
 
 	return
 }
 
+// delimiterCommand returns the delimiter set by a MySQL client
+// "DELIMITER //" line, as written by mysqldump and MySQL Workbench around
+// stored routines and triggers.
+func delimiterCommand(line []byte) ([]byte, bool) {
+	fields := bytes.Fields(line)
+	if len(fields) != 2 || !bytes.EqualFold(fields[0], []byte("DELIMITER")) {
+		return nil, false
+	}
+	return fields[1], true
+}
+
+// hasDelimiterCommand reports whether the current dialect is MySQL's,
+// whose client accepts DELIMITER lines.
+func hasDelimiterCommand(c *config) bool {
+	switch c.dialect.(type) {
+	case *MySQLDialect, *TiDBDialect:
+		return true
+	}
+	return false
+}
+
+// isSQLMigration reports whether the file is a plain or gzip-compressed
+// SQL migration.
+func isSQLMigration(name string) bool {
+	return strings.HasSuffix(name, ".sql") || strings.HasSuffix(name, gzipSQLExt)
+}
+
+// sqlFile wraps a migration file, transparently decompressing .sql.gz files
+// and serving migrations read from a stream.
+type sqlFile struct {
+	io.Reader
+	f  fs.File
+	gz *gzip.Reader
+}
+
+func openSQLFile(c *config, path string) (*sqlFile, error) {
+	if b, ok := memoryFile(path); ok {
+		return &sqlFile{Reader: bytes.NewReader(b)}, nil
+	}
+
+	f, err := c.openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, gzipSQLExt) {
+		return &sqlFile{Reader: f, f: f}, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &sqlFile{Reader: gz, f: f, gz: gz}, nil
+}
+
+func (s *sqlFile) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	if s.gz != nil {
+		if err := s.gz.Close(); err != nil {
+			s.f.Close()
+			return err
+		}
+	}
+	return s.f.Close()
+}
+
 // Run a migration specified in raw SQL.
 //
 // Sections of the script can be annotated with a special comment,
@@ -159,46 +385,133 @@ func getSQLStatements(r io.Reader, direction bool) (stmts []string, tx bool) {
 //
 // All statements following an Up or Down directive are grouped together
 // until another direction directive is found.
-func runSQLMigration(db *sql.DB, scriptFile string, v int64, direction bool) error {
-	f, err := os.Open(scriptFile)
+func runSQLMigration(ctx context.Context, db *sql.DB, scriptFile string, v int64, direction bool) (execResult, error) {
+	c := configFrom(ctx)
+	statements, lines, useTx, err := readSQLStatements(c, scriptFile, direction)
+	if err != nil {
+		return execResult{}, &ValidationError{File: filepath.Base(scriptFile), Err: fmt.Errorf("%s: %v", filepath.Base(scriptFile), err)}
+	}
+	ctx = withCopyIn(ctx, db)
+
+	tool, err := onlineTool(c, scriptFile)
 	if err != nil {
-		log.Fatal(err)
+		return execResult{}, err
+	}
+	if tool != "" {
+		return runOnlineMigration(ctx, db, tool, scriptFile, v, direction, statements, lines)
 	}
-	defer f.Close()
 
-	statements, useTx := getSQLStatements(f, direction)
+	if useTx, err = needsTransaction(c, scriptFile, statements, lines, useTx); err != nil {
+		return execResult{}, err
+	}
 
+	var down []byte
+	if direction {
+		down = downSQL(ctx, db, scriptFile)
+	}
+
+	result := newExecResult()
 	if useTx {
 		// TRANSACTION.
 
-		tx, err := db.Begin()
+		tx, err := db.BeginTx(ctx, nil)
 		if err != nil {
-			log.Fatal(err)
+			return result, fmt.Errorf("db.Begin: %v", err)
 		}
 
-		for _, query := range statements {
-			if _, err = tx.Exec(query); err != nil {
+		for i, query := range statements {
+			if err = execStatement(ctx, tx, scriptFile, v, i, lines[i], query, &result); err != nil {
 				tx.Rollback()
-				return err
+				return result, err
 			}
 		}
-		if _, err := tx.Exec(GetDialect().insertVersionSQL(), v, direction); err != nil {
+		if _, err := tx.ExecContext(ctx, c.insertVersionSQL(), v, direction); err != nil {
 			tx.Rollback()
-			return err
+			return result, err
+		}
+		if err := writeDownSQL(ctx, tx, down, v); err != nil {
+			tx.Rollback()
+			return result, err
 		}
 
-		return tx.Commit()
+		return result, tx.Commit()
 	}
 
 	// NO TRANSACTION.
-	for _, query := range statements {
-		if _, err := db.Exec(query); err != nil {
-			return err
+	progress := newProgress(ctx, v, filepath.Base(scriptFile), len(statements))
+	for i, query := range statements {
+		if index := concurrentIndexName(c, query); index != "" {
+			err = execConcurrentIndex(ctx, db, scriptFile, v, i, lines[i], query, index, &result)
+		} else {
+			err = execStatement(ctx, db, scriptFile, v, i, lines[i], query, &result)
 		}
+		if err != nil {
+			return result, err
+		}
+		progress.done(i + 1)
+	}
+	if _, err := db.ExecContext(ctx, c.insertVersionSQL(), v, direction); err != nil {
+		return result, err
 	}
-	if _, err := db.Exec(GetDialect().insertVersionSQL(), v, direction); err != nil {
-		return err
+	if err := writeDownSQL(ctx, db, down, v); err != nil {
+		return result, err
 	}
 
+	return result, nil
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// execResult accumulates what a migration executed.
+type execResult struct {
+	sql  hash.Hash
+	rows int64
+}
+
+func newExecResult() execResult {
+	return execResult{sql: sha256.New()}
+}
+
+// checksum returns the SHA-256 of the executed SQL, if any.
+func (r execResult) checksum() string {
+	if r.sql == nil {
+		return ""
+	}
+	return hex.EncodeToString(r.sql.Sum(nil))
+}
+
+func execStatement(ctx context.Context, db execer, scriptFile string, v int64, i, line int, query string, result *execResult) (err error) {
+	c := configFrom(ctx)
+	ctx, span := tracer.Start(ctx, "goose.statement", map[string]interface{}{
+		"goose.version":   v,
+		"goose.file":      filepath.Base(scriptFile),
+		"goose.statement": i + 1,
+	})
+	defer func() { endSpan(span, err) }()
+
+	logStatement(c, filepath.Base(scriptFile), i, query)
+	stopHeartbeat := startHeartbeat(c, filepath.Base(scriptFile), i+1)
+	var res sql.Result
+	if load, ok := parseLoad(query); ok {
+		var n int64
+		n, err = execLoad(ctx, db, scriptFile, load)
+		res = driver.RowsAffected(n)
+	} else {
+		res, err = db.ExecContext(ctx, query)
+	}
+	stopHeartbeat()
+	if err != nil {
+		emit(ctx, StatementFailed{Version: v, File: filepath.Base(scriptFile), Statement: i + 1, Line: line, Err: err})
+		return &StatementError{File: filepath.Base(scriptFile), Line: line, Query: query, Err: err}
+	}
+
+	result.sql.Write([]byte(query))
+	// Not all drivers report affected rows for every statement.
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		result.rows += n
+	}
 	return nil
 }
@@ -1,7 +1,14 @@
 package goose
 
 import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -38,6 +45,18 @@ func TestSemicolons(t *testing.T) {
 			line:   "END \" ; \" -- comment",
 			result: false,
 		},
+		{
+			line:   "\tSELECT 1;\u00a0",
+			result: true,
+		},
+		{
+			line:   "SELECT 1;--comment",
+			result: false,
+		},
+		{
+			line:   "   ",
+			result: false,
+		},
 	}
 
 	for _, test := range tests {
@@ -80,13 +99,37 @@ func TestSplitStatements(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		stmts, _ := getSQLStatements(strings.NewReader(test.sql), test.direction)
+		stmts, _, _ := getSQLStatements(strings.NewReader(test.sql), test.direction)
 		if len(stmts) != test.count {
 			t.Errorf("incorrect number of stmts. got %v, want %v", len(stmts), test.count)
 		}
 	}
 }
 
+func TestStatementLines(t *testing.T) {
+	sql := `-- +goose Up
+CREATE TABLE post (id int);
+
+-- comment
+INSERT INTO post VALUES (1);
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS int AS $$
+BEGIN RETURN 1; END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+`
+	_, lines, _, _ := parseSQLStatements(packageConfig(), strings.NewReader(sql), true)
+	want := []int{2, 5, 7}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("incorrect statement lines. got %v, want %v", lines, want)
+	}
+
+	err := &StatementError{File: "00001_post.sql", Line: 5, Query: "-- comment\nINSERT INTO post VALUES (1);\n", Err: errors.New("duplicate key")}
+	if got, want := err.Error(), "00001_post.sql:5: duplicate key\n\tINSERT INTO post VALUES (1);"; got != want {
+		t.Errorf("incorrect error. got %q, want %q", got, want)
+	}
+}
+
 func TestUseTransactions(t *testing.T) {
 	type testData struct {
 		fileName        string
@@ -113,7 +156,7 @@ func TestUseTransactions(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
-		_, useTx := getSQLStatements(f, true)
+		_, useTx, _ := getSQLStatements(f, true)
 		if useTx != test.useTransactions {
 			t.Errorf("Failed transaction check. got %v, want %v", useTx, test.useTransactions)
 		}
@@ -121,6 +164,74 @@ func TestUseTransactions(t *testing.T) {
 	}
 }
 
+func TestRedactSQL(t *testing.T) {
+	tests := []struct {
+		sql, want string
+	}{
+		{
+			sql:  "CREATE USER app WITH PASSWORD 'hunter2';",
+			want: "CREATE USER app WITH PASSWORD '***';",
+		},
+		{
+			sql:  "CREATE USER 'app'@'%' IDENTIFIED BY 'hunter2';",
+			want: "CREATE USER 'app'@'%' IDENTIFIED BY '***';",
+		},
+		{
+			sql:  "CREATE SERVER s OPTIONS (host 'db', password 'x', token abc);",
+			want: "CREATE SERVER s OPTIONS (host 'db', password '***', token ***);",
+		},
+		{
+			sql:  "SELECT dblink_connect('postgres://app:hunter2@db/app');",
+			want: "SELECT dblink_connect('postgres://app:***@db/app');",
+		},
+		{
+			sql:  "UPDATE users SET username='admin' WHERE username='root';",
+			want: "UPDATE users SET username='admin' WHERE username='root';",
+		},
+	}
+
+	for _, test := range tests {
+		if got := redactSQL(test.sql); got != test.want {
+			t.Errorf("redactSQL(%q) = %q, want %q", test.sql, got, test.want)
+		}
+	}
+}
+
+func TestGzipMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "00001_multi.sql.gz")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := gz.Write([]byte(multitxt)); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	out.Close()
+
+	if v, err := NumericComponent(path); err != nil || v != 1 {
+		t.Fatalf("NumericComponent(%q) = %v, %v; want 1", path, v, err)
+	}
+
+	f, err := openSQLFile(packageConfig(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stmts, _, _ := getSQLStatements(f, true)
+	if len(stmts) != 2 {
+		t.Errorf("incorrect number of stmts. got %v, want %v", len(stmts), 2)
+	}
+}
+
 var functxt = `-- +goose Up
 CREATE TABLE IF NOT EXISTS histories (
   id                BIGSERIAL  PRIMARY KEY,
@@ -180,3 +291,179 @@ CREATE TABLE fancier_post (
 -- +goose Down
 DROP TABLE fancier_post;
 `
+
+func TestLineScanner(t *testing.T) {
+	long := strings.Repeat("x", 3*readerBufSize)
+	s := newLineScanner(strings.NewReader("a\r\n" + long + "\n\nlast"))
+	var got []string
+	for s.Scan() {
+		got = append(got, string(s.Bytes()))
+	}
+	if s.Err() != nil || len(got) != 4 || got[0] != "a" || got[1] != long || got[2] != "" || got[3] != "last" {
+		t.Errorf("got %d lines, err %v", len(got), s.Err())
+	}
+	s.release()
+
+	defer SetMaxLineSize(maxLineSize)
+	SetMaxLineSize(readerBufSize)
+	s = newLineScanner(strings.NewReader(long))
+	defer s.release()
+	if s.Scan() || s.Err() == nil {
+		t.Error("expected a line too long error")
+	}
+}
+
+func BenchmarkEndsWithSemicolon(b *testing.B) {
+	line := []byte("INSERT INTO users (id, login, email) VALUES (1, 'root', 'root@example.com'); -- the admin")
+	for i := 0; i < b.N; i++ {
+		endsWithSemicolon(line)
+	}
+}
+
+func BenchmarkParseSQLStatements(b *testing.B) {
+	var sql strings.Builder
+	sql.WriteString("-- +goose Up\n")
+	for i := 0; i < 10000; i++ {
+		sql.WriteString("INSERT INTO users (id, login) VALUES (1, 'root'); -- row\n")
+	}
+	sql.WriteString("-- +goose Down\nDELETE FROM users;\n")
+	b.SetBytes(int64(sql.Len()))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		parseSQLStatements(packageConfig(), strings.NewReader(sql.String()), true)
+	}
+}
+
+func TestAnnotationPrefixes(t *testing.T) {
+	sql := `-- +migrate Up notransaction
+CREATE TABLE post (id int);
+-- +migrate StatementBegin
+CREATE FUNCTION f() RETURNS int AS $$
+BEGIN RETURN 1; END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+-- +goose Down
+DROP TABLE post;
+`
+	defer SetAnnotationPrefixes()
+	SetAnnotationPrefixes("-- +migrate")
+
+	stmts, _, tx, _ := parseSQLStatements(packageConfig(), strings.NewReader(sql), true)
+	if len(stmts) != 2 || tx {
+		t.Errorf("got %d statements, tx %v; want 2 statements without transaction", len(stmts), tx)
+	}
+	if stmts, _, _, _ := parseSQLStatements(packageConfig(), strings.NewReader(sql), false); len(stmts) != 1 {
+		t.Errorf("got %d down statements, want 1", len(stmts))
+	}
+	if !hasAnnotation([]byte("-- +migrate AutoDown\n"), "AutoDown") {
+		t.Error("expected the AutoDown annotation to be found")
+	}
+}
+
+func TestDelimiter(t *testing.T) {
+	defer SetDialect("postgres")
+	if err := SetDialect("mysql"); err != nil {
+		t.Fatal(err)
+	}
+	sql := `-- +goose Up
+CREATE TABLE post (id int, updated_at datetime);
+DELIMITER //
+CREATE TRIGGER post_updated BEFORE UPDATE ON post
+FOR EACH ROW
+BEGIN
+  SET NEW.updated_at = NOW();
+END //
+CREATE PROCEDURE touch()
+BEGIN
+  UPDATE post SET updated_at = NOW();
+END
+//
+delimiter ;
+INSERT INTO post VALUES (1, NOW());
+
+-- +goose Down
+DROP TABLE post;
+`
+	stmts, lines, _, _ := parseSQLStatements(packageConfig(), strings.NewReader(sql), true)
+	want := []string{
+		"-- +goose Up\nCREATE TABLE post (id int, updated_at datetime);\n",
+		"CREATE TRIGGER post_updated BEFORE UPDATE ON post\nFOR EACH ROW\nBEGIN\n  SET NEW.updated_at = NOW();\nEND \n",
+		"CREATE PROCEDURE touch()\nBEGIN\n  UPDATE post SET updated_at = NOW();\nEND\n\n",
+		"INSERT INTO post VALUES (1, NOW());\n",
+	}
+	if !reflect.DeepEqual(stmts, want) {
+		t.Errorf("got statements %q, want %q", stmts, want)
+	}
+	if want := []int{2, 4, 9, 15}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("got lines %v, want %v", lines, want)
+	}
+}
+
+func TestDelimiterOutsideStatements(t *testing.T) {
+	defer SetDialect("postgres")
+	sql := `-- +goose Up
+CREATE TABLE csv_format (
+  name text,
+  delimiter char(1),
+  quote char(1)
+);
+DELIMITER ;
+SELECT 1;
+`
+	for _, test := range []struct {
+		dialect string
+		want    []string
+	}{
+		// The column isn't a DELIMITER line, the line after the table is.
+		{"mysql", []string{"-- +goose Up\nCREATE TABLE csv_format (\n  name text,\n  delimiter char(1),\n  quote char(1)\n);\n", "SELECT 1;\n"}},
+		// Other dialects have no DELIMITER lines.
+		{"postgres", []string{"-- +goose Up\nCREATE TABLE csv_format (\n  name text,\n  delimiter char(1),\n  quote char(1)\n);\n", "DELIMITER ;\n", "SELECT 1;\n"}},
+	} {
+		if err := SetDialect(test.dialect); err != nil {
+			t.Fatal(err)
+		}
+		stmts, _, _, _ := parseSQLStatements(packageConfig(), strings.NewReader(sql), true)
+		if !reflect.DeepEqual(stmts, test.want) {
+			t.Errorf("%s: got statements %q, want %q", test.dialect, stmts, test.want)
+		}
+	}
+}
+
+func TestMigrationUpContextCanceled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "00001_index.sql")
+	migration := "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX users_email ON users (email);\n"
+	if err := ioutil.WriteFile(path, []byte(migration), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("goose-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m := &Migration{Version: 1, Next: -1, Previous: -1, Source: path}
+	if err := m.UpContext(ctx, db); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, _, _, err := parseSQLStatements(packageConfig(), strings.NewReader("CREATE TABLE users (id int);\n"), true); err == nil {
+		t.Error("expected an error for a migration without annotations")
+	}
+
+	db, err := sql.Open("goose-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m := &Migration{Version: 1, Next: -1, Previous: -1, Source: "00001_create_users.go"}
+	if err := m.Up(db); err == nil || !strings.Contains(err.Error(), "must be registered") {
+		t.Errorf("got error %v, want an unregistered Go migration error", err)
+	}
+}
@@ -0,0 +1,148 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+var allowMissing = false
+
+// SetAllowMissing sets whether up applies migrations missing from the
+// database, i.e. with a lower version than an applied migration, as when a
+// branch adding one is merged after newer migrations were applied. They
+// are then applied in order, before the new migrations. Otherwise, up
+// leaves them pending, logging a warning.
+func SetAllowMissing(allow bool) {
+	allowMissing = allow
+}
+
+// appliedVersions returns which of versions are applied to db: those whose
+// most recent record isn't a rollback. Without versions, it reads the whole
+// history.
+func appliedVersions(ctx context.Context, db *sql.DB, versions ...int64) (map[int64]bool, error) {
+	table := configFrom(ctx).tables.version()
+	q := fmt.Sprintf("SELECT version_id, is_applied FROM %s ORDER BY id DESC", table)
+	if len(versions) > 0 {
+		q = fmt.Sprintf("SELECT version_id, is_applied FROM %s WHERE version_id IN (%s) ORDER BY id DESC", table, versionList(versions))
+	}
+	rows, err := db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]bool{}
+	for rows.Next() {
+		var row MigrationRecord
+		if err := rows.Scan(&row.VersionID, &row.IsApplied); err != nil {
+			return nil, err
+		}
+		if _, ok := applied[row.VersionID]; !ok {
+			applied[row.VersionID] = row.IsApplied
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for v, ok := range applied {
+		if !ok {
+			delete(applied, v)
+		}
+	}
+	return applied, nil
+}
+
+// versionList formats versions for an IN list.
+func versionList(versions []int64) string {
+	list := make([]string, len(versions))
+	for i, v := range versions {
+		list[i] = fmt.Sprint(v)
+	}
+	return strings.Join(list, ", ")
+}
+
+// pendingMigrations returns the migrations that aren't applied to db, in
+// order. Missing migrations, lower than the current version, are only
+// applied with allowMissing; otherwise they are left pending with a
+// warning. Only the records of migrations in migrations are read, and
+// those below the current version only when some of them were never
+// applied.
+func pendingMigrations(ctx context.Context, db *sql.DB, migrations Migrations) (Migrations, error) {
+	c := configFrom(ctx)
+	// The version table of a pristine database is created first.
+	current, err := ensureDBVersion(ctx, db)
+	if err != nil && err != ErrNoNextVersion {
+		return nil, err
+	}
+
+	var below, above []int64
+	for _, m := range migrations {
+		if m.Version < current {
+			below = append(below, m.Version)
+		} else {
+			above = append(above, m.Version)
+		}
+	}
+
+	applied := map[int64]bool{}
+	if len(above) > 0 {
+		// Migrations above the current version may still be applied out
+		// of order, by an earlier -allow-missing run.
+		if applied, err = appliedVersions(ctx, db, above...); err != nil {
+			return nil, err
+		}
+	}
+	if len(below) > 0 {
+		var n int
+		q := fmt.Sprintf("SELECT COUNT(DISTINCT version_id) FROM %s WHERE is_applied = %s AND version_id IN (%s)", c.tables.version(), c.placeholder(1), versionList(below))
+		if err := db.QueryRowContext(ctx, q, true).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n < len(below) {
+			missing, err := appliedVersions(ctx, db, below...)
+			if err != nil {
+				return nil, err
+			}
+			for v := range missing {
+				applied[v] = true
+			}
+		} else {
+			for _, v := range below {
+				applied[v] = true
+			}
+		}
+	}
+
+	var pending Migrations
+	var missing []string
+	for _, m := range unapplied(migrations, applied) {
+		if m.Version < current {
+			missing = append(missing, fmt.Sprint(m.Version))
+			if !c.allowMissing {
+				continue
+			}
+		}
+		pending = append(pending, m)
+	}
+	switch {
+	case len(missing) == 0:
+	case c.allowMissing:
+		c.log.Printf("goose: applying %d migrations missing before version %d: %s\n", len(missing), current, strings.Join(missing, ", "))
+	default:
+		c.log.Printf("WARNING: %d migrations missing before version %d are not applied: %s; apply them with -allow-missing\n", len(missing), current, strings.Join(missing, ", "))
+	}
+	return pending, nil
+}
+
+// unapplied returns the migrations whose version isn't in applied.
+func unapplied(migrations Migrations, applied map[int64]bool) Migrations {
+	var pending Migrations
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
@@ -0,0 +1,127 @@
+package goose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook receives a notification when a migration run starts, succeeds or
+// fails.
+type Webhook struct {
+	URL string
+	// Slack posts a Slack-formatted message instead of the JSON payload.
+	Slack bool
+}
+
+// RunNotification is the JSON payload posted to webhooks.
+type RunNotification struct {
+	Event      string             `json:"event"` // start, success or failure
+	Command    string             `json:"command"`
+	Database   string             `json:"database,omitempty"`
+	Migrations []AppliedMigration `json:"migrations"`
+	Duration   time.Duration      `json:"duration_ns"`
+	Error      string             `json:"error,omitempty"`
+}
+
+var (
+	webhooks      []Webhook
+	databaseName  string
+	webhookClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// AddWebhook registers a webhook notified of every run changing the
+// database.
+func AddWebhook(w Webhook) {
+	webhooks = append(webhooks, w)
+}
+
+// SetDatabaseName sets the database name reported in notifications.
+func SetDatabaseName(name string) {
+	databaseName = name
+}
+
+// DBName returns the database name of dbstring for the current dialect.
+func DBName(dbstring string) (string, error) {
+	return GetDialect().getDBName(dbstring)
+}
+
+func notifyRunStarted(r *runReport) {
+	notify(RunNotification{Event: "start", Command: r.command})
+}
+
+func notifyRunFinished(r *runReport, err error) {
+	n := RunNotification{
+		Event:      "success",
+		Command:    r.command,
+		Migrations: r.migrations,
+		Duration:   time.Since(r.started),
+	}
+	if err != nil {
+		n.Event = "failure"
+		n.Error = err.Error()
+	}
+	notify(n)
+}
+
+// notify posts n to all webhooks. Delivery failures are logged, they never
+// fail the run.
+func notify(n RunNotification) {
+	if len(webhooks) == 0 {
+		return
+	}
+	n.Database = databaseName
+	if n.Migrations == nil {
+		n.Migrations = []AppliedMigration{}
+	}
+
+	for _, w := range webhooks {
+		var payload interface{} = n
+		if w.Slack {
+			payload = map[string]string{"text": slackMessage(n)}
+		}
+		if err := postJSON(w.URL, payload); err != nil {
+			log.Printf("goose: webhook: %v\n", err)
+		}
+	}
+}
+
+func postJSON(url string, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+func slackMessage(n RunNotification) string {
+	target := "the database"
+	if n.Database != "" {
+		target = "`" + n.Database + "`"
+	}
+
+	var b strings.Builder
+	switch n.Event {
+	case "start":
+		fmt.Fprintf(&b, ":hourglass_flowing_sand: goose %s started on %s", n.Command, target)
+	case "success":
+		fmt.Fprintf(&b, ":white_check_mark: goose %s on %s: %d migrations in %v", n.Command, target, len(n.Migrations), n.Duration.Round(time.Millisecond))
+	default:
+		fmt.Fprintf(&b, ":x: goose %s on %s failed after %d migrations: %s", n.Command, target, len(n.Migrations), n.Error)
+	}
+	for _, m := range n.Migrations {
+		fmt.Fprintf(&b, "\n• %s (%s, %v)", m.File, m.Direction, m.Duration.Round(time.Millisecond))
+	}
+	return b.String()
+}
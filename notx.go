@@ -0,0 +1,117 @@
+package goose
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// autoNoTransaction sets whether migrations with statements that can't run
+// in a transaction run outside of one, or are refused.
+var autoNoTransaction = true
+
+// SetAutoNoTransaction sets whether the migrations with statements the
+// dialect refuses to run in a transaction, such as PostgreSQL's CREATE INDEX
+// CONCURRENTLY or VACUUM, run outside of a transaction (the default), or are
+// refused with a ValidationError until they are marked
+// "-- +goose NO TRANSACTION".
+func SetAutoNoTransaction(auto bool) {
+	autoNoTransaction = auto
+}
+
+// noTxRule matches a statement that can't run in a transaction.
+type noTxRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var (
+	vacuumRule         = noTxRule{"VACUUM", regexp.MustCompile(`(?is)^\s*VACUUM\b`)}
+	createDatabaseRule = noTxRule{"CREATE DATABASE", regexp.MustCompile(`(?is)^\s*CREATE\s+(DATABASE|SCHEMA)\b`)}
+	dropDatabaseRule   = noTxRule{"DROP DATABASE", regexp.MustCompile(`(?is)^\s*DROP\s+(DATABASE|SCHEMA)\b`)}
+
+	postgresNoTxRules = []noTxRule{
+		{"INDEX CONCURRENTLY", concurrentIndexRegexp},
+		{"REINDEX CONCURRENTLY", regexp.MustCompile(`(?is)^\s*REINDEX\s+(\(.*?\)\s*)?\w+\s+CONCURRENTLY\b`)},
+		vacuumRule,
+		{"CREATE DATABASE", regexp.MustCompile(`(?is)^\s*CREATE\s+DATABASE\b`)},
+		{"DROP DATABASE", regexp.MustCompile(`(?is)^\s*DROP\s+DATABASE\b`)},
+		{"CREATE TABLESPACE", regexp.MustCompile(`(?is)^\s*(CREATE|DROP)\s+TABLESPACE\b`)},
+		{"ALTER SYSTEM", regexp.MustCompile(`(?is)^\s*ALTER\s+SYSTEM\b`)},
+	}
+	redshiftNoTxRules = []noTxRule{
+		vacuumRule,
+		{"ALTER TABLE APPEND", regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+\S+\s+APPEND\b`)},
+		{"CREATE DATABASE", regexp.MustCompile(`(?is)^\s*CREATE\s+DATABASE\b`)},
+		{"DROP DATABASE", regexp.MustCompile(`(?is)^\s*DROP\s+DATABASE\b`)},
+	}
+	mysqlNoTxRules = []noTxRule{createDatabaseRule, dropDatabaseRule}
+)
+
+// noTxRules returns the rules of the current dialect.
+func noTxRules(c *config) []noTxRule {
+	switch c.dialect.(type) {
+	case *PostgresDialect:
+		return postgresNoTxRules
+	case *RedshiftDialect:
+		return redshiftNoTxRules
+	case *MySQLDialect, *TiDBDialect:
+		return mysqlNoTxRules
+	}
+	return nil
+}
+
+// noTransactionStatement returns the index of the first statement that
+// can't run in a transaction with the current dialect, and what it is, or
+// -1 if there is none.
+func noTransactionStatement(c *config, statements []string) (int, string) {
+	rules := noTxRules(c)
+	for i, query := range statements {
+		query = stripComments(query)
+		for _, r := range rules {
+			if r.re.MatchString(query) {
+				return i, r.name
+			}
+		}
+	}
+	return -1, ""
+}
+
+// needsTransaction returns whether a migration marked to run in a
+// transaction, useTx, can run in one. Migrations with statements that
+// can't run in a transaction run outside of one or, unless
+// autoNoTransaction, are refused.
+func needsTransaction(c *config, scriptFile string, statements []string, lines []int, useTx bool) (bool, error) {
+	if !useTx {
+		return false, nil
+	}
+	i, name := noTransactionStatement(c, statements)
+	if i < 0 {
+		return true, nil
+	}
+	file := filepath.Base(scriptFile)
+	if !autoNoTransaction {
+		return true, &ValidationError{File: file, Err: fmt.Errorf("%s:%d: %s can't run in a transaction, add \"-- +goose NO TRANSACTION\" to the migration", file, lines[i], name)}
+	}
+	c.log.Printf("goose: %s: running outside of a transaction, as %s at line %d can't run in one\n", file, name, lines[i])
+	return false, nil
+}
+
+// checkTransactions refuses an SQL migration with statements that can't
+// run in a transaction, in either direction, unless it is marked
+// "-- +goose NO TRANSACTION".
+func checkTransactions(c *config, m *Migration) error {
+	if !isSQLMigration(m.Source) {
+		return nil
+	}
+	for _, direction := range []bool{true, false} {
+		statements, lines, useTx, err := readSQLStatements(c, m.Source, direction)
+		if err != nil {
+			return err
+		}
+		if _, err := needsTransaction(c, m.Source, statements, lines, useTx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
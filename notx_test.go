@@ -0,0 +1,47 @@
+package goose
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNoTransactionStatement(t *testing.T) {
+	defer SetDialect("postgres")
+
+	tests := []struct {
+		dialect, query, name string
+	}{
+		{"postgres", "VACUUM ANALYZE users;\n", "VACUUM"},
+		{"postgres", "-- Rebuild.\nREINDEX (VERBOSE) INDEX CONCURRENTLY users_email_idx;\n", "REINDEX CONCURRENTLY"},
+		{"postgres", "CREATE DATABASE reports;\n", "CREATE DATABASE"},
+		{"postgres", "CREATE SCHEMA reports;\n", ""},
+		{"postgres", "REINDEX INDEX users_email_idx;\n", ""},
+		{"redshift", "ALTER TABLE events APPEND FROM staging_events;\n", "ALTER TABLE APPEND"},
+		{"mysql", "CREATE DATABASE IF NOT EXISTS reports;\n", "CREATE DATABASE"},
+		{"mysql", "VACUUM;\n", ""},
+	}
+	for _, test := range tests {
+		SetDialect(test.dialect)
+		if _, name := noTransactionStatement(packageConfig(), []string{"SELECT 1;\n", test.query}); name != test.name {
+			t.Errorf("%s %q: got %q, want %q", test.dialect, test.query, name, test.name)
+		}
+	}
+}
+
+func TestNeedsTransaction(t *testing.T) {
+	statements, lines := []string{"CREATE TABLE t (id int);\n", "VACUUM t;\n"}, []int{2, 3}
+	if useTx, err := needsTransaction(packageConfig(), "00001_vacuum.sql", statements, lines, true); useTx || err != nil {
+		t.Errorf("got %v, %v; want to run outside of a transaction", useTx, err)
+	}
+
+	defer SetAutoNoTransaction(true)
+	SetAutoNoTransaction(false)
+	_, err := needsTransaction(packageConfig(), "00001_vacuum.sql", statements, lines, true)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || err.Error() != `00001_vacuum.sql:3: VACUUM can't run in a transaction, add "-- +goose NO TRANSACTION" to the migration` {
+		t.Errorf("got %v, want a validation error", err)
+	}
+	if useTx, err := needsTransaction(packageConfig(), "00001_vacuum.sql", statements, lines, false); useTx || err != nil {
+		t.Errorf("got %v, %v; want NO TRANSACTION migrations to be accepted", useTx, err)
+	}
+}
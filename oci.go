@@ -0,0 +1,333 @@
+package goose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// OCIPrefix marks a migrations source stored as an OCI artifact.
+const OCIPrefix = "oci://"
+
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	ociTitleAnnotation      = "org.opencontainers.image.title"
+
+	// Registries cap manifests at 4MB; blobs are migrations, not images.
+	maxOCIManifestSize = 4 << 20
+	maxOCIBlobSize     = 256 << 20
+)
+
+type ociRef struct {
+	registry string
+	repo     string
+	ref      string // tag or digest
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// IsOCISource reports whether dir refers to an OCI artifact.
+func IsOCISource(dir string) bool {
+	return strings.HasPrefix(dir, OCIPrefix)
+}
+
+// FetchOCI pulls a migrations bundle published as an OCI artifact, e.g.
+// oci://registry.example.com/team/migrations:v42, verifies the digests of
+// its manifest and layers and unpacks it into a temporary directory, which
+// the caller is responsible for removing. A tag is resolved to the digest
+// the registry reports for it and the manifest checked against that digest;
+// pin the digest in the reference, e.g. migrations:v42@sha256:..., to not
+// trust the registry for the tag.
+//
+// Layers may be tarballs (optionally gzip-compressed) or single files
+// named by the org.opencontainers.image.title annotation, as pushed by oras.
+// Registry credentials are read from GOOSE_OCI_USERNAME and
+// GOOSE_OCI_PASSWORD.
+func FetchOCI(source string) (string, error) {
+	ref, err := parseOCIRef(source)
+	if err != nil {
+		return "", err
+	}
+
+	c := &ociClient{ref: ref}
+	manifest, err := c.manifest()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir("", "goose-oci")
+	if err != nil {
+		return "", err
+	}
+	for _, layer := range manifest.Layers {
+		blob, err := c.blob(layer.Digest)
+		if err == nil {
+			err = unpackOCILayer(dir, layer, blob)
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+func parseOCIRef(source string) (ociRef, error) {
+	s := strings.TrimPrefix(source, OCIPrefix)
+
+	slash := strings.Index(s, "/")
+	if slash < 0 {
+		return ociRef{}, fmt.Errorf("%s: missing repository", source)
+	}
+	ref := ociRef{registry: s[:slash], repo: s[slash+1:], ref: "latest"}
+
+	if at := strings.Index(ref.repo, "@"); at >= 0 {
+		// A digest pins the tag it may follow, e.g. migrations:v42@sha256:...
+		ref.repo, ref.ref = ref.repo[:at], ref.repo[at+1:]
+		if colon := strings.LastIndex(ref.repo, ":"); colon >= 0 {
+			ref.repo = ref.repo[:colon]
+		}
+	} else if colon := strings.LastIndex(ref.repo, ":"); colon >= 0 {
+		ref.repo, ref.ref = ref.repo[:colon], ref.repo[colon+1:]
+	}
+	if ref.repo == "" || ref.ref == "" {
+		return ociRef{}, fmt.Errorf("%s: invalid reference", source)
+	}
+	return ref, nil
+}
+
+type ociClient struct {
+	ref   ociRef
+	token string
+}
+
+func (c *ociClient) url(kind, ref string) string {
+	scheme := "https"
+	if host := strings.Split(c.ref.registry, ":")[0]; host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, c.ref.registry, c.ref.repo, kind, ref)
+}
+
+func (c *ociClient) manifest() (*ociManifest, error) {
+	digest := ""
+	if strings.HasPrefix(c.ref.ref, "sha256:") {
+		digest = c.ref.ref
+	}
+
+	var body []byte
+	if digest != "" {
+		body, _ = readCache(digest)
+	}
+	if body == nil {
+		resp, err := c.get(c.url("manifests", c.ref.ref), ociManifestMediaType+", "+dockerManifestMediaType, maxOCIManifestSize)
+		if err != nil {
+			return nil, err
+		}
+		body = resp.body
+		if digest == "" {
+			// A tag is only as good as the digest it resolves to: check
+			// the manifest against it and say which one runs.
+			digest = resp.header.Get("Docker-Content-Digest")
+			if digest == "" {
+				return nil, fmt.Errorf("manifest %s: registry did not report its digest; pin it in the reference", c.ref.ref)
+			}
+			log.Printf("goose: %s/%s:%s resolved to %s\n", c.ref.registry, c.ref.repo, c.ref.ref, digest)
+		}
+	}
+	if err := verifyDigest(body, digest); err != nil {
+		return nil, fmt.Errorf("manifest %s: %v", c.ref.ref, err)
+	}
+	writeCache(digest, body)
+
+	var m ociManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("manifest %s: %v", c.ref.ref, err)
+	}
+	return &m, nil
+}
+
+func (c *ociClient) blob(digest string) ([]byte, error) {
+	if body, ok := readCache(digest); ok {
+		return body, nil
+	}
+
+	resp, err := c.get(c.url("blobs", digest), "", maxOCIBlobSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDigest(resp.body, digest); err != nil {
+		return nil, fmt.Errorf("blob %s: %v", digest, err)
+	}
+	writeCache(digest, resp.body)
+	return resp.body, nil
+}
+
+type ociResponse struct {
+	header http.Header
+	body   []byte
+}
+
+// get fetches a registry resource of at most max bytes, obtaining a bearer
+// token when challenged.
+func (c *ociClient) get(u, accept string, max int64) (*ociResponse, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, max+1))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) > max {
+			return nil, fmt.Errorf("GET %s: larger than %d bytes", u, max)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if err := c.authenticate(resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+		}
+		return &ociResponse{header: resp.Header, body: body}, nil
+	}
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+func (c *ociClient) authenticate(challenge string) error {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return fmt.Errorf("unsupported registry authentication %q", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+
+	q := url.Values{}
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", "repository:"+c.ref.repo+":pull")
+	req, err := http.NewRequest("GET", params["realm"]+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if user := os.Getenv("GOOSE_OCI_USERNAME"); user != "" {
+		req.SetBasicAuth(user, os.Getenv("GOOSE_OCI_PASSWORD"))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry token request: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	return nil
+}
+
+func verifyDigest(b []byte, digest string) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("unsupported digest %q", digest)
+	}
+	sum := sha256.Sum256(b)
+	if got := "sha256:" + hex.EncodeToString(sum[:]); got != digest {
+		return fmt.Errorf("digest mismatch: got %s, want %s", got, digest)
+	}
+	return nil
+}
+
+func unpackOCILayer(dir string, layer ociDescriptor, blob []byte) error {
+	if title := layer.Annotations[ociTitleAnnotation]; title != "" && !strings.Contains(layer.MediaType, "tar") {
+		return ioutil.WriteFile(filepath.Join(dir, filepath.Base(title)), blob, 0644)
+	}
+
+	var r io.Reader = bytes.NewReader(blob)
+	if strings.Contains(layer.MediaType, "gzip") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("layer %s: %v", layer.Digest, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("layer %s: %v", layer.Digest, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Migrations live in a flat folder; never write outside of it.
+		f, err := os.Create(filepath.Join(dir, filepath.Base(hdr.Name)))
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(f, io.LimitReader(tr, maxOCIBlobSize+1))
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if n > maxOCIBlobSize {
+			return fmt.Errorf("layer %s: %s larger than %d bytes", layer.Digest, hdr.Name, maxOCIBlobSize)
+		}
+	}
+}
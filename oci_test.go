@@ -0,0 +1,179 @@
+package goose
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFetchOCI(t *testing.T) {
+	var layer bytes.Buffer
+	gz := gzip.NewWriter(&layer)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "migrations/00001_init.sql", Mode: 0644, Size: int64(len(multitxt)), Typeflag: tar.TypeReg})
+	tw.Write([]byte(multitxt))
+	tw.Close()
+	gz.Close()
+
+	digest := func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+	layerDigest := digest(layer.Bytes())
+	manifest, _ := json.Marshal(ociManifest{Layers: []ociDescriptor{
+		{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: layerDigest},
+	}})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/team/migrations/manifests/v1", "/v2/team/migrations/manifests/" + digest(manifest):
+			w.Header().Set("Docker-Content-Digest", digest(manifest))
+			w.Write(manifest)
+		case "/v2/team/migrations/blobs/" + layerDigest:
+			w.Write(layer.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	registry := strings.TrimPrefix(srv.URL, "http://")
+	dir, err := FetchOCI("oci://" + registry + "/team/migrations:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "00001_init.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != multitxt {
+		t.Errorf("unexpected migration contents: %q", b)
+	}
+
+	if _, err := FetchOCI("oci://" + registry + "/team/migrations@" + digest([]byte("other"))); err == nil {
+		t.Error("expected fetching an unknown digest to fail")
+	}
+
+	// With a cache, pinned references no longer need the registry.
+	cache, err := ioutil.TempDir("", "goose-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	SetCacheDir(cache)
+	defer SetCacheDir("")
+
+	pinned := "oci://" + registry + "/team/migrations@" + digest(manifest)
+	for i := 0; i < 2; i++ {
+		dir, err := FetchOCI(pinned)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.RemoveAll(dir)
+		srv.Close()
+	}
+}
+
+func TestFetchOCIMismatch(t *testing.T) {
+	digest := func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+	layer := []byte(multitxt)
+	manifest, _ := json.Marshal(ociManifest{Layers: []ociDescriptor{
+		{MediaType: "application/sql", Digest: digest(layer), Annotations: map[string]string{ociTitleAnnotation: "00001_init.sql"}},
+	}})
+	tampered, _ := json.Marshal(ociManifest{Layers: []ociDescriptor{
+		{MediaType: "application/sql", Digest: digest([]byte("DROP TABLE users;")), Annotations: map[string]string{ociTitleAnnotation: "00001_init.sql"}},
+	}})
+
+	// The registry serves other content than requested, claiming it's
+	// the pinned one.
+	served, header := tampered, digest(manifest)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/manifests/"):
+			if header != "" {
+				w.Header().Set("Docker-Content-Digest", header)
+			}
+			w.Write(served)
+		case strings.Contains(r.URL.Path, "/blobs/"):
+			w.Write([]byte("DROP TABLE users;\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	registry := strings.TrimPrefix(srv.URL, "http://")
+
+	for _, source := range []string{
+		"oci://" + registry + "/team/migrations@" + digest(manifest),
+		"oci://" + registry + "/team/migrations:v1@" + digest(manifest),
+		"oci://" + registry + "/team/migrations:v1",
+	} {
+		if dir, err := FetchOCI(source); err == nil || !strings.Contains(err.Error(), "manifest") || !strings.Contains(err.Error(), "digest mismatch") {
+			os.RemoveAll(dir)
+			t.Errorf("%s: got %v, want a manifest digest mismatch", source, err)
+		}
+	}
+
+	// A tag needs the registry to tell its digest.
+	header = ""
+	if dir, err := FetchOCI("oci://" + registry + "/team/migrations:v1"); err == nil || !strings.Contains(err.Error(), "did not report its digest") {
+		os.RemoveAll(dir)
+		t.Errorf("tag without digest: got %v, want an error", err)
+	}
+
+	// Layers are always verified against the manifest.
+	served, header = manifest, digest(manifest)
+	if dir, err := FetchOCI("oci://" + registry + "/team/migrations:v1"); err == nil || !strings.Contains(err.Error(), "blob "+digest(layer)+": digest mismatch") {
+		os.RemoveAll(dir)
+		t.Errorf("tag: got %v, want a blob digest mismatch", err)
+	}
+}
+
+func TestFetchOCITooLarge(t *testing.T) {
+	manifest := bytes.Repeat([]byte(" "), maxOCIManifestSize+1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256(manifest)
+		w.Header().Set("Docker-Content-Digest", "sha256:"+hex.EncodeToString(sum[:]))
+		w.Write(manifest)
+	}))
+	defer srv.Close()
+
+	registry := strings.TrimPrefix(srv.URL, "http://")
+	if dir, err := FetchOCI("oci://" + registry + "/team/migrations:v1"); err == nil || !strings.Contains(err.Error(), "larger than") {
+		os.RemoveAll(dir)
+		t.Errorf("got %v, want a size error", err)
+	}
+}
+
+func TestParseOCIRef(t *testing.T) {
+	tests := []struct {
+		source string
+		want   ociRef
+	}{
+		{"oci://ghcr.io/team/migrations:v1", ociRef{"ghcr.io", "team/migrations", "v1"}},
+		{"oci://localhost:5000/migrations", ociRef{"localhost:5000", "migrations", "latest"}},
+		{"oci://ghcr.io/team/migrations@sha256:abcd", ociRef{"ghcr.io", "team/migrations", "sha256:abcd"}},
+		{"oci://localhost:5000/migrations:v1@sha256:abcd", ociRef{"localhost:5000", "migrations", "sha256:abcd"}},
+	}
+	for _, test := range tests {
+		got, err := parseOCIRef(test.source)
+		if err != nil || got != test.want {
+			t.Errorf("parseOCIRef(%q) = %+v, %v; want %+v", test.source, got, err, test.want)
+		}
+	}
+}
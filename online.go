@@ -0,0 +1,155 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Online schema change tools, declared by a migration with
+// "-- +goose Online gh-ost" or "-- +goose Online pt-osc".
+const (
+	GhOst = "gh-ost"
+	PtOSC = "pt-osc"
+)
+
+// OnlineOptions configures the online schema change tools.
+type OnlineOptions struct {
+	GhOstPath string   // gh-ost binary, "gh-ost" by default
+	GhOstArgs []string // extra arguments, e.g. --host, --user, --password or --allow-on-master
+	PtOSCPath string   // pt-online-schema-change binary, "pt-online-schema-change" by default
+	PtOSCArgs []string // extra arguments, e.g. --host, --user or --password
+}
+
+var onlineOptions OnlineOptions
+
+// SetOnlineOptions configures how gh-ost and pt-online-schema-change run
+// the ALTER TABLE statements of online migrations.
+func SetOnlineOptions(o OnlineOptions) {
+	onlineOptions = o
+}
+
+// onlineTool returns the tool a migration file declares, if any.
+func onlineTool(c *config, path string) (string, error) {
+	tool, err := readAnnotation(c, path, "Online")
+	if err != nil || tool == "" {
+		return "", err
+	}
+	if tool != GhOst && tool != PtOSC {
+		return "", &ValidationError{File: filepath.Base(path), Err: fmt.Errorf("%q: unknown online schema change tool, must be gh-ost or pt-osc", tool)}
+	}
+	return tool, nil
+}
+
+// readAnnotation returns the value of the first "-- +goose NAME VALUE"
+// annotation of a migration file, or "" if there is none.
+func readAnnotation(c *config, path, name string) (string, error) {
+	values, err := readAnnotations(c, path, name)
+	if err != nil || len(values) == 0 {
+		return "", err
+	}
+	return values[0], nil
+}
+
+// readAnnotations returns the values of all the "-- +goose NAME VALUE"
+// annotations of a migration file.
+func readAnnotations(c *config, path, name string) ([]string, error) {
+	f, err := openSQLFile(c, path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values []string
+	prefix := []byte(name + " ")
+	scanner := newLineScanner(f)
+	defer scanner.release()
+	for scanner.Scan() {
+		if cmd, ok := annotationCommand(bytes.TrimSpace(scanner.Bytes())); ok && bytes.HasPrefix(cmd, prefix) {
+			values = append(values, string(bytes.TrimSpace(cmd[len(prefix):])))
+		}
+	}
+	return values, scanner.Err()
+}
+
+var alterTableRegexp = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+` + "`?" + `([\w.]+)` + "`?" + `\s+(.+?)\s*;?\s*$`)
+
+// runOnlineMigration runs the ALTER TABLE statements of a migration through
+// tool, and the other statements directly. As the tools copy the table in
+// the background, the migration runs outside of a transaction.
+func runOnlineMigration(ctx context.Context, db *sql.DB, tool, scriptFile string, v int64, direction bool, statements []string, lines []int) (execResult, error) {
+	c := configFrom(ctx)
+	result := newExecResult()
+	if _, ok := c.dialect.(*MySQLDialect); !ok {
+		return result, fmt.Errorf("%s: online schema changes require MySQL", filepath.Base(scriptFile))
+	}
+
+	progress := newProgress(ctx, v, filepath.Base(scriptFile), len(statements))
+	for i, query := range statements {
+		m := alterTableRegexp.FindStringSubmatch(stripComments(query))
+		if m == nil {
+			if err := execStatement(ctx, db, scriptFile, v, i, lines[i], query, &result); err != nil {
+				return result, err
+			}
+			progress.done(i + 1)
+			continue
+		}
+
+		logStatement(c, filepath.Base(scriptFile), i, query)
+		if err := runOnlineTool(ctx, tool, m[1], m[2]); err != nil {
+			emit(ctx, StatementFailed{Version: v, File: filepath.Base(scriptFile), Statement: i + 1, Line: lines[i], Err: err})
+			return result, &StatementError{File: filepath.Base(scriptFile), Line: lines[i], Query: query, Err: err}
+		}
+		result.sql.Write([]byte(query))
+		progress.done(i + 1)
+	}
+	if _, err := db.ExecContext(ctx, c.insertVersionSQL(), v, direction); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// runOnlineTool alters table, optionally qualified with its database, with
+// tool.
+func runOnlineTool(ctx context.Context, tool, table, alter string) error {
+	database := databaseName
+	if i := strings.Index(table, "."); i >= 0 {
+		database, table = table[:i], table[i+1:]
+	}
+	if database == "" {
+		return fmt.Errorf("%s: unknown database, qualify the table name", tool)
+	}
+
+	var name string
+	var args []string
+	switch tool {
+	case GhOst:
+		name = onlineOptions.GhOstPath
+		if name == "" {
+			name = "gh-ost"
+		}
+		args = append([]string{"--database=" + database, "--table=" + table, "--alter=" + alter, "--execute"}, onlineOptions.GhOstArgs...)
+	case PtOSC:
+		name = onlineOptions.PtOSCPath
+		if name == "" {
+			name = "pt-online-schema-change"
+		}
+		args = append([]string{"--alter=" + alter, "--execute"}, onlineOptions.PtOSCArgs...)
+		args = append(args, fmt.Sprintf("D=%s,t=%s", database, table))
+	}
+
+	configFrom(ctx).log.Printf("goose: running %s on %s.%s\n", name, database, table)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
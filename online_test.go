@@ -0,0 +1,71 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAlterTableRegexp(t *testing.T) {
+	tests := []struct {
+		query        string
+		table, alter string
+	}{
+		{"ALTER TABLE events ADD COLUMN source VARCHAR(64) NULL;\n", "events", "ADD COLUMN source VARCHAR(64) NULL"},
+		{"alter table `app.events`\n  DROP COLUMN source,\n  ADD INDEX (created_at);", "app.events", "DROP COLUMN source,\n  ADD INDEX (created_at)"},
+		{"UPDATE events SET source = 'api';", "", ""},
+	}
+	for _, test := range tests {
+		m := alterTableRegexp.FindStringSubmatch(test.query)
+		switch {
+		case m == nil && test.table != "":
+			t.Errorf("%q: no match", test.query)
+		case m != nil && (m[1] != test.table || m[2] != test.alter):
+			t.Errorf("%q: got %q, %q, want %q, %q", test.query, m[1], m[2], test.table, test.alter)
+		}
+	}
+}
+
+func TestOnlineTool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-online")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := map[string]string{
+		"-- +goose Online gh-ost\n-- +goose Up\nALTER TABLE t ADD c int;\n": GhOst,
+		"-- +goose Online pt-osc\n-- +goose Up\nALTER TABLE t ADD c int;\n": PtOSC,
+		"-- +goose Up\nALTER TABLE t ADD c int;\n":                          "",
+	}
+	for sql, expected := range tests {
+		path := filepath.Join(dir, "00001_alter.sql")
+		if err := ioutil.WriteFile(path, []byte(sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if tool, err := onlineTool(packageConfig(), path); err != nil || tool != expected {
+			t.Errorf("%q: got %q, %v, want %q", sql, tool, err, expected)
+		}
+	}
+}
+
+func TestOnlineToolLongLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-online")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A line longer than the 64KB a bufio.Scanner accepts.
+	insert := "INSERT INTO t VALUES " + strings.Repeat("(1, 'goose'), ", 200*1024/14) + "(1, 'goose');\n"
+	path := filepath.Join(dir, "00001_seed.sql")
+	sql := "-- +goose Up\n" + insert + "-- +goose Online gh-ost\nALTER TABLE t ADD c int;\n"
+	if err := ioutil.WriteFile(path, []byte(sql), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if tool, err := onlineTool(packageConfig(), path); err != nil || tool != GhOst {
+		t.Errorf("got %q, %v, want %q", tool, err, GhOst)
+	}
+}
@@ -0,0 +1,23 @@
+package goose
+
+// options configure a single run.
+type options struct {
+	eventHandler func(Event)
+	plan         *Plan
+	noLock       bool
+}
+
+// OptionsFunc configures a run started with RunWithOptions.
+type OptionsFunc func(o *options)
+
+// WithEventHandler receives the events of the run, so that applications
+// embedding goose can forward its progress to their own telemetry.
+func WithEventHandler(h func(Event)) OptionsFunc {
+	return func(o *options) { o.eventHandler = h }
+}
+
+// WithPlan makes the apply command run plan, instead of reading it from the
+// file given as argument.
+func WithPlan(plan *Plan) OptionsFunc {
+	return func(o *options) { o.plan = plan }
+}
@@ -0,0 +1,36 @@
+package goose
+
+import (
+	"strings"
+	"text/template"
+)
+
+// Paired migrations are written as two files per version, in golang-migrate
+// style: NNN_name.up.sql and NNN_name.down.sql, with neither Up nor Down
+// annotations. The up file is the migration's Source.
+
+// isPairedMigration reports whether path is the up file of a paired
+// migration.
+func isPairedMigration(path string) bool {
+	return strings.HasSuffix(path, migrateUpExt)
+}
+
+// pairedDownFile returns the down file of a paired migration.
+func pairedDownFile(up string) string {
+	return strings.TrimSuffix(up, migrateUpExt) + migrateDownExt
+}
+
+// pairedFile returns the file holding the statements of one direction of a
+// paired migration. Migrations are only collected with both files.
+func pairedFile(up string, direction bool) string {
+	if direction {
+		return up
+	}
+	return pairedDownFile(up)
+}
+
+var pairedUpTemplate = template.Must(template.New("goose.paired-up").Parse(`-- SQL in this file is executed when the migration is applied.
+`))
+
+var pairedDownTemplate = template.Must(template.New("goose.paired-down").Parse(`-- SQL in this file is executed when the migration is rolled back.
+`))
@@ -0,0 +1,89 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPairedMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-paired")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"00001_users.sql":      "-- +goose Up\nCREATE TABLE users (id int);\n-- +goose Down\nDROP TABLE users;\n",
+		"00002_roles.up.sql":   "CREATE TABLE roles (id int);\n\nCREATE INDEX roles_id ON roles (id);\n",
+		"00002_roles.down.sql": "DROP TABLE roles;\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || filepath.Base(migrations[1].Source) != "00002_roles.up.sql" {
+		t.Fatalf("got %v", migrations)
+	}
+
+	up, lines, _, err := readSQLStatements(packageConfig(), migrations[1].Source, true)
+	if err != nil || len(up) != 2 || lines[1] != 3 {
+		t.Errorf("up: got %q, lines %v, %v", up, lines, err)
+	}
+	down, _, _, err := readSQLStatements(packageConfig(), migrations[1].Source, false)
+	if err != nil || len(down) != 1 || down[0] != "DROP TABLE roles;\n" {
+		t.Errorf("down: got %q, %v", down, err)
+	}
+
+	// The lock covers the down file.
+	if err := WriteLock(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "00002_roles.down.sql"), []byte("DROP TABLE IF EXISTS roles;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyLock(dir); err == nil || !strings.Contains(err.Error(), "00002_roles.up.sql has changed") {
+		t.Errorf("got %v, want the changed down file reported", err)
+	}
+	os.Remove(filepath.Join(dir, LockFile))
+
+	for name, want := range map[string]string{
+		"00003_backfill.up.sql": "00003_backfill.up.sql: no matching .down.sql file",
+		"00004_orphan.down.sql": "00004_orphan.down.sql: no matching .up.sql file",
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte("SELECT 1;\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CollectMigrations(dir, minVersion, maxVersion); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %q", err, want)
+		}
+		os.Remove(path)
+	}
+}
+
+func TestPairedStreamMigrations(t *testing.T) {
+	defer ReadStream(strings.NewReader(""))
+	stream := streamFileMarker + "00001_roles.up.sql\nCREATE TABLE roles (id int);\n" +
+		streamFileMarker + "00001_roles.down.sql\nDROP TABLE roles;\n"
+	if err := ReadStream(strings.NewReader(stream)); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := CollectMigrations(StreamDir, minVersion, maxVersion)
+	if err != nil || len(migrations) != 1 {
+		t.Fatalf("got %v, %v, want the up file only", migrations, err)
+	}
+	down, _, _, err := readSQLStatements(packageConfig(), migrations[0].Source, false)
+	if err != nil || len(down) != 1 || down[0] != "DROP TABLE roles;\n" {
+		t.Errorf("down: got %q, %v", down, err)
+	}
+}
@@ -0,0 +1,101 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"path/filepath"
+)
+
+// Deployment phases, declared by a migration with "-- +goose Phase expand"
+// or "-- +goose Phase contract". Untagged migrations are expand migrations.
+const (
+	PhaseExpand   = "expand"
+	PhaseContract = "contract"
+)
+
+// migrationPhase returns the phase of a migration. Go migrations are expand
+// migrations.
+func migrationPhase(c *config, m *Migration) (string, error) {
+	if !isSQLMigration(m.Source) {
+		return PhaseExpand, nil
+	}
+	phase, err := readAnnotation(c, m.Source, "Phase")
+	switch {
+	case err != nil:
+		return "", err
+	case phase == "":
+		return PhaseExpand, nil
+	case phase != PhaseExpand && phase != PhaseContract:
+		return "", &ValidationError{File: filepath.Base(m.Source), Err: fmt.Errorf("%q: unknown phase, must be expand or contract", phase)}
+	}
+	return phase, nil
+}
+
+// UpPhase applies the pending migrations of a deployment phase, in order,
+// stopping at the first migration of the other phase: expand migrations run
+// before deploying new code, contract migrations once the old code is gone.
+func UpPhase(db *sql.DB, dir, phase string) error {
+	return upPhase(context.Background(), db, dir, phase)
+}
+
+func upPhase(ctx context.Context, db *sql.DB, dir, phase string) error {
+	if phase != PhaseExpand && phase != PhaseContract {
+		return fmt.Errorf("%q: unknown phase, must be expand or contract", phase)
+	}
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	if err := checkVersionPolicy(migrations, false); err != nil {
+		return err
+	}
+
+	pending, err := pendingMigrations(ctx, db, migrations)
+	if err != nil {
+		return err
+	}
+	// The phase runs up to the first migration of another phase.
+	run := pending
+	var next *Migration
+	var nextPhase string
+	for i, m := range pending {
+		p, err := migrationPhase(c, m)
+		if err != nil {
+			return err
+		}
+		if p != phase {
+			run, next, nextPhase = pending[:i], m, p
+			break
+		}
+	}
+	if err := verifyChecksums(c, run); err != nil {
+		return err
+	}
+	for _, m := range run {
+		if err := m.up(ctx, db); err != nil {
+			return err
+		}
+	}
+
+	current, err := GetDBVersionContext(ctx, db)
+	if err != nil {
+		return err
+	}
+	if next != nil {
+		c.log.Printf("goose: %s phase done, %s is a %s migration. current version: %d\n", phase, filepath.Base(next.Source), nextPhase, current)
+		return nil
+	}
+	c.log.Printf("goose: no migrations to run. current version: %d\n", current)
+	return nil
+}
+
+// parseUpArgs parses the -phase flag of the up command.
+func parseUpArgs(args []string) (phase string, err error) {
+	fs := flag.NewFlagSet("up", flag.ContinueOnError)
+	fs.StringVar(&phase, "phase", "", "only apply the migrations of this phase, expand or contract")
+	err = fs.Parse(args)
+	return phase, err
+}
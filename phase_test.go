@@ -0,0 +1,40 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrationPhase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-phase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		sql   string
+		phase string
+		err   bool
+	}{
+		{"-- +goose Up\nALTER TABLE users ADD COLUMN email text;\n", PhaseExpand, false},
+		{"-- +goose Phase contract\n-- +goose Up\nALTER TABLE users DROP COLUMN login;\n", PhaseContract, false},
+		{"-- +goose Phase cleanup\n-- +goose Up\nSELECT 1;\n", "", true},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, "00001_users.sql")
+		if err := ioutil.WriteFile(path, []byte(test.sql), 0644); err != nil {
+			t.Fatal(err)
+		}
+		phase, err := migrationPhase(packageConfig(), &Migration{Version: 1, Source: path})
+		if phase != test.phase || (err != nil) != test.err {
+			t.Errorf("%q: got %q, %v", test.sql, phase, err)
+		}
+	}
+
+	if phase, err := migrationPhase(packageConfig(), &Migration{Version: 2, Source: "00002_backfill.go"}); phase != PhaseExpand || err != nil {
+		t.Errorf("Go migration: got %q, %v", phase, err)
+	}
+}
@@ -0,0 +1,274 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Plan lists the migrations a command would run, in order. Its JSON form can
+// be persisted and approved by deploy tooling, then executed with ApplyPlan.
+type Plan struct {
+	Command        string             `json:"command"`
+	CurrentVersion int64              `json:"current_version"`
+	TargetVersion  int64              `json:"target_version"`
+	Migrations     []PlannedMigration `json:"migrations"`
+}
+
+// PlannedMigration is a step of a Plan.
+type PlannedMigration struct {
+	Version       int64  `json:"version"`
+	File          string `json:"file"`
+	Direction     string `json:"direction"` // up or down
+	Go            bool   `json:"go,omitempty"`
+	Phase         string `json:"phase"` // expand or contract
+	NoTransaction bool   `json:"no_transaction"`
+	Statements    int    `json:"statements"` // SQL statements, 0 for Go migrations
+	SHA256        string `json:"sha256,omitempty"`
+}
+
+// GetPlan returns the plan of command, one of up, up-to VERSION, down or
+// down-to VERSION, against the current version of db. Nothing is executed.
+func GetPlan(db *sql.DB, dir, command string, args ...string) (*Plan, error) {
+	return GetPlanContext(context.Background(), db, dir, command, args...)
+}
+
+// GetPlanContext is GetPlan with a context, canceling its queries.
+func GetPlanContext(ctx context.Context, db *sql.DB, dir, command string, args ...string) (*Plan, error) {
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	current, err := GetDBVersionContext(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Command: command, CurrentVersion: current, TargetVersion: current, Migrations: []PlannedMigration{}}
+	var steps Migrations
+	switch {
+	case command == "up" && len(args) == 0:
+		plan.TargetVersion = maxVersion
+	case command == "up-to" && len(args) == 1:
+		if plan.TargetVersion, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+			return nil, fmt.Errorf("version must be a number (got '%s')", args[0])
+		}
+	case command == "down-to" && len(args) == 1:
+		if plan.TargetVersion, err = parseDownTarget(args[0]); err != nil {
+			return nil, err
+		}
+	case command == "down" && len(args) == 0:
+		m, err := migrations.Current(current)
+		if err != nil {
+			return nil, fmt.Errorf("no migration %v", current)
+		}
+		steps = Migrations{m}
+	default:
+		return nil, fmt.Errorf("plan must be of form: goose [OPTIONS] plan [-format=F] up | up-to VERSION | down | down-to VERSION")
+	}
+
+	switch command {
+	case "up", "up-to":
+		pending, err := pendingMigrations(ctx, db, migrations)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range pending {
+			if m.Version <= plan.TargetVersion {
+				steps = append(steps, m)
+			}
+		}
+	case "down-to":
+		for _, m := range migrations {
+			if m.Version > plan.TargetVersion && m.Version <= current {
+				steps = append(steps, m)
+			}
+		}
+		sort.Sort(sort.Reverse(steps))
+	}
+
+	direction := command == "up" || command == "up-to"
+	for _, m := range steps {
+		step, err := planMigration(c, m, direction)
+		if err != nil {
+			return nil, err
+		}
+		plan.Migrations = append(plan.Migrations, step)
+	}
+
+	switch {
+	case len(plan.Migrations) == 0:
+		plan.TargetVersion = current
+	case direction:
+		plan.TargetVersion = steps[len(steps)-1].Version
+	case command == "down":
+		plan.TargetVersion = 0
+		if steps[0].Previous > 0 {
+			plan.TargetVersion = steps[0].Previous
+		}
+	}
+	return plan, nil
+}
+
+func planMigration(c *config, m *Migration, direction bool) (PlannedMigration, error) {
+	step := PlannedMigration{Version: m.Version, File: filepath.Base(m.Source), Direction: "up"}
+	if !direction {
+		step.Direction = "down"
+	}
+	if !isSQLMigration(m.Source) {
+		step.Go, step.NoTransaction = true, m.NoTx
+	}
+	phase, err := migrationPhase(c, m)
+	if err != nil {
+		return step, err
+	}
+	step.Phase = phase
+
+	// Registered Go migrations may have been compiled elsewhere.
+	if _, err := c.statFile(m.Source); err != nil {
+		return step, nil
+	}
+	sum, err := migrationSHA256(c, m.Source)
+	if err != nil {
+		return step, err
+	}
+	step.SHA256 = sum
+
+	if step.Go {
+		return step, nil
+	}
+	statements, _, useTx, err := readSQLStatements(c, m.Source, direction)
+	if err != nil {
+		return step, fmt.Errorf("%s: %v", step.File, err)
+	}
+	step.Statements = len(statements)
+	i, _ := noTransactionStatement(c, statements)
+	step.NoTransaction = !useTx || i >= 0
+
+	// Online schema changes run outside of a transaction.
+	tool, err := onlineTool(c, m.Source)
+	if tool != "" {
+		step.NoTransaction = true
+	}
+	return step, err
+}
+
+// ApplyPlan executes plan, after checking that the database is still at the
+// version it was made for and that its migration files didn't change.
+func ApplyPlan(db *sql.DB, dir string, plan *Plan) error {
+	return applyPlan(context.Background(), db, dir, plan)
+}
+
+func applyPlan(ctx context.Context, db *sql.DB, dir string, plan *Plan) error {
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	current, err := GetDBVersionContext(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current != plan.CurrentVersion {
+		return fmt.Errorf("plan was made at version %d, the database is at version %d", plan.CurrentVersion, current)
+	}
+
+	steps := make([]*Migration, len(plan.Migrations))
+	for i, p := range plan.Migrations {
+		m, err := migrations.Current(p.Version)
+		if err != nil || filepath.Base(m.Source) != p.File {
+			return fmt.Errorf("planned migration %s not found", p.File)
+		}
+		step, err := planMigration(c, m, p.Direction == "up")
+		if err != nil {
+			return err
+		}
+		if step.SHA256 != p.SHA256 {
+			return fmt.Errorf("%s changed since the plan was made", p.File)
+		}
+		steps[i] = m
+	}
+	if err := verifyChecksums(c, steps); err != nil {
+		return err
+	}
+
+	for i, m := range steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if plan.Migrations[i].Direction == "up" {
+			err = m.up(ctx, db)
+		} else {
+			err = m.down(ctx, db)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadPlan reads a plan written by the plan command with -format=json.
+func ReadPlan(path string) (*Plan, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	if err := json.Unmarshal(b, &plan); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &plan, nil
+}
+
+// printPlan implements the plan command: plan [-format=text|json] COMMAND
+// [VERSION].
+func printPlan(w io.Writer, db *sql.DB, dir string, args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	format := fs.String("format", "text", "text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	command := "up"
+	if fs.NArg() > 0 {
+		command = fs.Arg(0)
+	}
+	var cmdArgs []string
+	if fs.NArg() > 1 {
+		cmdArgs = fs.Args()[1:]
+	}
+
+	plan, err := GetPlan(db, dir, command, cmdArgs...)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	case "text":
+		fmt.Fprintf(w, "goose plan: %s, version %d -> %d\n", plan.Command, plan.CurrentVersion, plan.TargetVersion)
+		for _, m := range plan.Migrations {
+			details := fmt.Sprintf("%d statements", m.Statements)
+			switch {
+			case m.Go:
+				details = "Go"
+			case m.NoTransaction:
+				details += ", no transaction"
+			}
+			fmt.Fprintf(w, "    %-5s %s (%s)\n", m.Direction, m.File, details)
+		}
+		return nil
+	}
+	return fmt.Errorf("%q: unknown format, must be text or json", *format)
+}
@@ -0,0 +1,45 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "00002_add_index.sql")
+	sql := `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY users_email ON users (email);
+ANALYZE users;
+
+-- +goose Down
+DROP INDEX CONCURRENTLY users_email;
+`
+	if err := ioutil.WriteFile(path, []byte(sql), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Migration{Version: 2, Source: path}
+	up, err := planMigration(packageConfig(), m, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up.Direction != "up" || up.Statements != 2 || !up.NoTransaction || up.SHA256 == "" {
+		t.Errorf("unexpected up step %+v", up)
+	}
+	down, err := planMigration(packageConfig(), m, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if down.Direction != "down" || down.Statements != 1 || down.SHA256 != up.SHA256 {
+		t.Errorf("unexpected down step %+v", down)
+	}
+}
@@ -0,0 +1,71 @@
+package goose
+
+import (
+	"context"
+	"time"
+)
+
+// progressInterval is the minimum delay between two progress reports of a
+// non-transactional migration.
+var progressInterval = 10 * time.Second
+
+// progress reports how far a non-transactional migration got, so that a
+// long backfill can be told apart from a hung one.
+type progress struct {
+	ctx     context.Context
+	version int64
+	file    string
+	total   int
+	last    time.Time
+}
+
+func newProgress(ctx context.Context, version int64, file string, total int) *progress {
+	return &progress{ctx: ctx, version: version, file: file, total: total, last: time.Now()}
+}
+
+// done is called after the n-th statement (1-based) completed. Every
+// statement is reported to the event handler, logs are throttled.
+func (p *progress) done(n int) {
+	emit(p.ctx, MigrationProgress{Version: p.version, File: p.file, Statement: n, Total: p.total})
+
+	if n == p.total || time.Since(p.last) < progressInterval {
+		return
+	}
+	p.last = time.Now()
+	configFrom(p.ctx).log.Printf("goose: %s: statement %d/%d (%d%%)\n", p.file, n, p.total, n*100/p.total)
+}
+
+var heartbeatInterval = 30 * time.Second
+
+// SetHeartbeat sets how often a statement still running is logged, 30s by
+// default. It keeps CI jobs that kill silent steps from aborting a long
+// statement. Zero disables the heartbeat.
+func SetHeartbeat(d time.Duration) {
+	heartbeatInterval = d
+}
+
+// startHeartbeat logs the statement periodically until the returned function
+// is called.
+func startHeartbeat(c *config, file string, statement int) (stop func()) {
+	if heartbeatInterval <= 0 {
+		return func() {}
+	}
+
+	started := time.Now()
+	ticker := time.NewTicker(heartbeatInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				c.log.Printf("goose: still executing %s statement %d (%v elapsed)\n", file, statement, time.Since(started).Round(time.Second))
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
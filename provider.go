@@ -0,0 +1,128 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"strconv"
+)
+
+// Provider migrates a database with its own dialect, migrations folder,
+// version table, logger and flags, so that a process can migrate several
+// databases, e.g. a PostgreSQL and a MySQL one, without their settings
+// interfering. Providers don't touch the package settings: they may run
+// concurrently with each other and with package-level functions such as
+// Run.
+//
+// The other package settings, e.g. SetStoreDownSQL, SetMigrationLock,
+// SetEnvironment and the notification hooks, apply to Providers too.
+type Provider struct {
+	db  *sql.DB
+	dir string
+	cfg *config
+}
+
+// ProviderOptions configures a Provider.
+type ProviderOptions struct {
+	TableName   string // version table, goose_db_version by default
+	TableSchema string // schema of the version table, the current schema by default
+	Logger      Logger // goose's output, the package logger by default
+	BaseFS      fs.FS  // filesystem dir is read from, as SetBaseFS
+
+	AllowMissing bool          // as SetAllowMissing
+	Strict       bool          // as SetStrict
+	DryRun       bool          // as SetDryRun
+	Verbose      bool          // as SetVerbose
+	Audit        *AuditOptions // as EnableAudit, no audit log if nil
+	RunContext   *RunContext   // as SetRunContext, no run context recorded if nil
+}
+
+// NewProvider returns a Provider migrating db with the migrations in dir.
+// dialect is one of the names SetDialect accepts.
+func NewProvider(dialect string, db *sql.DB, dir string, opts ProviderOptions) (*Provider, error) {
+	d, err := lookupDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TableName == "" {
+		opts.TableName = defaultTableName
+	}
+	if opts.Logger == nil {
+		opts.Logger = log
+	}
+
+	p := &Provider{db: db, dir: dir}
+	p.cfg = &config{
+		provider:     p,
+		dialect:      d,
+		tables:       gooseTables{name: opts.TableName, schema: opts.TableSchema},
+		log:          opts.Logger,
+		fsys:         opts.BaseFS,
+		allowMissing: opts.AllowMissing,
+		strict:       opts.Strict,
+		dryRun:       opts.DryRun,
+		verbose:      opts.Verbose,
+	}
+	if opts.Audit != nil {
+		p.cfg.audit = auditOptions(*opts.Audit)
+	}
+	if opts.RunContext != nil {
+		p.cfg.runContext = defaultRunContext(*opts.RunContext)
+	}
+	return p, nil
+}
+
+// providerCommands are the commands a Provider runs, the others only using
+// the package settings.
+var providerCommands = map[string]bool{
+	"up":        true,
+	"up-by-one": true,
+	"up-to":     true,
+	"down":      true,
+	"down-to":   true,
+	"redo":      true,
+	"reset":     true,
+	"status":    true,
+	"pending":   true,
+	"version":   true,
+}
+
+// Run runs a goose command, as RunWithContext. Only the commands applying,
+// rolling back and reporting migrations are supported.
+func (p *Provider) Run(ctx context.Context, command string, args ...string) error {
+	if !providerCommands[command] {
+		return fmt.Errorf("%q: not supported by a Provider", command)
+	}
+	return runWithOptions(withConfig(ctx, p.cfg), command, p.db, p.dir, args)
+}
+
+// Up applies all available migrations.
+func (p *Provider) Up(ctx context.Context) error {
+	return p.Run(ctx, "up")
+}
+
+// UpTo migrates up to a specific version.
+func (p *Provider) UpTo(ctx context.Context, version int64) error {
+	return p.Run(ctx, "up-to", strconv.FormatInt(version, 10))
+}
+
+// Down rolls back a single migration from the current version.
+func (p *Provider) Down(ctx context.Context) error {
+	return p.Run(ctx, "down")
+}
+
+// DownTo rolls back migrations to a specific version.
+func (p *Provider) DownTo(ctx context.Context, version int64) error {
+	return p.Run(ctx, "down-to", strconv.FormatInt(version, 10))
+}
+
+// Status returns the status of every migration.
+func (p *Provider) Status(ctx context.Context) ([]MigrationStatus, error) {
+	return GetStatusContext(withConfig(ctx, p.cfg), p.db, p.dir)
+}
+
+// Version returns the current version of the database.
+func (p *Provider) Version(ctx context.Context) (int64, error) {
+	return GetDBVersionContext(withConfig(ctx, p.cfg), p.db)
+}
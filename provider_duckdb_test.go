@@ -0,0 +1,71 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProvidersConcurrent(t *testing.T) {
+	ctx := context.Background()
+	users, orders := openDuckDB(t), openDuckDB(t)
+	SetDialect("postgres")
+
+	p1, err := NewProvider("duckdb", users, "migrations", ProviderOptions{
+		TableName: "users_db_version",
+		BaseFS: fstest.MapFS{
+			"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n-- +goose Down\nDROP TABLE users;\n")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := NewProvider("duckdb", orders, "migrations", ProviderOptions{
+		TableName: "orders_db_version",
+		BaseFS: fstest.MapFS{
+			"migrations/00001_orders.sql": {Data: []byte("-- +goose Up\nCREATE TABLE orders (id int);\n-- +goose Down\nDROP TABLE orders;\n")},
+			"migrations/00002_status.sql": {Data: []byte("-- +goose Up\nALTER TABLE orders ADD status text;\n-- +goose Down\nALTER TABLE orders DROP status;\n")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 2)
+	for _, p := range []*Provider{p1, p2} {
+		go func() { errs <- p.Up(ctx) }()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		p       *Provider
+		db      *sql.DB
+		table   string
+		version int64
+	}{{p1, users, "users_db_version", 1}, {p2, orders, "orders_db_version", 2}} {
+		if v, err := test.p.Version(ctx); err != nil || v != test.version {
+			t.Errorf("%s: got version %d, %v; want %d", test.table, v, err, test.version)
+		}
+		statuses, err := test.p.Status(ctx)
+		if err != nil || len(statuses) != int(test.version) {
+			t.Errorf("%s: got statuses %+v, %v", test.table, statuses, err)
+		}
+		var n int
+		if err := test.db.QueryRow("SELECT count(*) FROM " + test.table + " WHERE version_id > 0").Scan(&n); err != nil || n != int(test.version) {
+			t.Errorf("%s: got %d records, %v", test.table, n, err)
+		}
+	}
+
+	// Neither touched the package settings.
+	if _, ok := GetDialect().(*PostgresDialect); !ok || TableName() != "goose_db_version" {
+		t.Errorf("package settings changed: %T, %s", GetDialect(), TableName())
+	}
+}
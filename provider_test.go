@@ -0,0 +1,105 @@
+package goose
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProviderSettings(t *testing.T) {
+	logger := &captureLogger{}
+	mysql, err := NewProvider("mysql", nil, "migrations", ProviderOptions{TableName: "billing_db_version", Logger: logger, AllowMissing: true, Audit: &AuditOptions{Operator: "ci"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pg, err := NewProvider("postgres", nil, "migrations", ProviderOptions{TableSchema: "ops", Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := mysql.cfg
+	if _, ok := c.dialect.(*MySQLDialect); !ok || c.tables.version() != "billing_db_version" || c.log != logger || !c.allowMissing || c.strict || c.audit == nil || c.audit.Operator != "ci" {
+		t.Errorf("mysql provider settings: %T, %s, %+v", c.dialect, c.tables.version(), c)
+	}
+	if q := c.insertVersionSQL(); !strings.Contains(q, "INSERT INTO billing_db_version ") {
+		t.Errorf("mysql provider query doesn't use its table: %s", q)
+	}
+	c = pg.cfg
+	if _, ok := c.dialect.(*PostgresDialect); !ok || c.tables.version() != "ops.goose_db_version" || c.log != log || c.allowMissing || !c.strict || c.audit != nil {
+		t.Errorf("postgres provider settings: %T, %s, %+v", c.dialect, c.tables.version(), c)
+	}
+
+	// The package settings are left alone.
+	if _, ok := GetDialect().(*PostgresDialect); !ok || TableName() != "goose_db_version" || log == logger || allowMissing || strict {
+		t.Errorf("package settings changed: %T, %s", GetDialect(), TableName())
+	}
+
+	if _, err := NewProvider("oracle", nil, "migrations", ProviderOptions{}); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+}
+
+func TestProviderBaseFS(t *testing.T) {
+	users := fstest.MapFS{"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n")}}
+	orders := fstest.MapFS{
+		"migrations/00001_users.sql":  {Data: []byte("-- +goose Up\nCREATE TABLE orders (id int);\n")},
+		"migrations/00002_status.sql": {Data: []byte("-- +goose Up\nALTER TABLE orders ADD status text;\n")},
+	}
+	p1, err := NewProvider("postgres", nil, "migrations", ProviderOptions{BaseFS: users})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := NewProvider("mysql", nil, "migrations", ProviderOptions{BaseFS: orders})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetBaseFS(fstest.MapFS{})
+	defer SetBaseFS(nil)
+
+	// Both collect and parse their own files at the same time, the cached
+	// statements of a path being kept per Provider.
+	var wg sync.WaitGroup
+	for _, test := range []struct {
+		p     *Provider
+		count int
+		table string
+	}{{p1, 1, "users"}, {p2, 2, "orders"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				migrations, err := collectMigrations(test.p.cfg, "migrations", minVersion, maxVersion)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(migrations) != test.count {
+					t.Errorf("got %d migrations, want %d", len(migrations), test.count)
+					return
+				}
+				statements, _, _, err := readSQLStatements(test.p.cfg, migrations[0].Source, true)
+				if err != nil || len(statements) != 1 || !strings.Contains(statements[0], test.table) {
+					t.Errorf("got statements %q, %v; want the %s ones", statements, err, test.table)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := statFile("migrations/00001_users.sql"); err == nil {
+		t.Error("package filesystem changed")
+	}
+}
+
+func TestProviderUnsupportedCommand(t *testing.T) {
+	p, err := NewProvider("postgres", nil, "migrations", ProviderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Run(context.Background(), "create", "users", "sql"); err == nil || !strings.Contains(err.Error(), "not supported by a Provider") {
+		t.Errorf("got %v, want an unsupported command error", err)
+	}
+}
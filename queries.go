@@ -0,0 +1,60 @@
+package goose
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// VerifyQueries prepares every query of the given SQL files, e.g. sqlc query
+// files, against db without executing them. Run against a database migrated
+// to the latest version, it tells whether the migrations break existing
+// queries. Queries are split as in migrations; all failures are reported.
+func VerifyQueries(db *sql.DB, files []string) error {
+	var failures []string
+	n := 0
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// An Up annotation makes the file parse like a migration, shifting
+		// line numbers by one.
+		statements, lines, _, err := parseSQLStatements(packageConfig(), bytes.NewReader(append([]byte(sqlCmdPrefix+"Up\n"), b...)), true)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		for i, query := range statements {
+			if strings.TrimSpace(stripComments(query)) == "" {
+				continue
+			}
+			n++
+			stmt, err := db.Prepare(query)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s:%d: %v", filepath.Base(file), lines[i]-1, err))
+				continue
+			}
+			stmt.Close()
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d queries failed:\n\t%s", len(failures), n, strings.Join(failures, "\n\t"))
+	}
+	log.Printf("goose: %d queries OK\n", n)
+	return nil
+}
+
+// stripComments removes the comment lines of a statement.
+func stripComments(query string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(query, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
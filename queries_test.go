@@ -0,0 +1,41 @@
+package goose
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyQueries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	queries := `-- name: GetUser :one
+SELECT id, name FROM users WHERE id = $1;
+
+-- name: ListUsers :many
+SELECT id, missing
+FROM users;
+`
+	file := filepath.Join(dir, "users.sql")
+	if err := ioutil.WriteFile(file, []byte(queries), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("goose-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = VerifyQueries(db, []string{file})
+	if err == nil || !strings.Contains(err.Error(), `1 of 2 queries failed:`) || !strings.Contains(err.Error(), `users.sql:5: column "missing" does not exist`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -1,17 +1,29 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
 )
 
 // Redo rolls back the most recently applied migration, then runs it again.
 func Redo(db *sql.DB, dir string) error {
-	currentVersion, err := GetDBVersion(db)
+	return redo(context.Background(), db, dir)
+}
+
+// RedoContext rolls back the most recently applied migration, then runs it
+// again, with ctx.
+func RedoContext(ctx context.Context, db *sql.DB, dir string) error {
+	return redo(ctx, db, dir)
+}
+
+func redo(ctx context.Context, db *sql.DB, dir string) error {
+	c := configFrom(ctx)
+	currentVersion, err := GetDBVersionContext(ctx, db)
 	if err != nil {
 		return err
 	}
 
-	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
@@ -21,11 +33,11 @@ func Redo(db *sql.DB, dir string) error {
 		return err
 	}
 
-	if err := current.Down(db); err != nil {
+	if err := current.down(ctx, db); err != nil {
 		return err
 	}
 
-	if err := current.Up(db); err != nil {
+	if err := current.up(ctx, db); err != nil {
 		return err
 	}
 
@@ -0,0 +1,70 @@
+package goose
+
+import (
+	"context"
+	"time"
+)
+
+// AppliedMigration is a migration applied or rolled back during a run.
+type AppliedMigration struct {
+	Version      int64         `json:"version"`
+	File         string        `json:"file"`
+	Direction    string        `json:"direction"`
+	Duration     time.Duration `json:"duration_ns"`
+	RowsAffected int64         `json:"rows_affected"`
+}
+
+// runReport collects the migrations applied during a run.
+type runReport struct {
+	command    string
+	started    time.Time
+	migrations []AppliedMigration
+}
+
+// printSummary logs the execution time of every migration of the run to l.
+func (r *runReport) printSummary(l Logger) {
+	if len(r.migrations) == 0 {
+		return
+	}
+
+	var total time.Duration
+	l.Println("    Duration        Migration")
+	l.Println("    =======================================")
+	for _, m := range r.migrations {
+		total += m.Duration
+		l.Printf("    %-15v -- %v (%s)\n", m.Duration.Round(time.Millisecond), m.File, m.Direction)
+	}
+	l.Printf("    %-15v -- total, %d migrations\n", total.Round(time.Millisecond), len(r.migrations))
+}
+
+type reportKey struct{}
+
+func withReport(ctx context.Context, r *runReport) context.Context {
+	return context.WithValue(ctx, reportKey{}, r)
+}
+
+// recordMigration adds a migration to the run report of ctx, if any.
+func recordMigration(ctx context.Context, m AppliedMigration) {
+	if r, ok := ctx.Value(reportKey{}).(*runReport); ok {
+		r.migrations = append(r.migrations, m)
+	}
+}
+
+// migratingCommands change the database schema.
+var migratingCommands = map[string]bool{
+	"up":        true,
+	"up-by-one": true,
+	"up-to":     true,
+	"down":      true,
+	"down-to":   true,
+	"redo":      true,
+	"reset":     true,
+	"apply":     true,
+}
+
+// IsMigratingCommand reports whether command applies or rolls back
+// migrations, and so runs under the migration lock and may change the
+// schema.
+func IsMigratingCommand(command string) bool {
+	return migratingCommands[command]
+}
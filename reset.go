@@ -1,28 +1,48 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
-	"log"
 	"sort"
 )
 
 // Reset rolls back all migrations
 func Reset(db *sql.DB, dir string) error {
-	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	return reset(context.Background(), db, dir)
+}
+
+// ResetContext rolls back all migrations, until ctx is canceled.
+func ResetContext(ctx context.Context, db *sql.DB, dir string) error {
+	return reset(ctx, db, dir)
+}
+
+func reset(ctx context.Context, db *sql.DB, dir string) error {
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
-	statuses, err := dbMigrationsStatus(db)
+	statuses, err := migrationsApplied(ctx, db, migrations)
 	if err != nil {
 		return err
 	}
 	sort.Sort(sort.Reverse(migrations))
 
+	var applied Migrations
 	for _, migration := range migrations {
-		if !statuses[migration.Version] {
-			continue
+		if statuses[migration.Version] {
+			applied = append(applied, migration)
+		}
+	}
+	if err := verifyChecksums(c, applied); err != nil {
+		return err
+	}
+
+	for _, migration := range applied {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		if err = migration.Down(db); err != nil {
+		if err = migration.down(ctx, db); err != nil {
 			return err
 		}
 	}
@@ -30,30 +50,19 @@ func Reset(db *sql.DB, dir string) error {
 	return nil
 }
 
-func dbMigrationsStatus(db *sql.DB) (map[int64]bool, error) {
-	rows, err := GetDialect().dbVersionQuery(db)
-	if err != nil {
-		return map[int64]bool{}, createVersionTable(db)
+// migrationsApplied returns which of migrations are applied to db, creating
+// the version table of a pristine database. Only the records of migrations
+// are read.
+func migrationsApplied(ctx context.Context, db *sql.DB, migrations Migrations) (map[int64]bool, error) {
+	if _, err := ensureDBVersion(ctx, db); err != nil && err != ErrNoNextVersion {
+		return nil, err
 	}
-	defer rows.Close()
-
-	// The most recent record for each migration specifies
-	// whether it has been applied or rolled back.
-
-	result := make(map[int64]bool)
-
-	for rows.Next() {
-		var row MigrationRecord
-		if err = rows.Scan(&row.VersionID, &row.IsApplied); err != nil {
-			log.Fatal("error scanning rows:", err)
-		}
-
-		if _, ok := result[row.VersionID]; ok {
-			continue
-		}
-
-		result[row.VersionID] = row.IsApplied
+	if len(migrations) == 0 {
+		return map[int64]bool{}, nil
 	}
-
-	return result, nil
+	versions := make([]int64, len(migrations))
+	for i, m := range migrations {
+		versions[i] = m.Version
+	}
+	return appliedVersions(ctx, db, versions...)
 }
@@ -0,0 +1,77 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+)
+
+// RunContext identifies the build applying migrations. It's recorded in the
+// goose_db_version_context table next to every version record, so that any
+// schema state can be traced back to the build that applied it.
+type RunContext struct {
+	// CommitSHA is the VCS commit of the migrations. It defaults to
+	// $GOOSE_COMMIT_SHA, or the commit reported by GitHub Actions or GitLab CI.
+	CommitSHA string
+	// Values are arbitrary key/values, e.g. the pipeline URL.
+	Values map[string]string
+}
+
+// commitSHAEnv lists the environment variables holding the current commit,
+// in order of preference.
+var commitSHAEnv = []string{"GOOSE_COMMIT_SHA", "GITHUB_SHA", "CI_COMMIT_SHA"}
+
+var runContext *RunContext
+
+// SetRunContext records c with every migration applied or rolled back.
+func SetRunContext(c RunContext) {
+	runContext = defaultRunContext(c)
+}
+
+// defaultRunContext returns c with the default commit.
+func defaultRunContext(c RunContext) *RunContext {
+	for _, env := range commitSHAEnv {
+		if c.CommitSHA != "" {
+			break
+		}
+		c.CommitSHA = os.Getenv(env)
+	}
+	return &c
+}
+
+// context is the run context table, named after the version table.
+func (t gooseTables) context() string {
+	return t.version() + "_context"
+}
+
+// writeRunContext records the run context of a version record, when set.
+// As the migration is committed already, it isn't canceled with ctx, and a
+// failure only logs a warning.
+func writeRunContext(ctx context.Context, db *sql.DB, version int64, direction bool) {
+	c := configFrom(ctx)
+	if c.runContext == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	table := c.tables.context()
+	if !tableMarked(&createdTables, db, table) {
+		if _, err := db.ExecContext(ctx, c.dialect.createContextTableSQL(c.tables)); err != nil {
+			c.log.Printf("WARNING: context of version %d not recorded: failed to create %s table: %v\n", version, table, err)
+			return
+		}
+		markTable(&createdTables, db, table)
+	}
+
+	sha := sql.NullString{String: c.runContext.CommitSHA, Valid: c.runContext.CommitSHA != ""}
+	var values sql.NullString
+	if len(c.runContext.Values) > 0 {
+		b, _ := json.Marshal(c.runContext.Values)
+		values = sql.NullString{String: string(b), Valid: true}
+	}
+
+	if _, err := db.ExecContext(ctx, c.dialect.insertContextSQL(c.tables), version, direction, sha, values); err != nil {
+		c.log.Printf("WARNING: context of version %d not recorded: %v\n", version, err)
+	}
+}
@@ -0,0 +1,120 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLocked is returned when another goose run holds the migration lock
+// longer than the lock timeout.
+var ErrLocked = errors.New("another goose run holds the migration lock")
+
+var (
+	runLock     = true
+	lockTimeout = 5 * time.Minute
+)
+
+// SetMigrationLock sets whether the commands applying or rolling back
+// migrations hold the migration lock, a PostgreSQL advisory lock or a MySQL
+// named lock, so that concurrent runs, e.g. of replicas starting at once,
+// don't race on the version table and interleave their DDL. It is enabled
+// by default, on the dialects supporting locks. As the lock takes a
+// connection of its own, databases limited to one by SetMaxOpenConns run
+// without it, with a warning.
+func SetMigrationLock(enabled bool) {
+	runLock = enabled
+}
+
+// SetLockTimeout sets how long a run waits for the migration lock before
+// failing with ErrLocked, 5 minutes by default.
+func SetLockTimeout(d time.Duration) {
+	lockTimeout = d
+}
+
+// withoutMigrationLock makes a run skip the migration lock, e.g. because
+// its caller already holds it.
+func withoutMigrationLock() OptionsFunc {
+	return func(o *options) { o.noLock = true }
+}
+
+// lockMigrations takes the migration lock on a connection of db, held until
+// unlock is called.
+func lockMigrations(ctx context.Context, db *sql.DB) (unlock func(), err error) {
+	c := configFrom(ctx)
+	if !runLock || db == nil || c.dialect.tryLockSQL(c.tables) == "" {
+		return func() {}, nil
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	unlock, err = holdMigrationLock(ctx, db, func(attempt int) (bool, error) {
+		if !time.Now().Before(deadline) {
+			return false, ErrLocked
+		}
+		if attempt == 0 {
+			c.log.Println("goose: another goose run holds the migration lock, waiting")
+		}
+		return false, nil
+	})
+	if err == errSingleConn {
+		c.log.Printf("WARNING: %v, running without it\n", err)
+		return func() {}, nil
+	}
+	return unlock, err
+}
+
+// errSingleConn is returned by holdMigrationLock for databases limited to a
+// single connection, which the run would wait for forever.
+var errSingleConn = errors.New("the migration lock needs a connection of its own, but db.SetMaxOpenConns is 1")
+
+// holdMigrationLock takes the migration lock on a dedicated connection of
+// db, trying again every startupPollInterval until ctx is done. wait is
+// called before each wait: it gives up with an error, or returns done when
+// the lock isn't needed anymore, unlock then being nil.
+func holdMigrationLock(ctx context.Context, db *sql.DB, wait func(attempt int) (done bool, err error)) (unlock func(), err error) {
+	if db.Stats().MaxOpenConnections == 1 {
+		return nil, errSingleConn
+	}
+	c := configFrom(ctx)
+
+	// Session-level locks belong to a connection, hold one for the run.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, c.dialect.tryLockSQL(c.tables)).Scan(&locked); err != nil {
+			conn.Close()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("taking migration lock: %v", err)
+		}
+		if locked {
+			break
+		}
+		if done, err := wait(attempt); done || err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(startupPollInterval):
+		}
+	}
+
+	return func() {
+		// Closing the connection releases the lock too.
+		if _, err := conn.ExecContext(context.Background(), c.dialect.unlockSQL(c.tables)); err != nil {
+			c.log.Printf("goose: releasing migration lock: %v\n", err)
+		}
+		conn.Close()
+	}, nil
+}
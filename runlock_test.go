@@ -0,0 +1,157 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockMigrations(t *testing.T) {
+	db, err := sql.Open("goose-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The fake driver can't query: the run fails before any migration.
+	err = RunWithContext(context.Background(), "up", db, "migrations")
+	if err == nil || !strings.Contains(err.Error(), "taking migration lock") {
+		t.Errorf("got error %v, want a migration lock error", err)
+	}
+
+	SetMigrationLock(false)
+	defer SetMigrationLock(true)
+	unlock, err := lockMigrations(context.Background(), db)
+	if err != nil {
+		t.Fatalf("lock taken while disabled: %v", err)
+	}
+	unlock()
+}
+
+// lockDriver is a driver whose connections share a PostgreSQL advisory
+// lock, for the migration lock tests.
+type lockDriver struct {
+	mu     sync.Mutex
+	holder *lockConn
+}
+
+func (d *lockDriver) Open(string) (driver.Conn, error) { return &lockConn{d: d}, nil }
+
+type lockConn struct {
+	fakeConn
+	d *lockDriver
+}
+
+func (c *lockConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "pg_try_advisory_lock") {
+		return nil, errors.New("not implemented")
+	}
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	locked := c.d.holder == nil || c.d.holder == c
+	if locked {
+		c.d.holder = c
+	}
+	return &boolRows{value: locked}, nil
+}
+
+func (c *lockConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "pg_advisory_unlock") {
+		c.release()
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *lockConn) Close() error {
+	c.release()
+	return nil
+}
+
+func (c *lockConn) release() {
+	c.d.mu.Lock()
+	if c.d.holder == c {
+		c.d.holder = nil
+	}
+	c.d.mu.Unlock()
+}
+
+type boolRows struct {
+	value bool
+	done  bool
+}
+
+func (r *boolRows) Columns() []string { return []string{"locked"} }
+func (r *boolRows) Close() error      { return nil }
+func (r *boolRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func init() {
+	sql.Register("goose-lock", &lockDriver{})
+}
+
+func TestLockMigrationsConcurrent(t *testing.T) {
+	db, err := sql.Open("goose-lock", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	savedInterval := startupPollInterval
+	startupPollInterval = 10 * time.Millisecond
+	defer func() { startupPollInterval = savedInterval }()
+	SetLockTimeout(50 * time.Millisecond)
+	defer SetLockTimeout(5 * time.Minute)
+
+	unlock, err := lockMigrations(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A second run waits for the lock, then times out.
+	started := time.Now()
+	if _, err := lockMigrations(context.Background(), db); err != ErrLocked {
+		t.Errorf("second run: got %v, want ErrLocked", err)
+	}
+	if waited := time.Since(started); waited < 50*time.Millisecond {
+		t.Errorf("second run gave up after %v, before the lock timeout", waited)
+	}
+
+	// It takes the lock once the first run releases it.
+	SetLockTimeout(5 * time.Minute)
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		unlock()
+	}()
+	unlock, err = lockMigrations(context.Background(), db)
+	if err != nil {
+		t.Fatalf("second run after release: %v", err)
+	}
+	unlock()
+
+	// A single connection would be held by the lock, the run waiting for
+	// another forever.
+	db.SetMaxOpenConns(1)
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+	unlock, err = lockMigrations(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "running without it") {
+		t.Errorf("got log %q, want a warning about the single connection", logger.lines)
+	}
+}
@@ -0,0 +1,168 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// isGooseTable reports whether table is one of goose's own tables, which
+// are left out of schema introspection.
+func isGooseTable(table string) bool {
+	switch table {
+	case tableName, tableName + "_context", tableName + "_meta", "goose_audit", "goose_seeds":
+		return true
+	}
+	return false
+}
+
+// dbSchema is the introspected schema of a database, by table name.
+type dbSchema map[string]*schemaTable
+
+type schemaTable struct {
+	name        string
+	columns     []schemaColumn    // in ordinal order
+	constraints map[string]string // name to type, e.g. PRIMARY KEY
+	indexes     map[string]string // name to CREATE INDEX statement
+	foreignKeys []schemaForeignKey
+}
+
+// schemaForeignKey is a column referencing a column of another table.
+type schemaForeignKey struct {
+	column    string
+	refTable  string
+	refColumn string
+}
+
+type schemaColumn struct {
+	name     string
+	dataType string
+	notNull  bool
+	def      string
+}
+
+// definition returns the column definition, as in CREATE TABLE.
+func (c schemaColumn) definition() string {
+	def := c.name + " " + c.dataType
+	if c.notNull {
+		def += " NOT NULL"
+	}
+	if c.def != "" {
+		def += " DEFAULT " + c.def
+	}
+	return def
+}
+
+func (s dbSchema) table(name string) *schemaTable {
+	t, ok := s[name]
+	if !ok {
+		t = &schemaTable{name: name, constraints: map[string]string{}, indexes: map[string]string{}}
+		s[name] = t
+	}
+	return t
+}
+
+// names returns the table names in order.
+func (s dbSchema) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *schemaTable) column(name string) (schemaColumn, bool) {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return schemaColumn{}, false
+}
+
+// readSchema introspects the tables, columns, constraints and indexes of the
+// current schema of db, except the goose tables.
+func readSchema(db *sql.DB) (dbSchema, error) {
+	d := GetDialect()
+	schema := dbSchema{}
+
+	query := func(q string, scan func(values []string)) error {
+		rows, err := db.Query(q)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			values := make([]sql.NullString, len(cols))
+			dest := make([]interface{}, len(cols))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+			strs := make([]string, len(values))
+			for i, v := range values {
+				strs[i] = v.String
+			}
+			if !isGooseTable(strs[0]) {
+				scan(strs)
+			}
+		}
+		return rows.Err()
+	}
+
+	err := query(d.columnsQuery(), func(v []string) {
+		t := schema.table(v[0])
+		t.columns = append(t.columns, schemaColumn{name: v[1], dataType: v[2], notNull: v[3] == "NO", def: v[4]})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing columns: %v", err)
+	}
+
+	err = query(fmt.Sprintf(`SELECT table_name, constraint_name, constraint_type
+		FROM information_schema.table_constraints WHERE table_schema = %s`, d.currentSchemaSQL()),
+		func(v []string) {
+			// PostgreSQL reports NOT NULL as CHECK constraints named after
+			// OIDs, they're covered by the columns.
+			if v[2] == "CHECK" && strings.HasSuffix(v[1], "_not_null") {
+				return
+			}
+			schema.table(v[0]).constraints[v[1]] = v[2]
+		})
+	if err != nil {
+		return nil, fmt.Errorf("listing constraints: %v", err)
+	}
+
+	err = query(d.indexesQuery(), func(v []string) {
+		schema.table(v[0]).indexes[v[1]] = v[2]
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing indexes: %v", err)
+	}
+
+	err = query(d.foreignKeysQuery(), func(v []string) {
+		t := schema.table(v[0])
+		t.foreignKeys = append(t.foreignKeys, schemaForeignKey{column: v[1], refTable: v[2], refColumn: v[3]})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing foreign keys: %v", err)
+	}
+	return schema, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
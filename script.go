@@ -0,0 +1,134 @@
+package goose
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Script writes the SQL that migrating the database from version current to
+// version target would execute, including the goose_db_version bookkeeping,
+// so that it can be applied by external tooling without goose.
+func Script(w io.Writer, dir string, current, target int64) error {
+	c := packageConfig()
+	migrations, err := collectMigrations(c, dir, current, target)
+	if err != nil {
+		return err
+	}
+
+	direction := target > current
+	if !direction {
+		sort.Sort(sort.Reverse(migrations))
+	}
+
+	fmt.Fprintf(w, "-- goose script: version %d -> %d\n", current, target)
+	for _, m := range migrations {
+		if err := scriptMigration(c, w, m, direction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scriptMigration(c *config, w io.Writer, m *Migration, direction bool) error {
+	name := filepath.Base(m.Source)
+	if !isSQLMigration(m.Source) {
+		return fmt.Errorf("%s: Go migrations can't be exported as SQL", name)
+	}
+
+	statements, useTx, _, err := scriptStatements(c, m, direction)
+	if err != nil {
+		return err
+	}
+	for _, query := range statements {
+		if _, ok := parseLoad(query); ok {
+			return fmt.Errorf("%s: fixture loads can't be exported as SQL", name)
+		}
+	}
+
+	dir := "Up"
+	if !direction {
+		dir = "Down"
+	}
+	fmt.Fprintf(w, "\n-- %s (%s)\n", name, dir)
+	writeScript(c, w, m.Version, statements, useTx, direction)
+	return nil
+}
+
+// scriptStatements returns the statements of one direction of a SQL
+// migration as a run would execute them, whether they run in a transaction,
+// and whether the migration is skipped in this environment, in which case
+// there are none.
+func scriptStatements(c *config, m *Migration, direction bool) (statements []string, useTx, skip bool, err error) {
+	statements, lines, useTx, err := readSQLStatements(c, m.Source, direction)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("%s: %v", filepath.Base(m.Source), err)
+	}
+	if useTx, err = needsTransaction(c, m.Source, statements, lines, useTx); err != nil {
+		return nil, false, false, err
+	}
+	if skip, err = skipForEnvironment(c, m.Source); err != nil {
+		return nil, false, false, err
+	}
+	if skip {
+		statements = nil
+	}
+	return statements, useTx, skip, nil
+}
+
+// writeScript writes statements followed by the version table insert
+// recording them, within BEGIN and COMMIT if useTx.
+func writeScript(c *config, w io.Writer, version int64, statements []string, useTx, direction bool) {
+	if useTx {
+		fmt.Fprintln(w, "BEGIN;")
+	}
+	for _, query := range statements {
+		fmt.Fprint(w, query)
+	}
+	fmt.Fprintln(w, insertVersionLiteral(c, version, direction))
+	if useTx {
+		fmt.Fprintln(w, "COMMIT;")
+	}
+}
+
+// insertVersionLiteral renders the version table insert with inlined values,
+// as scripts can't bind parameters.
+func insertVersionLiteral(c *config, version int64, applied bool) string {
+	return fmt.Sprintf("INSERT INTO %s (version_id, is_applied, tstamp) VALUES (%d, %t, %s);", c.tables.version(), version, applied, c.dialect.utcNowSQL())
+}
+
+// parseScriptArgs parses "up [FROM]", "up-to VERSION [FROM]" and
+// "down-to VERSION FROM" into the current and target versions.
+func parseScriptArgs(args []string) (current, target int64, err error) {
+	usage := fmt.Errorf("script must be of form: goose [OPTIONS] script up [FROM] | up-to VERSION [FROM] | down-to VERSION FROM")
+	if len(args) == 0 {
+		return 0, 0, usage
+	}
+
+	versions := make([]int64, 0, 2)
+	for _, arg := range args[1:] {
+		v, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("version must be a number (got '%s')", arg)
+		}
+		versions = append(versions, v)
+	}
+
+	switch {
+	case args[0] == "up" && len(versions) <= 1:
+		if len(versions) == 1 {
+			current = versions[0]
+		}
+		return current, maxVersion, nil
+	case args[0] == "up-to" && (len(versions) == 1 || len(versions) == 2):
+		if len(versions) == 2 {
+			current = versions[1]
+		}
+		return current, versions[0], nil
+	case args[0] == "down-to" && len(versions) == 2:
+		return versions[1], versions[0], nil
+	}
+	return 0, 0, usage
+}
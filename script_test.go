@@ -0,0 +1,59 @@
+package goose
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Script(&buf, "./examples/sql-migrations", 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"-- 00001_create_users_table.sql (Up)",
+		"VALUES (1, true, now() at time zone 'utc');\nCOMMIT;",
+		"VALUES (3, true, now() at time zone 'utc');",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("script does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN;") != 2 {
+		t.Errorf("expected NO TRANSACTION migration to run outside of a transaction:\n%s", out)
+	}
+	if strings.Index(out, "00001_") > strings.Index(out, "00002_") {
+		t.Errorf("migrations are out of order:\n%s", out)
+	}
+}
+
+func TestParseScriptArgs(t *testing.T) {
+	tests := []struct {
+		args            []string
+		current, target int64
+		err             bool
+	}{
+		{args: []string{"up"}, current: 0, target: maxVersion},
+		{args: []string{"up", "2"}, current: 2, target: maxVersion},
+		{args: []string{"up-to", "5"}, current: 0, target: 5},
+		{args: []string{"up-to", "5", "3"}, current: 3, target: 5},
+		{args: []string{"down-to", "1", "3"}, current: 3, target: 1},
+		{args: []string{"down-to", "1"}, err: true},
+		{args: []string{"up-to", "x"}, err: true},
+		{args: []string{}, err: true},
+	}
+
+	for _, test := range tests {
+		current, target, err := parseScriptArgs(test.args)
+		if (err != nil) != test.err {
+			t.Errorf("%v: unexpected error %v", test.args, err)
+			continue
+		}
+		if current != test.current || target != test.target {
+			t.Errorf("%v: got %d -> %d, want %d -> %d", test.args, current, target, test.current, test.target)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var seedDir = "db/seeds"
+
+// SetSeedDir sets the folder of the seed scripts, db/seeds by default.
+func SetSeedDir(dir string) {
+	seedDir = dir
+}
+
+// seeds is the table recording the seeds run, in the schema of the version
+// table.
+func (t gooseTables) seeds() string {
+	return t.qualified("goose_seeds")
+}
+
+// seedFile is a seed script, named after its file without extension.
+type seedFile struct {
+	name string
+	path string
+}
+
+// Seed loads data with the seed scripts of the seed folder and, if env isn't
+// empty, of its env subfolder, in name order. Unlike migrations, seeds are
+// never run by up, and are tracked per environment in goose_seeds: a seed
+// already run in env is skipped unless it changed since or rerun is set.
+// Seeds given by name always run, the others are skipped.
+func Seed(db *sql.DB, env string, rerun bool, names ...string) error {
+	seeds, err := collectSeeds(env)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, s := range seeds {
+		known[s.name] = true
+	}
+	selected := map[string]bool{}
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("%s: no such seed in %s", name, seedDir)
+		}
+		selected[name] = true
+	}
+
+	if _, err := db.Exec(GetDialect().createSeedTableSQL(packageTables())); err != nil {
+		return fmt.Errorf("failed to create %s table: %v", packageTables().seeds(), err)
+	}
+	applied, err := appliedSeeds(db, env)
+	if err != nil {
+		return err
+	}
+
+	ran := 0
+	for _, s := range seeds {
+		if len(names) > 0 && !selected[s.name] {
+			continue
+		}
+		sum, err := fileSHA256(packageConfig(), s.path)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 && !rerun && applied[s.name] == sum {
+			continue
+		}
+		if err := runSeed(db, s, env, sum); err != nil {
+			return err
+		}
+		log.Println(colorize(colorGreen, "OK   "), filepath.Base(s.path))
+		ran++
+	}
+
+	if ran == 0 {
+		log.Println("goose: no seeds to run")
+	}
+	return nil
+}
+
+// collectSeeds lists the seeds of the seed folder and of its env subfolder,
+// which override the seeds of the same name.
+func collectSeeds(env string) ([]seedFile, error) {
+	byName := map[string]seedFile{}
+	dirs := []string{seedDir}
+	if env != "" {
+		dirs = append(dirs, filepath.Join(seedDir, env))
+	}
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			name := strings.TrimSuffix(filepath.Base(path), ".sql")
+			byName[name] = seedFile{name: name, path: path}
+		}
+	}
+
+	seeds := make([]seedFile, 0, len(byName))
+	for _, s := range byName {
+		seeds = append(seeds, s)
+	}
+	sort.Slice(seeds, func(i, j int) bool { return seeds[i].name < seeds[j].name })
+	return seeds, nil
+}
+
+// appliedSeeds returns the checksum each seed was last run with in env.
+func appliedSeeds(db *sql.DB, env string) (map[string]string, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT name, env, checksum FROM %s ORDER BY id", packageTables().seeds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[string]string{}
+	for rows.Next() {
+		var name, seedEnv, sum string
+		if err := rows.Scan(&name, &seedEnv, &sum); err != nil {
+			return nil, err
+		}
+		if seedEnv == env {
+			applied[name] = sum
+		}
+	}
+	return applied, rows.Err()
+}
+
+// runSeed executes a seed with runScript, recording it in goose_seeds.
+func runSeed(db *sql.DB, s seedFile, env, sum string) error {
+	return runScript(context.Background(), db, s.path, func(exec execer) error {
+		_, err := exec.ExecContext(context.Background(), GetDialect().insertSeedSQL(packageTables()), s.name, env, sum)
+		return err
+	})
+}
+
+// seedCommand implements the seed command: seed [-env=ENV] [-rerun]
+// [NAME...].
+func seedCommand(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	env := fs.String("env", environment, "environment, whose seed subfolder is also run")
+	rerun := fs.Bool("rerun", false, "run the seeds even if they already ran")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return Seed(db, *env, *rerun, fs.Args()...)
+}
@@ -0,0 +1,44 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectSeeds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-seeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{"01_countries.sql", "02_users.sql", "staging/02_users.sql", "staging/03_demo.sql", "production/04_admins.sql", "README.md"}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("SELECT 1;\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer SetSeedDir(seedDir)
+	SetSeedDir(dir)
+
+	seeds, err := collectSeeds("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"01_countries.sql", "staging/02_users.sql", "staging/03_demo.sql"}
+	if len(seeds) != len(expected) {
+		t.Fatalf("got %d seeds, want %d", len(seeds), len(expected))
+	}
+	for i, s := range seeds {
+		if s.path != filepath.Join(dir, expected[i]) {
+			t.Errorf("seed %d: got %s, want %s", i, s.path, expected[i])
+		}
+	}
+}
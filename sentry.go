@@ -0,0 +1,134 @@
+package goose
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sentryDSN is the parsed Sentry DSN, nil when Sentry reporting is disabled.
+var sentryDSN *sentryTarget
+
+type sentryTarget struct {
+	storeURL  string
+	publicKey string
+}
+
+// SetSentryDSN reports failed runs to the Sentry project identified by dsn,
+// in the form https://PUBLIC_KEY@HOST/PROJECT_ID. An empty dsn disables
+// reporting.
+func SetSentryDSN(dsn string) error {
+	if dsn == "" {
+		sentryDSN = nil
+		return nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return fmt.Errorf("sentry DSN: %v", err)
+	}
+	project := strings.Trim(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || project == "" {
+		return errors.New("sentry DSN: must be of form https://PUBLIC_KEY@HOST/PROJECT_ID")
+	}
+
+	// Sentry may be hosted under a path prefix, the project ID is the last
+	// segment.
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	sentryDSN = &sentryTarget{
+		storeURL:  fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		publicKey: u.User.Username(),
+	}
+	return nil
+}
+
+// sentryEvent is the subset of the Sentry event payload goose sends.
+type sentryEvent struct {
+	EventID   string                 `json:"event_id"`
+	Timestamp string                 `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Platform  string                 `json:"platform"`
+	Logger    string                 `json:"logger"`
+	Message   string                 `json:"message"`
+	Tags      map[string]string      `json:"tags"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+// reportToSentry sends the error failing a run to Sentry. Delivery failures
+// are logged, they never fail the run.
+func reportToSentry(command string, err error) {
+	if sentryDSN == nil || err == nil || err == ErrNoChange {
+		return
+	}
+	if err := sendSentryEvent(newSentryEvent(command, err)); err != nil {
+		log.Printf("goose: sentry: %v\n", err)
+	}
+}
+
+func newSentryEvent(command string, err error) sentryEvent {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	e := sentryEvent{
+		EventID:   hex.EncodeToString(id),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Level:     "error",
+		Platform:  "go",
+		Logger:    "goose",
+		Message:   err.Error(),
+		Tags:      map[string]string{"command": command},
+		Extra:     map[string]interface{}{},
+	}
+	if databaseName != "" {
+		e.Tags["database"] = databaseName
+	}
+
+	var migrationErr *MigrationError
+	if errors.As(err, &migrationErr) {
+		e.Tags["version"] = fmt.Sprint(migrationErr.Version)
+		e.Tags["file"] = filepath.Base(migrationErr.Source)
+		if len(migrationErr.Stack) > 0 {
+			e.Extra["stack"] = string(migrationErr.Stack)
+		}
+	}
+	var stmtErr *StatementError
+	if errors.As(err, &stmtErr) {
+		e.Extra["line"] = stmtErr.Line
+		e.Extra["sql"] = redactSQL(stmtErr.Query)
+	}
+	return e
+}
+
+func sendSentryEvent(e sentryEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", sentryDSN.storeURL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=goose/1.0, sentry_key=%s", sentryDSN.publicKey))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", sentryDSN.storeURL, resp.Status)
+	}
+	return nil
+}
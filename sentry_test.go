@@ -0,0 +1,46 @@
+package goose
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetSentryDSN(t *testing.T) {
+	defer SetSentryDSN("")
+
+	tests := []struct {
+		dsn, storeURL string
+	}{
+		{"https://key@o1.ingest.sentry.io/42", "https://o1.ingest.sentry.io/api/42/store/"},
+		{"http://key@localhost:9000/sentry/7", "http://localhost:9000/sentry/api/7/store/"},
+	}
+	for _, test := range tests {
+		if err := SetSentryDSN(test.dsn); err != nil {
+			t.Fatal(err)
+		}
+		if sentryDSN.storeURL != test.storeURL || sentryDSN.publicKey != "key" {
+			t.Errorf("%s: got %+v, want store URL %s", test.dsn, sentryDSN, test.storeURL)
+		}
+	}
+
+	if err := SetSentryDSN("https://o1.ingest.sentry.io/42"); err == nil {
+		t.Error("expected an error for a DSN without key")
+	}
+}
+
+func TestSentryEvent(t *testing.T) {
+	err := &MigrationError{Version: 3, Source: "db/00003_users.sql", Err: &StatementError{
+		File:  "00003_users.sql",
+		Line:  12,
+		Query: "CREATE USER app WITH PASSWORD 'hunter2';",
+		Err:   errors.New("role exists"),
+	}}
+
+	e := newSentryEvent("up", err)
+	if e.Tags["version"] != "3" || e.Tags["file"] != "00003_users.sql" || e.Tags["command"] != "up" {
+		t.Errorf("incorrect tags: %v", e.Tags)
+	}
+	if e.Extra["line"] != 12 || e.Extra["sql"] != "CREATE USER app WITH PASSWORD '***';" {
+		t.Errorf("incorrect extra: %v", e.Extra)
+	}
+}
@@ -0,0 +1,187 @@
+package goose
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerOptions configures the HTTP admin server returned by NewServer.
+type ServerOptions struct {
+	// Tokens maps the bearer tokens accepted by the server to the name of
+	// their operator, logged with every request and recorded in goose_audit
+	// when auditing is enabled. Without tokens, every request is refused.
+	Tokens map[string]string
+}
+
+// RunResult is the JSON response of the migrating endpoints of the server.
+type RunResult struct {
+	Status     string             `json:"status"` // applied, up_to_date or failed
+	Version    int64              `json:"version"`
+	Migrations []AppliedMigration `json:"migrations"`
+	DurationMs int64              `json:"duration_ms"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// server is the HTTP admin server of a migration folder.
+type server struct {
+	db      *sql.DB
+	dir     string
+	opts    ServerOptions
+	running chan struct{} // holds a value while migrations run
+}
+
+// NewServer returns an HTTP handler exposing the migrations of dir, so that
+// they can be observed and run from internal tooling:
+//
+//	GET  /status              status of all the migrations
+//	GET  /pending             migrations not applied yet
+//	POST /up                  apply all the pending migrations
+//	POST /up-to?version=N     apply the migrations up to version N
+//	POST /down                roll back the latest migration
+//	POST /down-to?version=N   roll back to version N, or an RFC 3339 time
+//
+// Requests must carry one of the tokens of opts in an
+// "Authorization: Bearer TOKEN" header. Only one run is allowed at a time,
+// concurrent ones get a 409 Conflict.
+func NewServer(db *sql.DB, dir string, opts ServerOptions) http.Handler {
+	s := &server{db: db, dir: dir, opts: opts, running: make(chan struct{}, 1)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/pending", s.handleStatus)
+	for _, command := range []string{"up", "up-to", "down", "down-to"} {
+		mux.HandleFunc("/"+command, s.handleRun)
+	}
+	return s.authenticate(mux)
+}
+
+// authenticate refuses the requests without a valid token, and logs the
+// others with their operator.
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operator, ok := s.operator(r)
+		if !ok {
+			log.Printf("WARNING: goose: serve: unauthorized %s %s from %s\n", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSONError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		log.Printf("goose: serve: %s %s by %s from %s\n", r.Method, r.URL.RequestURI(), operator, r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(withOperator(r.Context(), operator)))
+	})
+}
+
+// operator returns the operator of the bearer token of r.
+func (s *server) operator(r *http.Request) (string, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return "", false
+	}
+	for t, operator := range s.opts.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, r.Method+" not allowed, use GET")
+		return
+	}
+	statuses, err := GetStatusContext(r.Context(), s.db, s.dir)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	records := statusRecords(statuses)
+	if r.URL.Path == "/pending" {
+		pending := records[:0]
+		for _, rec := range records {
+			if rec.State == "pending" {
+				pending = append(pending, rec)
+			}
+		}
+		records = pending
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (s *server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, r.Method+" not allowed, use POST")
+		return
+	}
+	command := strings.TrimPrefix(r.URL.Path, "/")
+	var args []string
+	if strings.HasSuffix(command, "-to") {
+		version := r.URL.Query().Get("version")
+		if _, err := parseDownTarget(version); err != nil || (command == "up-to" && !isVersionNumber(version)) {
+			writeJSONError(w, http.StatusBadRequest, command+" needs a version parameter")
+			return
+		}
+		args = []string{version}
+	}
+
+	select {
+	case s.running <- struct{}{}:
+		defer func() { <-s.running }()
+	default:
+		writeJSONError(w, http.StatusConflict, "migrations are already running")
+		return
+	}
+
+	started := time.Now()
+	result := RunResult{Migrations: []AppliedMigration{}}
+	err := RunWithOptionsContext(r.Context(), command, s.db, s.dir, args, WithEventHandler(func(e Event) {
+		if e, ok := e.(MigrationApplied); ok {
+			result.Migrations = append(result.Migrations, e.AppliedMigration)
+		}
+	}))
+	if err == nil {
+		result.Version, err = GetDBVersionContext(r.Context(), s.db)
+	}
+	result.DurationMs = int64(time.Since(started) / time.Millisecond)
+
+	code := http.StatusOK
+	switch {
+	case err != nil:
+		result.Status = "failed"
+		result.Error = err.Error()
+		code = http.StatusInternalServerError
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			code = http.StatusUnprocessableEntity
+		}
+	case len(result.Migrations) > 0:
+		result.Status = "applied"
+	default:
+		result.Status = "up_to_date"
+	}
+	log.Printf("goose: serve: %s by %s: %s, %d migrations\n", command, operatorFrom(r.Context()), result.Status, len(result.Migrations))
+	writeJSON(w, code, result)
+}
+
+// isVersionNumber reports whether s is a version rather than a time.
+func isVersionNumber(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("goose: serve: writing response: %v\n", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,36 @@
+package goose
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServerRequests(t *testing.T) {
+	h := NewServer(nil, "examples/sql-migrations", ServerOptions{Tokens: map[string]string{"secret": "alice"}})
+
+	tests := []struct {
+		method, target, token string
+		code                  int
+	}{
+		{"GET", "/status", "", http.StatusUnauthorized},
+		{"GET", "/status", "wrong", http.StatusUnauthorized},
+		{"POST", "/status", "secret", http.StatusMethodNotAllowed},
+		{"GET", "/up", "secret", http.StatusMethodNotAllowed},
+		{"POST", "/up-to", "secret", http.StatusBadRequest},
+		{"POST", "/up-to?version=2017-05-06T08:00:00Z", "secret", http.StatusBadRequest},
+		{"POST", "/down-to?version=yesterday", "secret", http.StatusBadRequest},
+		{"POST", "/reset", "secret", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.target, nil)
+		if test.token != "" {
+			r.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s %s: got %d, want %d", test.method, test.target, w.Code, test.code)
+		}
+	}
+}
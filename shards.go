@@ -0,0 +1,130 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Shard is one of the databases of a sharded deployment.
+type Shard struct {
+	Name     string `yaml:"name"`
+	DBString string `yaml:"dbstring"`
+}
+
+// ShardOptions configures RunShards.
+type ShardOptions struct {
+	Driver string
+	Shards []Shard
+	// Discover lists the shards when Shards is empty, e.g. from a service
+	// registry.
+	Discover func() ([]Shard, error)
+	// Parallelism is the number of shards migrated at once, 1 by default.
+	Parallelism int
+	// ContinueOnError keeps migrating the other shards after a failure,
+	// instead of not starting the remaining ones.
+	ContinueOnError bool
+}
+
+// RunShards runs command against every shard and logs a report of the
+// results. The status command prints a combined view instead, listing for
+// every migration the shards it is pending on.
+func RunShards(dir, command string, args []string, opts ShardOptions) ([]TargetResult, error) {
+	shards := opts.Shards
+	if len(shards) == 0 && opts.Discover != nil {
+		var err error
+		if shards, err = opts.Discover(); err != nil {
+			return nil, fmt.Errorf("discovering shards: %v", err)
+		}
+	}
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no shards")
+	}
+
+	byName := map[string]Shard{}
+	names := make([]string, 0, len(shards))
+	for _, s := range shards {
+		if _, ok := byName[s.Name]; ok || s.Name == "" {
+			return nil, fmt.Errorf("shard names must be unique and not empty, got %q", s.Name)
+		}
+		byName[s.Name] = s
+		names = append(names, s.Name)
+	}
+
+	open := func(name string, fn func(db *sql.DB) (int64, error)) (int64, error) {
+		db, err := sql.Open(opts.Driver, byName[name].DBString)
+		if err != nil {
+			return 0, err
+		}
+		defer db.Close()
+		return fn(db)
+	}
+
+	if command == "status" {
+		var mu sync.Mutex
+		statuses := map[string][]MigrationStatus{}
+		results := runEach(names, opts.Parallelism, !opts.ContinueOnError, func(name string) (int64, error) {
+			return open(name, func(db *sql.DB) (int64, error) {
+				s, err := GetStatus(db, dir)
+				if err != nil {
+					return 0, err
+				}
+				mu.Lock()
+				statuses[name] = s
+				mu.Unlock()
+				return GetDBVersion(db)
+			})
+		})
+		printShardStatus(names, statuses)
+		return results, targetsError("shards", results)
+	}
+
+	results := runEach(names, opts.Parallelism, !opts.ContinueOnError, func(name string) (int64, error) {
+		return open(name, func(db *sql.DB) (int64, error) {
+			if err := Run(command, db, dir, args...); err != nil {
+				return 0, err
+			}
+			return GetDBVersion(db)
+		})
+	})
+	printTargetReport("Shard", results)
+	return results, targetsError("shards", results)
+}
+
+// printShardStatus prints, for every migration, the number of shards it is
+// applied on and the shards it is pending on.
+func printShardStatus(names []string, statuses map[string][]MigrationStatus) {
+	files := map[int64]string{}
+	applied := map[int64]int{}
+	pending := map[int64][]string{}
+	for _, name := range names {
+		for _, s := range statuses[name] {
+			files[s.Version] = filepath.Base(s.Source)
+			if s.Applied {
+				applied[s.Version]++
+			} else {
+				pending[s.Version] = append(pending[s.Version], name)
+			}
+		}
+	}
+	versions := make([]int64, 0, len(files))
+	for v := range files {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	log.Printf("    %-40s %-9s %s\n", "Migration", "Applied", "Pending on")
+	log.Println("    ==========================================================")
+	for _, v := range versions {
+		count := fmt.Sprintf("%-9s", fmt.Sprintf("%d/%d", applied[v], len(statuses)))
+		if len(pending[v]) > 0 {
+			count = colorize(colorYellow, count)
+		} else {
+			count = colorize(colorGreen, count)
+		}
+		log.Printf("    %-40s %s %s\n", files[v], count, strings.Join(pending[v], ", "))
+	}
+}
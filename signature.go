@@ -0,0 +1,131 @@
+package goose
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SignatureVerifier verifies a detached signature over the migrations
+// manifest before any migration is collected.
+type SignatureVerifier interface {
+	// SignatureExt returns the extension of the signature file, next to
+	// the manifest, e.g. ".sig" for migrations.yaml.sig.
+	SignatureExt() string
+	// Verify returns an error unless signature is a valid signature of
+	// manifest.
+	Verify(manifest, signature []byte) error
+}
+
+var signatureVerifier SignatureVerifier
+
+// SetSignatureVerifier requires migrations folders to have a signed
+// migrations.yaml manifest, refusing to collect migrations otherwise. As
+// the signature only covers the manifest, every SQL migration it lists must
+// then have a sha256. A nil verifier disables the check.
+func SetSignatureVerifier(v SignatureVerifier) {
+	signatureVerifier = v
+}
+
+// GPGVerifier checks the migrations.yaml.sig detached GPG signature with
+// gpgv against the public keys in Keyring.
+type GPGVerifier struct {
+	Keyring string
+}
+
+// SignatureExt implements SignatureVerifier.
+func (v GPGVerifier) SignatureExt() string {
+	return ".sig"
+}
+
+// Verify implements SignatureVerifier.
+func (v GPGVerifier) Verify(manifest, signature []byte) error {
+	// gpgv looks up keyrings without a slash in its home directory.
+	keyring, err := filepath.Abs(v.Keyring)
+	if err != nil {
+		return err
+	}
+	return runVerifier(manifest, signature, func(manifest, signature string) []string {
+		return []string{"gpgv", "--keyring", keyring, signature, manifest}
+	})
+}
+
+// SigstoreVerifier checks the migrations.yaml.sigstore.json bundle with
+// cosign, either against a public key or a keyless signing identity.
+type SigstoreVerifier struct {
+	Key      string // public key; if empty, Identity and Issuer are used
+	Identity string // expected certificate identity, e.g. a CI workflow
+	Issuer   string // expected OIDC issuer of the identity
+}
+
+// SignatureExt implements SignatureVerifier.
+func (v SigstoreVerifier) SignatureExt() string {
+	return ".sigstore.json"
+}
+
+// Verify implements SignatureVerifier.
+func (v SigstoreVerifier) Verify(manifest, signature []byte) error {
+	return runVerifier(manifest, signature, func(manifest, signature string) []string {
+		args := []string{"cosign", "verify-blob", "--bundle", signature}
+		if v.Key != "" {
+			args = append(args, "--key", v.Key)
+		} else {
+			args = append(args, "--certificate-identity", v.Identity, "--certificate-oidc-issuer", v.Issuer)
+		}
+		return append(args, manifest)
+	})
+}
+
+// runVerifier runs the command line returned by command on temporary
+// copies of the manifest and its signature, so that the command verifies
+// the very bytes goose parses.
+func runVerifier(manifest, signature []byte, command func(manifest, signature string) []string) error {
+	dir, err := ioutil.TempDir("", "goose-signature")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	manifestPath, signaturePath := filepath.Join(dir, ManifestFile), filepath.Join(dir, ManifestFile+".sig")
+	if err := ioutil.WriteFile(manifestPath, manifest, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(signaturePath, signature, 0600); err != nil {
+		return err
+	}
+
+	args := command(manifestPath, signaturePath)
+	var out bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v: %s", args[0], err, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+// readSignedManifest reads the manifest of dir, as ReadManifest, enforcing
+// the configured signature verifier. The manifest is read once: the bytes
+// verified are the ones parsed.
+func readSignedManifest(c *config, dir string) (*Manifest, error) {
+	if signatureVerifier == nil {
+		return readManifest(c, dir)
+	}
+	path := filepath.Join(dir, ManifestFile)
+	b, err := c.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("signature verification requires a signed %s: %v", ManifestFile, err)
+	}
+	signature, err := c.readFile(path + signatureVerifier.SignatureExt())
+	if err != nil {
+		return nil, fmt.Errorf("%s signature verification failed: %v", ManifestFile, err)
+	}
+	if err := signatureVerifier.Verify(b, signature); err != nil {
+		return nil, fmt.Errorf("%s signature verification failed: %v", ManifestFile, err)
+	}
+	return parseManifest(b)
+}
@@ -0,0 +1,67 @@
+package goose
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type fakeVerifier struct {
+	err                 error
+	manifest, signature string
+}
+
+func (v *fakeVerifier) SignatureExt() string {
+	return ".sig"
+}
+
+func (v *fakeVerifier) Verify(manifest, signature []byte) error {
+	v.manifest, v.signature = string(manifest), string(signature)
+	return v.err
+}
+
+func TestManifestSignature(t *testing.T) {
+	const users = "-- +goose Up\nCREATE TABLE users (id int);\n"
+	const sum = "7af9c1fda6f20bc29e8c2cb2d8e4e0a9d3c3d56a1a66ff3e47c0d4cd82a0a5e1"
+	const manifest = "migrations:\n- file: 00001_users.sql\n  sha256: " + sum + "\n"
+	fsys := fstest.MapFS{
+		"signed/00001_users.sql":     {Data: []byte(users)},
+		"signed/migrations.yaml":     {Data: []byte(manifest)},
+		"signed/migrations.yaml.sig": {Data: []byte("signature")},
+		"unsigned/00001_users.sql":   {Data: []byte(users)},
+		"nosum/00001_users.sql":      {Data: []byte(users)},
+		"nosum/migrations.yaml":      {Data: []byte("migrations:\n- file: 00001_users.sql\n")},
+		"nosum/migrations.yaml.sig":  {Data: []byte("signature")},
+	}
+	SetBaseFS(fsys)
+	defer SetBaseFS(nil)
+	v := &fakeVerifier{}
+	SetSignatureVerifier(v)
+	defer SetSignatureVerifier(nil)
+
+	migrations, err := CollectMigrations("signed", minVersion, maxVersion)
+	if err != nil {
+		t.Fatalf("signed manifest: %v", err)
+	}
+	// The manifest is verified from the base filesystem.
+	if v.manifest != manifest || v.signature != "signature" {
+		t.Errorf("verified %q with %q, want the manifest and its signature", v.manifest, v.signature)
+	}
+	// The file doesn't match the sha256 the signed manifest lists.
+	if err := migrations[0].verifyChecksum(packageConfig()); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("tampered file: got %v, want a checksum mismatch", err)
+	}
+
+	if _, err := CollectMigrations("unsigned", minVersion, maxVersion); err == nil || !strings.Contains(err.Error(), "requires a signed migrations.yaml") {
+		t.Errorf("missing manifest: got %v", err)
+	}
+	if _, err := CollectMigrations("nosum", minVersion, maxVersion); err == nil || !strings.Contains(err.Error(), "has no sha256") {
+		t.Errorf("missing sha256: got %v", err)
+	}
+
+	v.err = errors.New("BAD signature")
+	if _, err := CollectMigrations("signed", minVersion, maxVersion); err == nil || !strings.Contains(err.Error(), "signature verification failed: BAD signature") {
+		t.Errorf("bad signature: got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package goose
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SnapshotFile is the default name of the schema snapshot.
+const SnapshotFile = "schema.snapshot"
+
+// SchemaSnapshot returns a normalized description of the tables, columns,
+// constraints and indexes of the current schema, stable across runs so that
+// it can be committed as a golden file.
+func SchemaSnapshot(db *sql.DB) (string, error) {
+	schema, err := readSchema(db)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, name := range schema.names() {
+		t := schema[name]
+		fmt.Fprintf(&b, "table %s\n", name)
+		for _, c := range t.columns {
+			fmt.Fprintf(&b, "  column %s\n", c.definition())
+		}
+		for _, name := range sortedKeys(t.constraints) {
+			fmt.Fprintf(&b, "  constraint %s %s\n", name, t.constraints[name])
+		}
+		for _, name := range sortedKeys(t.indexes) {
+			fmt.Fprintf(&b, "  index %s %s\n", name, t.indexes[name])
+		}
+	}
+	return b.String(), nil
+}
+
+// WriteSnapshot writes the schema snapshot of db to path.
+func WriteSnapshot(db *sql.DB, path string) error {
+	snapshot, err := SchemaSnapshot(db)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, []byte(snapshot), 0644); err != nil {
+		return err
+	}
+	log.Printf("goose: wrote schema snapshot %s\n", path)
+	return nil
+}
+
+// CheckSnapshot compares the schema of db with the snapshot at path,
+// returning an error listing the differences.
+func CheckSnapshot(db *sql.DB, path string) error {
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	got, err := SchemaSnapshot(db)
+	if err != nil {
+		return err
+	}
+	if diff := snapshotDiff(string(want), got); diff != "" {
+		return fmt.Errorf("schema differs from %s:\n%s", path, diff)
+	}
+	log.Printf("goose: schema matches %s\n", path)
+	return nil
+}
+
+// snapshotDiff lists the lines only in want (-) or got (+), under their
+// table.
+func snapshotDiff(want, got string) string {
+	wantLines, gotLines := snapshotLines(want), snapshotLines(got)
+
+	var diff []string
+	for line := range wantLines {
+		if !gotLines[line] {
+			diff = append(diff, "-"+line)
+		}
+	}
+	for line := range gotLines {
+		if !wantLines[line] {
+			diff = append(diff, "+"+line)
+		}
+	}
+	// Sort by line, then removed before added.
+	sort.Slice(diff, func(i, j int) bool {
+		if diff[i][1:] != diff[j][1:] {
+			return diff[i][1:] < diff[j][1:]
+		}
+		return diff[i] < diff[j]
+	})
+	return strings.Join(diff, "\n")
+}
+
+// snapshotLines qualifies every line of a snapshot with its table, so that
+// lines can be compared regardless of their position.
+func snapshotLines(snapshot string) map[string]bool {
+	lines := map[string]bool{}
+	table := ""
+	for _, line := range strings.Split(snapshot, "\n") {
+		switch {
+		case strings.HasPrefix(line, "table "):
+			table = strings.TrimPrefix(line, "table ")
+			lines[line] = true
+		case strings.TrimSpace(line) != "":
+			lines[table+":"+strings.TrimSpace(line)] = true
+		}
+	}
+	return lines
+}
+
+// snapshot implements the snapshot command: snapshot [-check] [FILE], FILE
+// defaulting to the snapshot in the migrations folder.
+func snapshot(db *sql.DB, dir string, args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	check := fs.Bool("check", false, "fail if the schema differs from the snapshot")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, SnapshotFile)
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	if *check {
+		return CheckSnapshot(db, path)
+	}
+	return WriteSnapshot(db, path)
+}
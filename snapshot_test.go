@@ -0,0 +1,26 @@
+package goose
+
+import "testing"
+
+func TestSnapshotDiff(t *testing.T) {
+	want := `table posts
+  column id integer NOT NULL
+table users
+  column id integer NOT NULL
+  column name text
+  index users_pkey CREATE UNIQUE INDEX users_pkey ON users USING btree (id)
+`
+	got := `table posts
+  column id integer NOT NULL
+table users
+  column id integer NOT NULL
+  column name text NOT NULL
+  index users_pkey CREATE UNIQUE INDEX users_pkey ON users USING btree (id)
+`
+	if diff := snapshotDiff(want, want); diff != "" {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+	if diff, expected := snapshotDiff(want, got), "-users:column name text\n+users:column name text NOT NULL"; diff != expected {
+		t.Errorf("incorrect diff. got %q, want %q", diff, expected)
+	}
+}
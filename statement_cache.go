@@ -0,0 +1,99 @@
+package goose
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parsedCacheMaxBytes bounds the size of the statements kept by the parsed
+// statement cache. Files parsed once it is full aren't cached.
+const parsedCacheMaxBytes = 64 * 1024 * 1024
+
+// parsedKey identifies a version of a migration file and a direction, and
+// whether DELIMITER lines were parsed. Files read by a Provider are keyed by
+// it, as its filesystem may have other files at the same path.
+type parsedKey struct {
+	provider   *Provider
+	path       string
+	direction  bool
+	modTime    time.Time
+	size       int64
+	delimiters bool
+}
+
+type parsedSQL struct {
+	stmts []string
+	lines []int
+	tx    bool
+}
+
+var (
+	parsedCacheMu    sync.Mutex
+	parsedCache      = map[parsedKey]*parsedSQL{}
+	parsedCacheBytes = 0
+)
+
+// readSQLStatements returns the statements of one direction of a SQL
+// migration file, like sqlStatements. Parsed files are cached for the
+// lifetime of the process by path, size and modification time, so that
+// redo, or plan then apply, don't parse big files again. The returned
+// slices must not be modified.
+func readSQLStatements(c *config, path string, direction bool) (stmts []string, lines []int, tx bool, err error) {
+	paired := isPairedMigration(path)
+	if paired {
+		path = pairedFile(path, direction)
+	}
+
+	key := parsedKey{provider: c.provider, path: path, direction: direction, delimiters: hasDelimiterCommand(c)}
+	if b, ok := memoryFile(path); ok {
+		key.size = int64(len(b))
+	} else {
+		info, err := c.statFile(path)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		key.modTime, key.size = info.ModTime(), info.Size()
+	}
+
+	parsedCacheMu.Lock()
+	p, ok := parsedCache[key]
+	parsedCacheMu.Unlock()
+	if ok {
+		return p.stmts, p.lines, p.tx, nil
+	}
+
+	f, err := openSQLFile(c, path)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer f.Close()
+	if paired {
+		// The whole file is the section of its direction.
+		section := sqlCmdPrefix + "Up\n"
+		if stmts, lines, tx, err = parseSQLStatements(c, io.MultiReader(strings.NewReader(section), f), true); err != nil {
+			return nil, nil, false, err
+		}
+		if len(stmts) > 0 {
+			stmts[0] = strings.TrimPrefix(stmts[0], section)
+		}
+		for i := range lines {
+			lines[i]--
+		}
+	} else if stmts, lines, tx, err = sqlStatements(c, f, direction); err != nil {
+		return nil, nil, false, err
+	}
+
+	size := 0
+	for _, s := range stmts {
+		size += len(s)
+	}
+	parsedCacheMu.Lock()
+	if parsedCacheBytes+size <= parsedCacheMaxBytes {
+		parsedCache[key] = &parsedSQL{stmts: stmts, lines: lines, tx: tx}
+		parsedCacheBytes += size
+	}
+	parsedCacheMu.Unlock()
+	return stmts, lines, tx, nil
+}
@@ -0,0 +1,43 @@
+package goose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSQLStatementsCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goose-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "00001_users.sql")
+	if err := ioutil.WriteFile(path, []byte("-- +goose Up\nCREATE TABLE users (id int);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, _, _, err := readSQLStatements(packageConfig(), path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _, _, err := readSQLStatements(packageConfig(), path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || &first[0] != &again[0] {
+		t.Errorf("expected the cached statements, got %q and %q", first, again)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("-- +goose Up\nCREATE TABLE users (id int);\nCREATE TABLE roles (id int);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, _, _, err := readSQLStatements(packageConfig(), path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("expected the changed file to be parsed again, got %q", changed)
+	}
+}
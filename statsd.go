@@ -0,0 +1,103 @@
+package goose
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// StatsdOptions configures the metrics sent to a StatsD or DogStatsD agent.
+type StatsdOptions struct {
+	// Addr is the host:port of the agent, e.g. localhost:8125.
+	Addr string
+	// Prefix is prepended to metric names, "goose." by default.
+	Prefix string
+	// Tags are added to every metric in the DogStatsD key:value form, e.g.
+	// service:billing or env:production. The database name is added as
+	// database:NAME.
+	Tags []string
+}
+
+var (
+	statsdConn net.Conn
+	statsdOpts StatsdOptions
+)
+
+// EnableStatsd sends run and migration metrics to a StatsD agent over UDP:
+//
+//	goose.run.duration        timer, tagged with command and status
+//	goose.run.migrations      counter of migrations applied by the run
+//	goose.migration.duration  timer, tagged with version, file and direction
+//	goose.migration.rows      counter of affected rows, same tags
+func EnableStatsd(opts StatsdOptions) error {
+	conn, err := net.Dial("udp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("statsd: %v", err)
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "goose."
+	}
+	statsdConn, statsdOpts = conn, opts
+	return nil
+}
+
+// sendRunMetrics sends the metrics of a finished run. Delivery failures are
+// logged, they never fail the run.
+func sendRunMetrics(r *runReport, err error) {
+	if statsdConn == nil {
+		return
+	}
+
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	runTags := []string{"command:" + r.command, "status:" + status}
+
+	metrics := []string{
+		statsdMetric("run.duration", durationMillis(time.Since(r.started)), "ms", runTags),
+		statsdMetric("run.migrations", int64(len(r.migrations)), "c", runTags),
+	}
+	for _, m := range r.migrations {
+		tags := []string{
+			fmt.Sprintf("version:%d", m.Version),
+			"file:" + m.File,
+			"direction:" + m.Direction,
+		}
+		metrics = append(metrics,
+			statsdMetric("migration.duration", durationMillis(m.Duration), "ms", tags),
+			statsdMetric("migration.rows", m.RowsAffected, "c", tags),
+		)
+	}
+
+	// One packet per metric stays below the UDP payload limit.
+	for _, metric := range metrics {
+		if _, err := statsdConn.Write([]byte(metric)); err != nil {
+			log.Printf("goose: statsd: %v\n", err)
+			return
+		}
+	}
+}
+
+// statsdMetric formats a metric in the DogStatsD format:
+// name:value|type|#tag:value,...
+func statsdMetric(name string, value int64, typ string, tags []string) string {
+	tags = append(append(tags[:len(tags):len(tags)], statsdOpts.Tags...), databaseTag()...)
+	metric := fmt.Sprintf("%s%s:%d|%s", statsdOpts.Prefix, name, value, typ)
+	if len(tags) > 0 {
+		metric += "|#" + strings.Join(tags, ",")
+	}
+	return metric
+}
+
+func databaseTag() []string {
+	if databaseName == "" {
+		return nil
+	}
+	return []string{"database:" + databaseName}
+}
+
+func durationMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
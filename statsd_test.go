@@ -0,0 +1,46 @@
+package goose
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSendRunMetrics(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := EnableStatsd(StatsdOptions{Addr: conn.LocalAddr().String(), Tags: []string{"env:test"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { statsdConn = nil }()
+
+	r := &runReport{command: "up", started: time.Now(), migrations: []AppliedMigration{
+		{Version: 1, File: "00001_users.sql", Direction: "up", Duration: 1500 * time.Millisecond, RowsAffected: 3},
+	}}
+	sendRunMetrics(r, nil)
+
+	want := []string{
+		"goose.run.migrations:1|c|#command:up,status:success,env:test",
+		"goose.migration.duration:1500|ms|#version:1,file:00001_users.sql,direction:up,env:test",
+		"goose.migration.rows:3|c|#version:1,file:00001_users.sql,direction:up,env:test",
+	}
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	// Skip the run duration, which isn't deterministic.
+	if _, _, err := conn.ReadFrom(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range want {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != w {
+			t.Errorf("incorrect metric. got %q, want %q", got, w)
+		}
+	}
+}
@@ -1,51 +1,252 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"os"
 	"path/filepath"
+	"strconv"
 	"time"
+
+	"gopkg.in/yaml.v2"
 )
 
+// MigrationStatus is the state of a single migration.
+type MigrationStatus struct {
+	Version   int64
+	Source    string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// State returns "applied" or "pending".
+func (s MigrationStatus) State() string {
+	if s.Applied {
+		return "applied"
+	}
+	return "pending"
+}
+
 // Status prints the status of all migrations.
 func Status(db *sql.DB, dir string) error {
+	return printStatus(context.Background(), db, dir, statusOptions{format: "table"}, false)
+}
+
+// Pending prints the migrations that haven't been applied yet.
+func Pending(db *sql.DB, dir string) error {
+	return printStatus(context.Background(), db, dir, statusOptions{format: "table"}, true)
+}
+
+// statusOptions are the flags of the status and pending commands.
+type statusOptions struct {
+	format string
+	// check fails with ErrPending when migrations are pending, so that CI
+	// pipelines can block deploys to databases not migrated yet.
+	check bool
+	// checksums also fails when the migration files don't match goose.lock.
+	checksums bool
+}
+
+// parseStatusArgs parses "[-format=F] [-check] [-checksums]".
+func parseStatusArgs(command string, args []string) (statusOptions, error) {
+	var opts statusOptions
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.StringVar(&opts.format, "format", "table", "output format: table, yaml, csv or json")
+	asJSON := fs.Bool("json", false, "same as -format=json")
+	fs.BoolVar(&opts.check, "check", false, "fail when migrations are pending")
+	fs.BoolVar(&opts.checksums, "checksums", false, "with -check, also fail when the migration files don't match goose.lock")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.checksums && !opts.check {
+		return opts, fmt.Errorf("-checksums only applies with -check")
+	}
+	if *asJSON {
+		opts.format = "json"
+	}
+	return opts, nil
+}
+
+// GetStatus returns the status of all migrations.
+func GetStatus(db *sql.DB, dir string) ([]MigrationStatus, error) {
+	return GetStatusContext(context.Background(), db, dir)
+}
+
+// GetStatusContext is GetStatus with a context, canceling its queries.
+func GetStatusContext(ctx context.Context, db *sql.DB, dir string) ([]MigrationStatus, error) {
 	// collect all migrations
-	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	migrations, err := collectMigrations(configFrom(ctx), dir, minVersion, maxVersion)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// must ensure that the version table exists if we're running on a pristine DB
-	if _, err := EnsureDBVersion(db); err != nil {
-		return err
+	if _, err := ensureDBVersion(ctx, db); err != nil {
+		return nil, err
 	}
 
-	log.Println("    Applied At                  Migration")
-	log.Println("    =======================================")
+	statuses := make([]MigrationStatus, 0, len(migrations))
 	for _, migration := range migrations {
-		printMigrationStatus(db, migration.Version, filepath.Base(migration.Source))
+		status, err := migrationStatus(ctx, db, migration)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func migrationStatus(ctx context.Context, db *sql.DB, migration *Migration) (MigrationStatus, error) {
+	var row MigrationRecord
+	q := fmt.Sprintf("SELECT tstamp, is_applied FROM %s WHERE version_id=%d ORDER BY tstamp DESC LIMIT 1", configFrom(ctx).tables.version(), migration.Version)
+	if err := db.QueryRowContext(ctx, q).Scan(&row.TStamp, &row.IsApplied); err != nil && err != sql.ErrNoRows {
+		return MigrationStatus{}, err
+	}
+
+	return MigrationStatus{
+		Version:   migration.Version,
+		Source:    migration.Source,
+		Applied:   row.IsApplied,
+		AppliedAt: row.TStamp,
+	}, nil
+}
+
+func printStatus(ctx context.Context, db *sql.DB, dir string, opts statusOptions, pendingOnly bool) error {
+	c := configFrom(ctx)
+	statuses, err := GetStatusContext(ctx, db, dir)
+	if err != nil {
+		return err
+	}
+	var pending []MigrationStatus
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, s)
+		}
+	}
+	if pendingOnly {
+		statuses = pending
+	}
+
+	switch opts.format {
+	case "table":
+		printStatusTable(c.log, statuses)
+	case "yaml":
+		err = writeStatusYAML(os.Stdout, statuses)
+	case "csv":
+		err = writeStatusCSV(os.Stdout, statuses)
+	case "json":
+		var current int64
+		if current, err = GetDBVersionContext(ctx, db); err == nil {
+			err = writeStatusJSON(os.Stdout, statuses, current, len(pending))
+		}
+	default:
+		err = fmt.Errorf("%q: unknown format, must be table, yaml, csv or json", opts.format)
 	}
+	if err != nil || !opts.check {
+		return err
+	}
+	return checkStatus(c, dir, pending, opts.checksums)
+}
 
+// checkStatus implements -check: it fails with ErrPending when migrations
+// are pending and, with checksums, with a ValidationError when the migration
+// files don't match goose.lock.
+func checkStatus(c *config, dir string, pending []MigrationStatus, checksums bool) error {
+	if checksums {
+		if err := verifyLock(c, dir); err != nil {
+			return &ValidationError{Err: err}
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d, the first one is %s", ErrPending, len(pending), filepath.Base(pending[0].Source))
+	}
 	return nil
 }
 
-func printMigrationStatus(db *sql.DB, version int64, script string) {
-	var row MigrationRecord
-	q := fmt.Sprintf("SELECT tstamp, is_applied FROM goose_db_version WHERE version_id=%d ORDER BY tstamp DESC LIMIT 1", version)
-	e := db.QueryRow(q).Scan(&row.TStamp, &row.IsApplied)
+func printStatusTable(l Logger, statuses []MigrationStatus) {
+	l.Printf("    %-28sMigration\n", "Applied At ("+timeZone.String()+")")
+	l.Println("    =======================================")
+	for _, s := range statuses {
+		// Pad before colorizing, escape codes would break the alignment.
+		appliedAt := colorize(colorYellow, fmt.Sprintf("%-24s", "Pending"))
+		if s.Applied {
+			appliedAt = colorize(colorGreen, fmt.Sprintf("%-24s", localTime(s.AppliedAt).Format(time.ANSIC)))
+		}
+		l.Printf("    %s -- %v\n", appliedAt, filepath.Base(s.Source))
+	}
+}
+
+// statusRecord is the serialized form of a MigrationStatus, its field order
+// defines the column order.
+type statusRecord struct {
+	Version   int64  `yaml:"version" json:"version"`
+	File      string `yaml:"file" json:"file"`
+	State     string `yaml:"state" json:"state"`
+	AppliedAt string `yaml:"applied_at" json:"applied_at,omitempty"`
+}
 
-	if e != nil && e != sql.ErrNoRows {
-		log.Fatal(e)
+func statusRecords(statuses []MigrationStatus) []statusRecord {
+	records := make([]statusRecord, 0, len(statuses))
+	for _, s := range statuses {
+		r := statusRecord{Version: s.Version, File: filepath.Base(s.Source), State: s.State()}
+		if s.Applied {
+			r.AppliedAt = localTime(s.AppliedAt).Format(time.RFC3339)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func writeStatusYAML(w io.Writer, statuses []MigrationStatus) error {
+	b, err := yaml.Marshal(statusRecords(statuses))
+	if err != nil {
+		return err
 	}
+	_, err = w.Write(b)
+	return err
+}
 
-	var appliedAt string
+// statusJSON is the document status -format=json writes. Unlike the other
+// formats, pending migrations have a null applied_at.
+type statusJSON struct {
+	CurrentVersion int64             `json:"current_version"`
+	Pending        int               `json:"pending"`
+	Migrations     []statusJSONEntry `json:"migrations"`
+}
 
-	if row.IsApplied {
-		appliedAt = row.TStamp.Format(time.ANSIC)
-	} else {
-		appliedAt = "Pending"
+type statusJSONEntry struct {
+	Version   int64   `json:"version"`
+	Name      string  `json:"name"`
+	State     string  `json:"state"`
+	AppliedAt *string `json:"applied_at"`
+}
+
+func writeStatusJSON(w io.Writer, statuses []MigrationStatus, current int64, pending int) error {
+	doc := statusJSON{CurrentVersion: current, Pending: pending, Migrations: []statusJSONEntry{}}
+	for _, r := range statusRecords(statuses) {
+		e := statusJSONEntry{Version: r.Version, Name: r.File, State: r.State}
+		if r.AppliedAt != "" {
+			appliedAt := r.AppliedAt
+			e.AppliedAt = &appliedAt
+		}
+		doc.Migrations = append(doc.Migrations, e)
 	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
 
-	log.Printf("    %-24s -- %v\n", appliedAt, script)
+func writeStatusCSV(w io.Writer, statuses []MigrationStatus) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"version", "file", "state", "applied_at"})
+	for _, r := range statusRecords(statuses) {
+		cw.Write([]string{strconv.FormatInt(r.Version, 10), r.File, r.State, r.AppliedAt})
+	}
+	cw.Flush()
+	return cw.Error()
 }
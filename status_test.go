@@ -0,0 +1,95 @@
+package goose
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testStatuses = []MigrationStatus{
+	{Version: 1, Source: "db/migrations/00001_create_users.sql", Applied: true, AppliedAt: time.Date(2019, 7, 10, 12, 0, 0, 0, time.UTC)},
+	{Version: 2, Source: "db/migrations/00002_rename_root.go"},
+}
+
+func TestWriteStatusCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeStatusCSV(&buf, testStatuses); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `version,file,state,applied_at
+1,00001_create_users.sql,applied,2019-07-10T12:00:00Z
+2,00002_rename_root.go,pending,
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteStatusYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeStatusYAML(&buf, testStatuses); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `- version: 1
+  file: 00001_create_users.sql
+  state: applied
+  applied_at: "2019-07-10T12:00:00Z"
+- version: 2
+  file: 00002_rename_root.go
+  state: pending
+  applied_at: ""
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteStatusJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeStatusJSON(&buf, testStatuses, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "current_version": 1,
+  "pending": 1,
+  "migrations": [
+    {
+      "version": 1,
+      "name": "00001_create_users.sql",
+      "state": "applied",
+      "applied_at": "2019-07-10T12:00:00Z"
+    },
+    {
+      "version": 2,
+      "name": "00002_rename_root.go",
+      "state": "pending",
+      "applied_at": null
+    }
+  ]
+}
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if opts, err := parseStatusArgs("status", []string{"-json"}); err != nil || opts.format != "json" {
+		t.Errorf("got format %q, error %v, want json", opts.format, err)
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	if err := checkStatus(packageConfig(), "examples/sql-migrations", nil, false); err != nil {
+		t.Errorf("got %v, want no error without pending migrations", err)
+	}
+	pending := []MigrationStatus{{Version: 3, Source: "examples/sql-migrations/00003_no_transaction.sql"}}
+	if err := checkStatus(packageConfig(), "examples/sql-migrations", pending, false); !errors.Is(err, ErrPending) {
+		t.Errorf("got %v, want ErrPending", err)
+	}
+	if _, err := parseStatusArgs("status", []string{"-checksums"}); err == nil {
+		t.Error("expected -checksums without -check to fail")
+	}
+}
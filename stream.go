@@ -0,0 +1,118 @@
+package goose
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// StreamDir is the migrations folder name referring to the migrations read
+// by ReadStream, e.g. `goose -dir - up < migrations.sql`.
+const StreamDir = "-"
+
+// streamFileMarker separates the files of a migration stream:
+//
+//	-- +goose File 00001_create_users_table.sql
+const streamFileMarker = sqlCmdPrefix + "File "
+
+// streamFiles holds the migrations read by ReadStream, keyed by file name.
+var streamFiles = map[string][]byte{}
+
+// ReadStream reads a concatenated stream of SQL migrations, each starting
+// with a "-- +goose File NAME" line, and keeps them in memory. They are
+// used instead of the filesystem when the migrations folder is StreamDir.
+func ReadStream(r io.Reader) error {
+	files := map[string][]byte{}
+
+	var name string
+	var buf bytes.Buffer
+	flush := func() {
+		if name != "" {
+			files[name] = append([]byte(nil), buf.Bytes()...)
+		}
+		buf.Reset()
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, readerBufSize), maxLineSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, streamFileMarker) {
+			flush()
+			name = path.Base(strings.TrimSpace(line[len(streamFileMarker):]))
+			if !isSQLMigration(name) || strings.HasSuffix(name, gzipSQLExt) {
+				return fmt.Errorf("stdin:%d: %q is not an SQL migration", n, name)
+			}
+			if _, ok := files[name]; ok {
+				return fmt.Errorf("stdin:%d: duplicate file %q", n, name)
+			}
+			continue
+		}
+		if name == "" {
+			if strings.TrimSpace(line) != "" {
+				return fmt.Errorf("stdin:%d: expected %q before any SQL", n, strings.TrimSpace(streamFileMarker)+" NAME")
+			}
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	flush()
+
+	streamFiles = files
+	return nil
+}
+
+func collectStreamMigrations(current, target int64) (Migrations, error) {
+	var migrations Migrations
+	for name := range streamFiles {
+		// The down file of a paired migration goes with its up file.
+		if strings.HasSuffix(name, migrateDownExt) {
+			if _, ok := streamFiles[strings.TrimSuffix(name, migrateDownExt)+migrateUpExt]; !ok {
+				return nil, fmt.Errorf("%s: no matching %s file", name, migrateUpExt)
+			}
+			continue
+		}
+		if _, ok := streamFiles[pairedDownFile(name)]; isPairedMigration(name) && !ok {
+			return nil, fmt.Errorf("%s: no matching %s file", name, migrateDownExt)
+		}
+		v, err := NumericComponent(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if versionFilter(v, current, target) {
+			migrations = append(migrations, &Migration{Version: v, Next: -1, Previous: -1, Source: StreamDir + "/" + name})
+		}
+	}
+
+	for v, migration := range registeredGoMigrations {
+		if versionFilter(v, current, target) {
+			migrations = append(migrations, migration)
+		}
+	}
+	return migrations, nil
+}
+
+// streamFile returns the contents of a migration read by ReadStream.
+func streamFile(source string) ([]byte, bool) {
+	if !strings.HasPrefix(source, StreamDir+"/") {
+		return nil, false
+	}
+	b, ok := streamFiles[source[len(StreamDir)+1:]]
+	return b, ok
+}
+
+// memoryFile returns the contents of a migration kept in memory rather than
+// read from the filesystem: read by ReadStream, or stored Down SQL.
+func memoryFile(source string) ([]byte, bool) {
+	if b, ok := streamFile(source); ok {
+		return b, true
+	}
+	return storedScript(source)
+}
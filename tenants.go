@@ -0,0 +1,148 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TargetResult is the outcome of a command on one of several schemas or
+// databases.
+type TargetResult struct {
+	Target   string
+	Version  int64 // version after the command
+	Duration time.Duration
+	Err      error
+}
+
+// TenantOptions configures RunTenants.
+type TenantOptions struct {
+	Pattern     string // LIKE pattern of the tenant schemas, e.g. tenant_%
+	Parallelism int    // schemas migrated at once, 1 by default
+	// Open connects to the database with schema as the search path, so that
+	// each tenant has its own goose_db_version table.
+	Open func(schema string) (*sql.DB, error)
+}
+
+// RunTenants runs command in every schema of db matching the pattern, at
+// most Parallelism at a time, and logs a report of the results. A failing
+// tenant doesn't stop the others; the returned error counts the failures.
+func RunTenants(db *sql.DB, dir, command string, args []string, opts TenantOptions) ([]TargetResult, error) {
+	schemas, err := tenantSchemas(db, opts.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("no schema matches %q", opts.Pattern)
+	}
+
+	results := runEach(schemas, opts.Parallelism, false, func(schema string) (int64, error) {
+		tenantDB, err := opts.Open(schema)
+		if err != nil {
+			return 0, err
+		}
+		defer tenantDB.Close()
+
+		// The schemas share the lock of the database: it would serialize
+		// them.
+		if err := RunWithOptions(command, tenantDB, dir, args, withoutMigrationLock()); err != nil {
+			return 0, err
+		}
+		return GetDBVersion(tenantDB)
+	})
+	printTargetReport("Schema", results)
+	return results, targetsError("tenants", results)
+}
+
+func tenantSchemas(db *sql.DB, pattern string) ([]string, error) {
+	rows, err := db.Query("SELECT schema_name FROM information_schema.schemata WHERE schema_name LIKE "+placeholder(1)+" ORDER BY schema_name", pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, rows.Err()
+}
+
+// runEach calls fn for every target, at most parallelism at a time. With
+// stopOnError, targets not started yet when one fails are skipped and have
+// no result.
+func runEach(targets []string, parallelism int, stopOnError bool, fn func(target string) (int64, error)) []TargetResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]TargetResult, len(targets))
+	ran := make([]bool, len(targets))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := false
+
+	for i, target := range targets {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := stopOnError && failed
+		mu.Unlock()
+		if stop {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, target string) {
+			defer func() { <-sem; wg.Done() }()
+
+			started := time.Now()
+			version, err := fn(target)
+			results[i] = TargetResult{Target: target, Version: version, Duration: time.Since(started), Err: err}
+			mu.Lock()
+			ran[i] = true
+			failed = failed || err != nil
+			mu.Unlock()
+		}(i, target)
+	}
+	wg.Wait()
+
+	done := results[:0]
+	for i, r := range results {
+		if ran[i] {
+			done = append(done, r)
+		}
+	}
+	return done
+}
+
+func printTargetReport(kind string, results []TargetResult) {
+	log.Printf("    %-30s %-15s %s\n", kind, "Version", "Result")
+	log.Println("    ==========================================================")
+	for _, r := range results {
+		result := colorize(colorGreen, "OK")
+		if r.Err != nil {
+			result = colorize(colorRed, r.Err.Error())
+		}
+		log.Printf("    %-30s %-15d %s\n", r.Target, r.Version, result)
+	}
+}
+
+func targetsError(kind string, results []TargetResult) error {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %s failed", failed, len(results), kind)
+	}
+	return nil
+}
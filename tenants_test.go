@@ -0,0 +1,56 @@
+package goose
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunEach(t *testing.T) {
+	var running, max int32
+	results := runEach([]string{"a", "b", "c", "d", "e"}, 2, false, func(target string) (int64, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&running, -1)
+		if target == "c" {
+			return 0, errors.New("boom")
+		}
+		return 3, nil
+	})
+	if len(results) != 5 || max > 2 {
+		t.Fatalf("got %d results with %d running at once", len(results), max)
+	}
+	if results[2].Target != "c" || results[2].Err == nil || results[0].Version != 3 {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if err := targetsError("tenants", results); err == nil || err.Error() != "1 of 5 tenants failed" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	results = runEach([]string{"a", "b", "c"}, 1, true, func(target string) (int64, error) {
+		return 0, errors.New("boom")
+	})
+	if len(results) != 1 {
+		t.Errorf("expected to stop after the first failure, got %+v", results)
+	}
+}
+
+func TestPrintShardStatus(t *testing.T) {
+	logger := &captureLogger{}
+	SetLogger(logger)
+	defer SetLogger(&stdLogger{})
+
+	statuses := map[string][]MigrationStatus{
+		"a": {{Version: 1, Source: "db/00001_init.sql", Applied: true}, {Version: 2, Source: "db/00002_email.sql", Applied: true}},
+		"b": {{Version: 1, Source: "db/00001_init.sql", Applied: true}, {Version: 2, Source: "db/00002_email.sql"}},
+	}
+	printShardStatus([]string{"a", "b"}, statuses)
+	if len(logger.lines) != 4 || logger.lines[3] != "    00002_email.sql                          1/2       b" {
+		t.Errorf("unexpected status %q", logger.lines)
+	}
+}
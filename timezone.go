@@ -0,0 +1,17 @@
+package goose
+
+import "time"
+
+var timeZone = time.UTC
+
+// SetTimeZone sets the time zone of the timestamps goose displays, UTC by
+// default, so that developers in different time zones see the same times.
+func SetTimeZone(loc *time.Location) {
+	timeZone = loc
+}
+
+// localTime returns t in the configured time zone. The tstamp columns are
+// written in UTC, see utcNowSQL, so that their times are read as UTC ones.
+func localTime(t time.Time) time.Time {
+	return t.In(timeZone)
+}
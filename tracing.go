@@ -0,0 +1,65 @@
+package goose
+
+import "context"
+
+// Tracer starts spans for migration runs, migrations and statements.
+//
+// It mirrors the subset of the OpenTelemetry tracing API goose needs, so an
+// OpenTelemetry tracer can be plugged in with a small adapter without goose
+// depending on it:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, goose.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name)
+//		for k, v := range attrs {
+//			span.SetAttributes(attribute.String(k, fmt.Sprint(v)))
+//		}
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	RecordError(err error)
+	End()
+}
+
+var tracer Tracer = noopTracer{}
+
+// SetTracer sets the Tracer instrumenting migration runs. Each run gets a
+// "goose.run" span, with a "goose.migration" child span per migration and a
+// "goose.statement" span per SQL statement.
+func SetTracer(t Tracer) {
+	if t == nil {
+		t = noopTracer{}
+	}
+	tracer = t
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ map[string]interface{}) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) RecordError(error) {}
+func (noopSpan) End()              {}
+
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+func directionName(direction bool) string {
+	if direction {
+		return "up"
+	}
+	return "down"
+}
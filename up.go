@@ -1,36 +1,65 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
-	"log"
 )
 
 // UpTo migrates up to a specific version.
 func UpTo(db *sql.DB, dir string, version int64) error {
-	migrations, err := CollectMigrations(dir, minVersion, version)
+	return upTo(context.Background(), db, dir, version)
+}
+
+// UpToContext migrates up to a specific version. Canceling ctx stops the
+// migration being applied and the ones after it.
+func UpToContext(ctx context.Context, db *sql.DB, dir string, version int64) error {
+	return upTo(ctx, db, dir, version)
+}
+
+func upTo(ctx context.Context, db *sql.DB, dir string, version int64) error {
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, version)
 	if err != nil {
 		return err
 	}
+	if err := checkVersionPolicy(migrations, false); err != nil {
+		return err
+	}
 
-	for {
-		current, err := GetDBVersion(db)
-		if err != nil {
+	pending, err := pendingMigrations(ctx, db, migrations)
+	if err != nil {
+		return err
+	}
+	if err := validateDependencies(c, migrations, pending); err != nil {
+		return err
+	}
+	if err := checkDDL(c, pending); err != nil {
+		return err
+	}
+	if err := verifyChecksums(c, pending); err != nil {
+		return err
+	}
+	if explainRows > 0 {
+		if err := explainPending(ctx, db, pending); err != nil {
 			return err
 		}
+	}
 
-		next, err := migrations.Next(current)
-		if err != nil {
-			if err == ErrNoNextVersion {
-				log.Printf("goose: no migrations to run. current version: %d\n", current)
-				return nil
-			}
+	for _, m := range pending {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-
-		if err = next.Up(db); err != nil {
+		if err = m.up(ctx, db); err != nil {
 			return err
 		}
 	}
+
+	current, err := GetDBVersionContext(ctx, db)
+	if err != nil {
+		return err
+	}
+	c.log.Printf("goose: no migrations to run. current version: %d\n", current)
+	return nil
 }
 
 // Up applies all available migrations.
@@ -38,29 +67,43 @@ func Up(db *sql.DB, dir string) error {
 	return UpTo(db, dir, maxVersion)
 }
 
+// UpContext applies all available migrations, until ctx is canceled.
+func UpContext(ctx context.Context, db *sql.DB, dir string) error {
+	return upTo(ctx, db, dir, maxVersion)
+}
+
 // UpByOne migrates up by a single version.
 func UpByOne(db *sql.DB, dir string) error {
-	migrations, err := CollectMigrations(dir, minVersion, maxVersion)
+	return upByOne(context.Background(), db, dir)
+}
+
+// UpByOneContext migrates up by a single version, with ctx.
+func UpByOneContext(ctx context.Context, db *sql.DB, dir string) error {
+	return upByOne(ctx, db, dir)
+}
+
+func upByOne(ctx context.Context, db *sql.DB, dir string) error {
+	c := configFrom(ctx)
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
-
-	currentVersion, err := GetDBVersion(db)
-	if err != nil {
+	if err := checkVersionPolicy(migrations, false); err != nil {
 		return err
 	}
 
-	next, err := migrations.Next(currentVersion)
+	pending, err := pendingMigrations(ctx, db, migrations)
 	if err != nil {
-		if err == ErrNoNextVersion {
-			log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
-		}
 		return err
 	}
-
-	if err = next.Up(db); err != nil {
-		return err
+	if len(pending) == 0 {
+		currentVersion, err := GetDBVersionContext(ctx, db)
+		if err != nil {
+			return err
+		}
+		c.log.Printf("goose: no migrations to run. current version: %d\n", currentVersion)
+		return ErrNoNextVersion
 	}
 
-	return nil
+	return pending[0].up(ctx, db)
 }
@@ -0,0 +1,36 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IsUpToDate reports whether every migration in dir is applied, returning
+// the versions that aren't. Unlike the migrating commands, it never writes
+// to the database, which makes it suitable for readiness checks:
+//
+//	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+//		ok, missing, err := goose.IsUpToDate(r.Context(), db, "migrations")
+//		if err != nil || !ok {
+//			http.Error(w, fmt.Sprintf("pending migrations %v: %v", missing, err), http.StatusServiceUnavailable)
+//		}
+//	})
+func IsUpToDate(ctx context.Context, db *sql.DB, dir string) (bool, []int64, error) {
+	migrations, err := collectMigrations(configFrom(ctx), dir, minVersion, maxVersion)
+	if err != nil {
+		return false, nil, err
+	}
+
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var missing []int64
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			missing = append(missing, m.Version)
+		}
+	}
+	return len(missing) == 0, missing, nil
+}
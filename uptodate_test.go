@@ -0,0 +1,46 @@
+//go:build duckdb
+// +build duckdb
+
+package goose
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIsUpToDate(t *testing.T) {
+	db := openDuckDB(t)
+	SetBaseFS(fstest.MapFS{
+		"migrations/00001_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id int);\n")},
+		"migrations/00002_email.sql": {Data: []byte("-- +goose Up\nALTER TABLE users ADD email text;\n")},
+	})
+	defer SetBaseFS(nil)
+	ctx := context.Background()
+
+	// Readiness checks never create the version table.
+	if ok, _, err := IsUpToDate(ctx, db, "migrations"); err == nil || ok {
+		t.Errorf("without a version table: got %v, %v; want an error", ok, err)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT count(*) FROM information_schema.tables WHERE table_name = 'goose_db_version'").Scan(&tables); err != nil || tables != 0 {
+		t.Fatalf("got %d version tables, %v; want none", tables, err)
+	}
+
+	if err := UpTo(db, "migrations", 1); err != nil {
+		t.Fatal(err)
+	}
+	ok, missing, err := IsUpToDate(ctx, db, "migrations")
+	if err != nil || ok || !reflect.DeepEqual(missing, []int64{2}) {
+		t.Errorf("with a pending migration: got %v, %v, %v; want false, [2]", ok, missing, err)
+	}
+
+	if err := Up(db, "migrations"); err != nil {
+		t.Fatal(err)
+	}
+	ok, missing, err = IsUpToDate(ctx, db, "migrations")
+	if err != nil || !ok || len(missing) != 0 {
+		t.Errorf("up to date: got %v, %v, %v; want true", ok, missing, err)
+	}
+}
@@ -0,0 +1,85 @@
+package goose
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// validateFilename checks that a migration file name is portable across
+// operating systems and has an unambiguous, digits-only version prefix.
+func validateFilename(name string) error {
+	if idx := strings.Index(name, "_"); idx <= 0 || strings.Trim(name[:idx], "0123456789") != "" {
+		return fmt.Errorf("%s: version prefix must only contain digits", name)
+	}
+
+	for _, r := range name {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return fmt.Errorf("%s: name contains %q, which is invalid on Windows", name, r)
+		}
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return fmt.Errorf("%s: name ends with a dot or space, which is invalid on Windows", name)
+	}
+	return nil
+}
+
+// validateMigrationNames checks the file names of a set of migrations,
+// rejecting names that only differ by case and distinct prefixes resolving
+// to the same version (e.g. 001_a.sql and 1_b.sql).
+func validateMigrationNames(migrations Migrations) error {
+	byName := map[string]string{}
+	byVersion := map[int64]string{}
+
+	for _, m := range migrations {
+		name := filepath.Base(m.Source)
+		if err := validateFilename(name); err != nil {
+			return &ValidationError{File: m.Source, Err: err}
+		}
+
+		folded := strings.ToLower(name)
+		if other, ok := byName[folded]; ok {
+			return &ValidationError{File: m.Source, Err: fmt.Errorf("%s and %s only differ by case", other, name)}
+		}
+		byName[folded] = name
+
+		if other, ok := byVersion[m.Version]; ok {
+			return &ValidationError{File: m.Source, Err: fmt.Errorf("%s and %s have the same version %d", other, name, m.Version)}
+		}
+		byVersion[m.Version] = name
+	}
+	return nil
+}
+
+// Validate checks the migrations folder without touching the database.
+func Validate(dir string) error {
+	c := packageConfig()
+	migrations, err := collectMigrations(c, dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	if err := checkVersionPolicy(migrations, true); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if err := m.verifyChecksum(c); err != nil {
+			return err
+		}
+	}
+	if err := validateDependencies(c, migrations, migrations); err != nil {
+		return err
+	}
+	if err := checkDDL(c, migrations); err != nil {
+		return err
+	}
+	if !autoNoTransaction {
+		for _, m := range migrations {
+			if err := checkTransactions(c, m); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Printf("goose: %d migrations OK\n", len(migrations))
+	return nil
+}
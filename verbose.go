@@ -0,0 +1,65 @@
+package goose
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const redactedSecret = "***"
+
+var (
+	verbose       = false
+	verboseMaxLen = 500
+)
+
+// SetVerbose sets whether every SQL statement is logged before it runs.
+// Anything looking like a password or a token is redacted.
+func SetVerbose(v bool) {
+	verbose = v
+}
+
+// SetVerboseMaxLen sets the length statements are truncated to in verbose
+// mode. Zero disables truncation.
+func SetVerboseMaxLen(n int) {
+	verboseMaxLen = n
+}
+
+var secretPatterns = []*regexp.Regexp{
+	// PASSWORD 'x', password = "x", token: x, api_key=x, ...
+	regexp.MustCompile(`(?i)(\b(?:password|passwd|pwd|secret|token|api_?key|access_?key)\b\s*(?:=|:|\s)\s*)('[^']*'|"[^"]*"|[^\s,;)]+)`),
+	// MySQL: IDENTIFIED BY 'x'
+	regexp.MustCompile(`(?i)(\bidentified\s+(?:with\s+\S+\s+)?by\s+)('[^']*'|"[^"]*")`),
+	// Credentials in connection URLs: scheme://user:x@host
+	regexp.MustCompile(`(://[^:/@\s]+:)([^@\s]+)(@)`),
+}
+
+// redactSQL masks credentials in a statement.
+func redactSQL(query string) string {
+	for _, re := range secretPatterns {
+		query = re.ReplaceAllStringFunc(query, func(match string) string {
+			sub := re.FindStringSubmatch(match)
+			value := sub[2]
+			if strings.HasPrefix(value, "'") || strings.HasPrefix(value, `"`) {
+				value = value[:1] + redactedSecret + value[:1]
+			} else {
+				value = redactedSecret
+			}
+			return sub[1] + value + strings.Join(sub[3:], "")
+		})
+	}
+	return query
+}
+
+// logStatement logs a statement about to run in verbose mode.
+func logStatement(c *config, file string, i int, query string) {
+	if !c.verbose {
+		return
+	}
+
+	query = strings.TrimSpace(redactSQL(query))
+	if verboseMaxLen > 0 && len(query) > verboseMaxLen {
+		query = fmt.Sprintf("%s... (%d more bytes)", query[:verboseMaxLen], len(query)-verboseMaxLen)
+	}
+	c.log.Printf("goose: %s statement %d:\n%s\n", file, i+1, query)
+}
@@ -1,17 +1,21 @@
 package goose
 
 import (
+	"context"
 	"database/sql"
-	"log"
 )
 
 // Version prints the current version of the database.
 func Version(db *sql.DB, dir string) error {
-	current, err := GetDBVersion(db)
+	return version(context.Background(), db)
+}
+
+func version(ctx context.Context, db *sql.DB) error {
+	current, err := GetDBVersionContext(ctx, db)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("goose: version %v\n", current)
+	configFrom(ctx).log.Printf("goose: version %v\n", current)
 	return nil
 }
@@ -0,0 +1,86 @@
+package goose
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// VersionPolicy is the convention migration versions must follow.
+type VersionPolicy string
+
+// Version policies. Timestamp versions are YYYYMMDDHHMMSS times, sequential
+// versions any other number.
+const (
+	// VersionAny accepts both formats, the default.
+	VersionAny VersionPolicy = ""
+	// VersionTimestamp only accepts timestamp versions.
+	VersionTimestamp VersionPolicy = "timestamp"
+	// VersionSequential only accepts sequential versions.
+	VersionSequential VersionPolicy = "sequential"
+	// VersionHybrid accepts timestamp versions for migrations in development,
+	// which validate refuses until they are renumbered sequentially.
+	VersionHybrid VersionPolicy = "hybrid"
+)
+
+const timestampVersionFormat = "20060102150405"
+
+var versionPolicy = VersionAny
+
+// SetVersionPolicy sets the version format validate and up enforce.
+func SetVersionPolicy(p VersionPolicy) error {
+	switch p {
+	case VersionAny, VersionTimestamp, VersionSequential, VersionHybrid:
+		versionPolicy = p
+		return nil
+	}
+	return fmt.Errorf("%q: unknown version policy, must be timestamp, sequential or hybrid", p)
+}
+
+// isTimestampVersion reports whether v is a YYYYMMDDHHMMSS timestamp.
+func isTimestampVersion(v int64) bool {
+	s := strconv.FormatInt(v, 10)
+	if len(s) != len(timestampVersionFormat) {
+		return false
+	}
+	_, err := time.Parse(timestampVersionFormat, s)
+	return err == nil
+}
+
+// parseDownTarget parses the target of down-to: a version, or an RFC 3339
+// time rolling back the timestamp versions created after it, timestamps
+// being in the configured time zone.
+func parseDownTarget(arg string) (int64, error) {
+	if v, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		return v, nil
+	}
+	t, err := time.Parse(time.RFC3339, arg)
+	if err != nil {
+		return 0, fmt.Errorf("version must be a number or an RFC 3339 time (got '%s')", arg)
+	}
+	// Versions are whole seconds: a version of the target second itself was
+	// created at or before the target time.
+	return strconv.ParseInt(localTime(t).Format(timestampVersionFormat), 10, 64)
+}
+
+// checkVersionPolicy checks the versions of migrations against the policy.
+// In hybrid mode, timestamp versions are only refused when validating.
+func checkVersionPolicy(migrations Migrations, validating bool) error {
+	for _, m := range migrations {
+		timestamp := isTimestampVersion(m.Version)
+		var err error
+		switch {
+		case versionPolicy == VersionTimestamp && !timestamp:
+			err = fmt.Errorf("%s: version must be a YYYYMMDDHHMMSS timestamp", filepath.Base(m.Source))
+		case versionPolicy == VersionSequential && timestamp:
+			err = fmt.Errorf("%s: version must be sequential, not a timestamp", filepath.Base(m.Source))
+		case versionPolicy == VersionHybrid && timestamp && validating:
+			err = fmt.Errorf("%s: timestamp versions must be renumbered sequentially before release", filepath.Base(m.Source))
+		}
+		if err != nil {
+			return &ValidationError{File: m.Source, Err: err}
+		}
+	}
+	return nil
+}
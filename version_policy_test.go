@@ -0,0 +1,68 @@
+package goose
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckVersionPolicy(t *testing.T) {
+	defer SetVersionPolicy(VersionAny)
+
+	sequential := Migrations{{Version: 1, Source: "00001_users.sql"}, {Version: 2, Source: "00002_roles.sql"}}
+	timestamps := Migrations{{Version: 20240312101500, Source: "20240312101500_orders.sql"}}
+	mixed := Migrations{sequential[0], sequential[1], timestamps[0]}
+
+	tests := []struct {
+		policy     VersionPolicy
+		migrations Migrations
+		validating bool
+		ok         bool
+	}{
+		{VersionAny, mixed, true, true},
+		{VersionTimestamp, timestamps, true, true},
+		{VersionTimestamp, mixed, false, false},
+		{VersionSequential, sequential, true, true},
+		{VersionSequential, mixed, false, false},
+		{VersionHybrid, mixed, false, true},
+		{VersionHybrid, mixed, true, false},
+		{VersionHybrid, sequential, true, true},
+	}
+	for i, test := range tests {
+		if err := SetVersionPolicy(test.policy); err != nil {
+			t.Fatal(err)
+		}
+		if err := checkVersionPolicy(test.migrations, test.validating); (err == nil) != test.ok {
+			t.Errorf("%d: %s: got %v", i, test.policy, err)
+		}
+	}
+
+	if err := SetVersionPolicy("semver"); err == nil {
+		t.Error("expected an unknown policy error")
+	}
+	if isTimestampVersion(20241399000000) {
+		t.Error("20241399000000 isn't a valid time")
+	}
+}
+
+func TestParseDownTarget(t *testing.T) {
+	defer SetTimeZone(timeZone)
+	SetTimeZone(time.FixedZone("UTC+2", 2*60*60))
+
+	tests := []struct {
+		arg  string
+		want int64
+	}{
+		{"42", 42},
+		{"20170506082527", 20170506082527},
+		{"2017-05-06T08:25:27Z", 20170506102527},
+		{"2017-05-06T10:25:27+02:00", 20170506102527},
+	}
+	for _, test := range tests {
+		if got, err := parseDownTarget(test.arg); err != nil || got != test.want {
+			t.Errorf("parseDownTarget(%q) = %v, %v; want %v", test.arg, got, err, test.want)
+		}
+	}
+	if _, err := parseDownTarget("yesterday"); err == nil {
+		t.Error("expected an error for an invalid target")
+	}
+}